@@ -0,0 +1,233 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/gin-gonic/gin"
+)
+
+type Direction string
+
+const (
+    NEXT Direction = "next"
+    PREV Direction = "prev"
+)
+
+const (
+    DEFAULT_PAGE_SIZE = 25
+    MAX_PAGE_SIZE = 100
+    MIN_SECRET_LENGTH = 32
+)
+
+// Signs cursors. Set once at startup from CURSOR_SECRET
+var Secret []byte
+
+// A position in a list. SortKey is the value of the column the list is ordered by, and ID breaks ties
+// between rows with the same sort key
+type Cursor struct {
+    SortKey string
+    ID string
+    Direction Direction
+}
+
+type PageRequest struct {
+    Cursor *Cursor
+    PageSize int
+    // The list being paged through. Cursors are signed for it, so they cannot be used on any other list
+    Listing string
+}
+
+// Describes how a list is ordered, so a cursor can be turned into a keyset condition
+type Keyset struct {
+    SortColumn string
+    SortType string
+    IDColumn string
+    IDType string
+    Descending bool
+}
+
+var invalidCursorError = &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid cursor"}
+
+// listing is signed along with the cursor, and the cursor only decodes for the same listing
+func Encode(c Cursor, listing string) (string, error) {
+
+    payload, err := json.Marshal(c)
+
+    if err != nil {
+        return "", customerrors.WrapBasicError(err)
+    }
+
+    encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+    signature := base64.RawURLEncoding.EncodeToString(sign(listing, encodedPayload))
+
+    return fmt.Sprintf("%s.%s", encodedPayload, signature), nil
+}
+
+func Decode(encoded string, listing string) (*Cursor, error) {
+
+    encodedPayload, encodedSignature, found := strings.Cut(encoded, ".")
+
+    if !found {
+        return nil, invalidCursorError
+    }
+
+    signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+
+    if err != nil || !hmac.Equal(signature, sign(listing, encodedPayload)) {
+        return nil, invalidCursorError
+    }
+
+    payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+    if err != nil {
+        return nil, invalidCursorError
+    }
+
+    c := &Cursor{}
+    err = json.Unmarshal(payload, c)
+
+    if err != nil || (c.Direction != NEXT && c.Direction != PREV) {
+        return nil, invalidCursorError
+    }
+
+    return c, nil
+}
+
+// Reads the cursor and pageSize query parameters. The listing is the path along with every other query parameter, so a
+// cursor only works on the list it came from, with the same filters
+func ParsePageRequest(c *gin.Context) (*PageRequest, error) {
+
+    query := c.Request.URL.Query()
+    query.Del("cursor")
+    query.Del("pageSize")
+
+    page := &PageRequest{PageSize: DEFAULT_PAGE_SIZE, Listing: fmt.Sprintf("%s?%s", c.Request.URL.Path, query.Encode())}
+
+    if pageSize := c.Query("pageSize"); pageSize != "" {
+        size, err := strconv.Atoi(pageSize)
+
+        if err != nil || size < 1 || size > MAX_PAGE_SIZE {
+            return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("pageSize must be between 1 and %d", MAX_PAGE_SIZE)}
+        }
+
+        page.PageSize = size
+    }
+
+    if encoded := c.Query("cursor"); encoded != "" {
+        decoded, err := Decode(encoded, page.Listing)
+
+        if err != nil {
+            return nil, err
+        }
+
+        page.Cursor = decoded
+    }
+
+    return page, nil
+}
+
+// One more row than the page size is fetched, to tell if there are more rows after the page
+func(p *PageRequest) Limit() int {
+    return p.PageSize + 1
+}
+
+// Builds the keyset condition and ordering for the page. The condition starts with AND, so it can be appended
+// to an existing WHERE clause. Placeholders are numbered starting from firstArg
+func(p *PageRequest) Clause(k Keyset, firstArg int) (string, string, []any) {
+
+    descending := k.Descending
+
+    if p.Cursor != nil && p.Cursor.Direction == PREV {
+        descending = !descending
+    }
+
+    comparison := ">"
+    order := "ASC"
+
+    if descending {
+        comparison = "<"
+        order = "DESC"
+    }
+
+    orderBy := fmt.Sprintf("ORDER BY %s %s, %s %s", k.SortColumn, order, k.IDColumn, order)
+
+    if p.Cursor == nil {
+        return "", orderBy, []any{}
+    }
+
+    condition := fmt.Sprintf("AND (%s, %s) %s ($%d::%s, $%d::%s)", k.SortColumn, k.IDColumn, comparison, firstArg, k.SortType, firstArg+1, k.IDType)
+
+    return condition, orderBy, []any{p.Cursor.SortKey, p.Cursor.ID}
+}
+
+// Turns the rows fetched for a page into a response. keyOf returns the sort key and ID of a row
+func BuildPage[T any](rows []T, page *PageRequest, keyOf func(T) (string, string)) (responses.PaginationResponse[[]T], error) {
+
+    response := responses.PaginationResponse[[]T]{PageSize: page.PageSize}
+
+    movingBackwards := page.Cursor != nil && page.Cursor.Direction == PREV
+    moreInDirection := len(rows) > page.PageSize
+
+    if moreInDirection {
+        rows = rows[:page.PageSize]
+    }
+
+    if movingBackwards {
+        slices.Reverse(rows)
+    }
+
+    response.DataResponse = rows
+
+    if len(rows) == 0 {
+        return response, nil
+    }
+
+    hasNext := moreInDirection || movingBackwards
+    response.HasMore = hasNext
+    hasPrev := (moreInDirection && movingBackwards) || (page.Cursor != nil && !movingBackwards)
+
+    if hasNext {
+        sortKey, id := keyOf(rows[len(rows)-1])
+        next, err := Encode(Cursor{SortKey: sortKey, ID: id, Direction: NEXT}, page.Listing)
+
+        if err != nil {
+            return response, err
+        }
+
+        response.NextCursor = next
+    }
+
+    if hasPrev {
+        sortKey, id := keyOf(rows[0])
+        prev, err := Encode(Cursor{SortKey: sortKey, ID: id, Direction: PREV}, page.Listing)
+
+        if err != nil {
+            return response, err
+        }
+
+        response.PrevCursor = prev
+    }
+
+    return response, nil
+}
+
+func TimeKey(t time.Time) string {
+    return t.UTC().Format(time.RFC3339Nano)
+}
+
+func sign(listing string, payload string) []byte {
+    mac := hmac.New(sha256.New, Secret)
+    mac.Write([]byte(listing + "." + payload))
+    return mac.Sum(nil)
+}
@@ -0,0 +1,167 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testListing = "/users/1/posts?"
+
+func useTestSecret(t *testing.T) {
+	previous := Secret
+	Secret = []byte(strings.Repeat("s", MIN_SECRET_LENGTH))
+	t.Cleanup(func() { Secret = previous })
+}
+
+func TestEncodeDecode(t *testing.T) {
+
+	useTestSecret(t)
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "next", cursor: Cursor{SortKey: "2026-10-18T12:00:00Z", ID: "post-1", Direction: NEXT}},
+		{name: "prev", cursor: Cursor{SortKey: "42", ID: "post-2", Direction: PREV}},
+		{name: "empty sort key", cursor: Cursor{SortKey: "", ID: "post-3", Direction: NEXT}},
+		{name: "characters that need escaping", cursor: Cursor{SortKey: "a.b\"c/d?e=f", ID: "post-4", Direction: PREV}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			encoded, err := Encode(test.cursor, testListing)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			decoded, err := Decode(encoded, testListing)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *decoded != test.cursor {
+				t.Fatalf("decoded %+v, want %+v", *decoded, test.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+
+	useTestSecret(t)
+
+	encoded, err := Encode(Cursor{SortKey: "1", ID: "post-1", Direction: NEXT}, testListing)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encodedPayload, encodedSignature, _ := strings.Cut(encoded, ".")
+
+	forgedPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"SortKey":"1","ID":"post-2","Direction":"next"}`))
+
+	secret := Secret
+	Secret = []byte(strings.Repeat("o", MIN_SECRET_LENGTH))
+	signedWithOtherSecret, err := Encode(Cursor{SortKey: "1", ID: "post-1", Direction: NEXT}, testListing)
+	Secret = secret
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknownDirection, err := Encode(Cursor{SortKey: "1", ID: "post-1", Direction: "sideways"}, testListing)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		listing string
+	}{
+		{name: "empty", encoded: "", listing: testListing},
+		{name: "no signature", encoded: encodedPayload, listing: testListing},
+		{name: "forged payload", encoded: forgedPayload + "." + encodedSignature, listing: testListing},
+		{name: "changed signature", encoded: encodedPayload + "." + strings.Repeat("A", len(encodedSignature)), listing: testListing},
+		{name: "signature is not base64", encoded: encodedPayload + ".!!!", listing: testListing},
+		{name: "other secret", encoded: signedWithOtherSecret, listing: testListing},
+		{name: "other list", encoded: encoded, listing: "/users/2/posts?"},
+		{name: "other filters", encoded: encoded, listing: testListing + "sort=rating"},
+		{name: "unknown direction", encoded: unknownDirection, listing: testListing},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if decoded, err := Decode(test.encoded, test.listing); err != invalidCursorError {
+				t.Fatalf("Decode = %+v, %v, want the invalid cursor error", decoded, err)
+			}
+		})
+	}
+}
+
+func TestParsePageRequest(t *testing.T) {
+
+	useTestSecret(t)
+	gin.SetMode(gin.TestMode)
+
+	encoded, err := Encode(Cursor{SortKey: "1", ID: "post-1", Direction: NEXT}, "/posts?sort=rating")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		target       string
+		wantPageSize int
+		wantCursor   bool
+		wantError    bool
+	}{
+		{name: "defaults", target: "/posts", wantPageSize: DEFAULT_PAGE_SIZE},
+		{name: "page size", target: "/posts?pageSize=10", wantPageSize: 10},
+		{name: "page size too small", target: "/posts?pageSize=0", wantError: true},
+		{name: "page size too large", target: "/posts?pageSize=101", wantError: true},
+		{name: "page size not a number", target: "/posts?pageSize=ten", wantError: true},
+		{name: "cursor for the same list", target: "/posts?sort=rating&pageSize=10&cursor=" + encoded, wantPageSize: 10, wantCursor: true},
+		{name: "cursor for other filters", target: "/posts?sort=date&cursor=" + encoded, wantError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, test.target, nil)
+
+			page, err := ParsePageRequest(c)
+
+			if test.wantError {
+				if err == nil {
+					t.Fatalf("ParsePageRequest = %+v, want an error", page)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if page.PageSize != test.wantPageSize {
+				t.Fatalf("page size = %d, want %d", page.PageSize, test.wantPageSize)
+			}
+
+			if (page.Cursor != nil) != test.wantCursor {
+				t.Fatalf("cursor = %+v, want a cursor %v", page.Cursor, test.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE feed_items (
+    ownerSpotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    posterSpotifyID varchar(255) NOT NULL,
+    songID varchar(255) NOT NULL,
+    createdAt timestamp with time zone NOT NULL,
+    PRIMARY KEY (ownerSpotifyID, posterSpotifyID, songID),
+    FOREIGN KEY (posterSpotifyID, songID) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX feed_items_owner_createdat_idx ON feed_items (ownerSpotifyID, createdAt DESC);
+CREATE INDEX feed_items_post_idx ON feed_items (posterSpotifyID, songID);
+
+INSERT INTO feed_items (ownerSpotifyID, posterSpotifyID, songID, createdAt)
+SELECT followers.follower, posts.posterspotifyid, posts.songid, posts.createdat
+FROM followers
+INNER JOIN posts ON posts.posterspotifyid = followers.userfollowed;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE feed_items;
+-- +goose StatementEnd
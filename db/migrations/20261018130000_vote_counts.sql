@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN likes int NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN dislikes int NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN likes int NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN dislikes int NOT NULL DEFAULT 0;
+
+UPDATE posts SET likes = votes.likes, dislikes = votes.dislikes
+FROM (
+    SELECT posterspotifyid, postsongid, count(*) FILTER (WHERE liked) AS likes, count(*) FILTER (WHERE NOT liked) AS dislikes
+    FROM post_votes
+    GROUP BY posterspotifyid, postsongid
+) AS votes
+WHERE posts.posterspotifyid = votes.posterspotifyid AND posts.songid = votes.postsongid;
+
+UPDATE comments SET likes = votes.likes, dislikes = votes.dislikes
+FROM (
+    SELECT commentid, count(*) FILTER (WHERE liked) AS likes, count(*) FILTER (WHERE NOT liked) AS dislikes
+    FROM comment_votes
+    GROUP BY commentid
+) AS votes
+WHERE comments.commentid = votes.commentid;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE comments DROP COLUMN dislikes;
+ALTER TABLE comments DROP COLUMN likes;
+ALTER TABLE posts DROP COLUMN dislikes;
+ALTER TABLE posts DROP COLUMN likes;
+-- +goose StatementEnd
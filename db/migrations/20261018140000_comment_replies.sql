@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE comments ADD COLUMN parentcommentid int references comments(commentid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE comments ADD COLUMN depth int NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN replycount int NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN deleted boolean NOT NULL DEFAULT false;
+
+CREATE INDEX comments_post_createdat_idx ON comments (posterspotifyid, songid, createdat DESC, commentid DESC) WHERE parentcommentid IS NULL;
+CREATE INDEX comments_parent_createdat_idx ON comments (parentcommentid, createdat, commentid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX comments_parent_createdat_idx;
+DROP INDEX comments_post_createdat_idx;
+DELETE FROM comments WHERE parentcommentid IS NOT NULL;
+DELETE FROM comments WHERE deleted;
+ALTER TABLE comments DROP COLUMN deleted;
+ALTER TABLE comments DROP COLUMN replycount;
+ALTER TABLE comments DROP COLUMN depth;
+ALTER TABLE comments DROP COLUMN parentcommentid;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(songname, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(albumname, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(review, '')), 'C')
+) STORED;
+
+ALTER TABLE comments ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', coalesce(commenttext, ''))
+) STORED;
+
+ALTER TABLE users ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(username, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(bio, '')), 'B')
+) STORED;
+
+CREATE INDEX posts_searchvector_idx ON posts USING GIN (searchvector);
+CREATE INDEX comments_searchvector_idx ON comments USING GIN (searchvector);
+CREATE INDEX users_searchvector_idx ON users USING GIN (searchvector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_searchvector_idx;
+DROP INDEX comments_searchvector_idx;
+DROP INDEX posts_searchvector_idx;
+ALTER TABLE users DROP COLUMN searchvector;
+ALTER TABLE comments DROP COLUMN searchvector;
+ALTER TABLE posts DROP COLUMN searchvector;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    exchange varchar(255) NOT NULL,
+    routingKey varchar(255) NOT NULL,
+    payload jsonb NOT NULL,
+    createdAt timestamp with time zone NOT NULL,
+    attempts int NOT NULL DEFAULT 0,
+    nextAttemptAt timestamp with time zone NOT NULL,
+    lastError text,
+    sentAt timestamp with time zone
+);
+
+CREATE INDEX outbox_messages_pending_idx ON outbox_messages (nextAttemptAt, id) WHERE sentAt IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox_messages;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notifications (
+    notificationID BIGSERIAL PRIMARY KEY,
+    recipientSpotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    notificationType varchar(32) NOT NULL,
+    subjectKey varchar(512) NOT NULL,
+    posterSpotifyID varchar(255),
+    songID varchar(255),
+    commentID int references comments(commentid) ON DELETE CASCADE,
+    actorCount int NOT NULL DEFAULT 0,
+    latestActorSpotifyID varchar(255),
+    read boolean NOT NULL DEFAULT false,
+    createdAt timestamp with time zone NOT NULL,
+    updatedAt timestamp with time zone NOT NULL,
+    FOREIGN KEY (posterSpotifyID, songID) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- Repeat actions on the same subject are folded into the recipient's one unread notification for it
+CREATE UNIQUE INDEX notifications_unread_subject_idx ON notifications (recipientSpotifyID, notificationType, subjectKey) WHERE NOT read;
+CREATE INDEX notifications_recipient_updatedat_idx ON notifications (recipientSpotifyID, updatedAt DESC, notificationID DESC);
+
+CREATE TABLE notification_actors (
+    notificationID bigint references notifications(notificationid) ON DELETE CASCADE NOT NULL,
+    actorSpotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    PRIMARY KEY (notificationID, actorSpotifyID)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_actors;
+DROP TABLE notifications;
+-- +goose StatementEnd
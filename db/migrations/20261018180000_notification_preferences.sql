@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notification_preferences (
+    spotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    eventType varchar(64) NOT NULL,
+    delivery varchar(16) NOT NULL,
+    updatedAt timestamp with time zone NOT NULL,
+    PRIMARY KEY (spotifyID, eventType)
+);
+
+CREATE TABLE pending_digest_events (
+    id BIGSERIAL PRIMARY KEY,
+    recipientSpotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    delivery varchar(16) NOT NULL,
+    eventType varchar(64) NOT NULL,
+    event jsonb NOT NULL,
+    createdAt timestamp with time zone NOT NULL
+);
+
+CREATE INDEX pending_digest_events_due_idx ON pending_digest_events (delivery, createdAt);
+CREATE INDEX pending_digest_events_recipient_idx ON pending_digest_events (recipientSpotifyID, delivery, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE pending_digest_events;
+DROP TABLE notification_preferences;
+-- +goose StatementEnd
@@ -0,0 +1,100 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE artists (
+    artistID varchar(255) PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    fetchedAt timestamp with time zone NOT NULL
+);
+
+CREATE TABLE albums (
+    albumID varchar(255) PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    albumArtURI varchar(255),
+    releaseDate varchar(10),
+    releaseDatePrecision varchar(5),
+    fetchedAt timestamp with time zone NOT NULL,
+    searchvector tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'B')) STORED
+);
+
+CREATE TABLE album_artists (
+    albumID varchar(255) references albums(albumid) ON DELETE CASCADE NOT NULL,
+    artistID varchar(255) references artists(artistid) ON DELETE CASCADE NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (albumID, artistID)
+);
+
+CREATE TABLE tracks (
+    trackID varchar(255) PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    albumID varchar(255) references albums(albumid) NOT NULL,
+    durationMs int,
+    fetchedAt timestamp with time zone NOT NULL,
+    searchvector tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A')) STORED
+);
+
+CREATE TABLE track_artists (
+    trackID varchar(255) references tracks(trackid) ON DELETE CASCADE NOT NULL,
+    artistID varchar(255) references artists(artistid) ON DELETE CASCADE NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (trackID, artistID)
+);
+
+CREATE INDEX tracks_albumid_idx ON tracks (albumID);
+CREATE INDEX tracks_fetchedat_idx ON tracks (fetchedAt);
+
+-- Existing posts seed the catalog with what they copied from Spotify. The rows are marked as fetched at the epoch, so
+-- the catalog refresher fills in the artists, durations and release dates
+INSERT INTO albums (albumID, name, albumArtURI, fetchedAt)
+SELECT DISTINCT ON (albumid) albumid, albumname, albumarturi, 'epoch' FROM posts ORDER BY albumid, updatedat DESC;
+
+INSERT INTO tracks (trackID, name, albumID, fetchedAt)
+SELECT DISTINCT ON (songid) songid, songname, albumid, 'epoch' FROM posts ORDER BY songid, updatedat DESC;
+
+ALTER TABLE posts ADD FOREIGN KEY (songID) references tracks(trackid);
+
+ALTER TABLE posts DROP COLUMN searchvector;
+ALTER TABLE posts DROP COLUMN songName;
+ALTER TABLE posts DROP COLUMN albumID;
+ALTER TABLE posts DROP COLUMN albumName;
+ALTER TABLE posts DROP COLUMN albumArtURI;
+
+ALTER TABLE posts ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(review, '')), 'C')
+) STORED;
+
+CREATE INDEX posts_searchvector_idx ON posts USING GIN (searchvector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE posts DROP COLUMN searchvector;
+
+ALTER TABLE posts ADD COLUMN songName varchar(255);
+ALTER TABLE posts ADD COLUMN albumID varchar(255);
+ALTER TABLE posts ADD COLUMN albumName varchar(255);
+ALTER TABLE posts ADD COLUMN albumArtURI varchar(255);
+
+UPDATE posts SET songName = tracks.name, albumID = albums.albumid, albumName = albums.name, albumArtURI = albums.albumarturi
+FROM tracks INNER JOIN albums ON albums.albumid = tracks.albumid
+WHERE tracks.trackid = posts.songid;
+
+ALTER TABLE posts ALTER COLUMN songName SET NOT NULL;
+ALTER TABLE posts ALTER COLUMN albumID SET NOT NULL;
+ALTER TABLE posts ALTER COLUMN albumName SET NOT NULL;
+
+ALTER TABLE posts ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(songname, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(albumname, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(review, '')), 'C')
+) STORED;
+
+CREATE INDEX posts_searchvector_idx ON posts USING GIN (searchvector);
+
+ALTER TABLE posts DROP CONSTRAINT posts_songid_fkey;
+
+DROP TABLE track_artists;
+DROP TABLE tracks;
+DROP TABLE album_artists;
+DROP TABLE albums;
+DROP TABLE artists;
+-- +goose StatementEnd
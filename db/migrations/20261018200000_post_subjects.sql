@@ -0,0 +1,100 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE artists ADD COLUMN imageURI varchar(255);
+ALTER TABLE artists ADD COLUMN searchvector tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A')) STORED;
+
+-- A post is about a track, an album or an artist. songID holds the Spotify ID of the subject, whatever its type, so
+-- it can no longer reference tracks
+ALTER TABLE posts DROP CONSTRAINT posts_songid_fkey;
+
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_posterspotifyid_postsongid_fkey;
+ALTER TABLE comments DROP CONSTRAINT comments_posterspotifyid_songid_fkey;
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_posterspotifyid_songid_fkey;
+ALTER TABLE notifications DROP CONSTRAINT notifications_posterspotifyid_songid_fkey;
+
+ALTER TABLE posts ADD COLUMN subjectType varchar(16) NOT NULL DEFAULT 'TRACK' CHECK (subjectType IN ('TRACK', 'ALBUM', 'ARTIST'));
+ALTER TABLE post_votes ADD COLUMN postSubjectType varchar(16) NOT NULL DEFAULT 'TRACK';
+ALTER TABLE comments ADD COLUMN subjectType varchar(16);
+ALTER TABLE feed_items ADD COLUMN subjectType varchar(16) NOT NULL DEFAULT 'TRACK';
+ALTER TABLE notifications ADD COLUMN subjectType varchar(16);
+
+UPDATE comments SET subjectType = 'TRACK' WHERE songID IS NOT NULL;
+UPDATE notifications SET subjectType = 'TRACK' WHERE songID IS NOT NULL;
+
+-- Unread notifications keep collecting actors once their subject keys include the subject type
+UPDATE notifications SET subjectKey = coalesce(posterSpotifyID, '') || ':' || coalesce(subjectType, '') || ':' || coalesce(songID, '') || coalesce(':' || commentID, '');
+
+ALTER TABLE posts DROP CONSTRAINT posts_pkey;
+ALTER TABLE posts ADD PRIMARY KEY (posterSpotifyID, subjectType, songID);
+
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_pkey;
+ALTER TABLE post_votes ADD PRIMARY KEY (voterSpotifyID, posterSpotifyID, postSubjectType, postSongID);
+ALTER TABLE post_votes ADD FOREIGN KEY (posterSpotifyID, postSubjectType, postSongID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE comments ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_pkey;
+ALTER TABLE feed_items ADD PRIMARY KEY (ownerSpotifyID, posterSpotifyID, subjectType, songID);
+ALTER TABLE feed_items ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE notifications ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+DROP INDEX feed_items_post_idx;
+CREATE INDEX feed_items_post_idx ON feed_items (posterSpotifyID, subjectType, songID);
+
+DROP INDEX comments_post_createdat_idx;
+CREATE INDEX comments_post_createdat_idx ON comments (posterspotifyid, subjecttype, songid, createdat DESC, commentid DESC) WHERE parentcommentid IS NULL;
+
+-- Album pages gather the reviews of the album and of its tracks
+CREATE INDEX posts_subject_idx ON posts (subjectType, songID);
+CREATE INDEX albums_fetchedat_idx ON albums (fetchedAt);
+CREATE INDEX artists_fetchedat_idx ON artists (fetchedAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX artists_fetchedat_idx;
+DROP INDEX albums_fetchedat_idx;
+DROP INDEX posts_subject_idx;
+
+-- Only track posts can be kept, everything about them cascades from the posts
+DELETE FROM posts WHERE subjectType <> 'TRACK';
+
+DROP INDEX comments_post_createdat_idx;
+CREATE INDEX comments_post_createdat_idx ON comments (posterspotifyid, songid, createdat DESC, commentid DESC) WHERE parentcommentid IS NULL;
+
+DROP INDEX feed_items_post_idx;
+CREATE INDEX feed_items_post_idx ON feed_items (posterSpotifyID, songID);
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_posterspotifyid_subjecttype_songid_fkey;
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_posterspotifyid_subjecttype_songid_fkey;
+ALTER TABLE comments DROP CONSTRAINT comments_posterspotifyid_subjecttype_songid_fkey;
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_posterspotifyid_postsubjecttype_postsongid_fkey;
+
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_pkey;
+ALTER TABLE feed_items ADD PRIMARY KEY (ownerSpotifyID, posterSpotifyID, songID);
+
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_pkey;
+ALTER TABLE post_votes ADD PRIMARY KEY (voterSpotifyID, posterSpotifyID, postSongID);
+
+ALTER TABLE posts DROP CONSTRAINT posts_pkey;
+ALTER TABLE posts ADD PRIMARY KEY (posterSpotifyID, songID);
+
+UPDATE notifications SET subjectKey = coalesce(posterSpotifyID, '') || ':' || coalesce(songID, '') || coalesce(':' || commentID, '');
+
+ALTER TABLE notifications DROP COLUMN subjectType;
+ALTER TABLE feed_items DROP COLUMN subjectType;
+ALTER TABLE comments DROP COLUMN subjectType;
+ALTER TABLE post_votes DROP COLUMN postSubjectType;
+ALTER TABLE posts DROP COLUMN subjectType;
+
+ALTER TABLE notifications ADD FOREIGN KEY (posterSpotifyID, songID) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE feed_items ADD FOREIGN KEY (posterSpotifyID, songID) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE comments ADD FOREIGN KEY (posterspotifyid, songid) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE post_votes ADD FOREIGN KEY (posterSpotifyID, postSongID) references posts(posterspotifyid, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE posts ADD FOREIGN KEY (songID) references tracks(trackid);
+
+ALTER TABLE artists DROP COLUMN searchvector;
+ALTER TABLE artists DROP COLUMN imageURI;
+-- +goose StatementEnd
@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE subject_rating_stats (
+    subjectType varchar(16) NOT NULL,
+    subjectID varchar(255) NOT NULL,
+    reviewCount int NOT NULL DEFAULT 0,
+    ratingSum bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (subjectType, subjectID)
+);
+
+CREATE TABLE subject_rating_counts (
+    subjectType varchar(16) NOT NULL,
+    subjectID varchar(255) NOT NULL,
+    rating int NOT NULL,
+    reviewCount int NOT NULL DEFAULT 0,
+    PRIMARY KEY (subjectType, subjectID, rating)
+);
+
+-- Posts are also removed by cascades when their poster is deleted, so the stats are kept up to date by a trigger rather
+-- than by every statement that writes posts
+CREATE FUNCTION update_subject_rating_stats() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE subject_rating_stats SET reviewCount = reviewCount - 1, ratingSum = ratingSum - OLD.rating
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID;
+
+        UPDATE subject_rating_counts SET reviewCount = reviewCount - 1
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID AND rating = OLD.rating;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'INSERT') THEN
+        INSERT INTO subject_rating_stats (subjectType, subjectID, reviewCount, ratingSum) VALUES (NEW.subjectType, NEW.songID, 1, NEW.rating)
+        ON CONFLICT (subjectType, subjectID) DO UPDATE SET reviewCount = subject_rating_stats.reviewCount + 1, ratingSum = subject_rating_stats.ratingSum + NEW.rating;
+
+        INSERT INTO subject_rating_counts (subjectType, subjectID, rating, reviewCount) VALUES (NEW.subjectType, NEW.songID, NEW.rating, 1)
+        ON CONFLICT (subjectType, subjectID, rating) DO UPDATE SET reviewCount = subject_rating_counts.reviewCount + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_subject_rating_stats AFTER INSERT OR DELETE OR UPDATE OF rating, subjectType, songID ON posts
+FOR EACH ROW EXECUTE FUNCTION update_subject_rating_stats();
+
+INSERT INTO subject_rating_stats (subjectType, subjectID, reviewCount, ratingSum)
+SELECT subjectType, songID, count(*), sum(rating) FROM posts GROUP BY subjectType, songID;
+
+INSERT INTO subject_rating_counts (subjectType, subjectID, rating, reviewCount)
+SELECT subjectType, songID, rating, count(*) FROM posts GROUP BY subjectType, songID, rating;
+
+-- Subject pages show the most liked reviews first
+CREATE INDEX posts_subject_likes_idx ON posts (subjectType, songID, likes DESC, createdAt DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX posts_subject_likes_idx;
+DROP TRIGGER posts_subject_rating_stats ON posts;
+DROP FUNCTION update_subject_rating_stats();
+DROP TABLE subject_rating_counts;
+DROP TABLE subject_rating_stats;
+-- +goose StatementEnd
@@ -0,0 +1,94 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The type of a column cannot change while a trigger is defined on it
+DROP TRIGGER posts_subject_rating_stats ON posts;
+
+-- Ratings are fixed-point numbers of stars, so half stars and other steps are stored exactly. A null rating is a review
+-- without one. Posts written before this stored 0 when they had no rating, and cannot be told apart from real 0s, so
+-- they keep their rating
+ALTER TABLE posts ALTER COLUMN rating TYPE numeric(5, 2);
+ALTER TABLE posts ALTER COLUMN rating DROP NOT NULL;
+
+-- reviewCount counts every review, and ratedCount only the ones with a rating, which the average is taken over
+ALTER TABLE subject_rating_stats ADD COLUMN ratedCount int NOT NULL DEFAULT 0;
+UPDATE subject_rating_stats SET ratedCount = reviewCount;
+ALTER TABLE subject_rating_stats ALTER COLUMN ratingSum TYPE numeric;
+
+ALTER TABLE subject_rating_counts ALTER COLUMN rating TYPE numeric(5, 2);
+
+CREATE OR REPLACE FUNCTION update_subject_rating_stats() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE subject_rating_stats SET reviewCount = reviewCount - 1, ratedCount = ratedCount - (OLD.rating IS NOT NULL)::int, ratingSum = ratingSum - coalesce(OLD.rating, 0)
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID;
+
+        UPDATE subject_rating_counts SET reviewCount = reviewCount - 1
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID AND rating = OLD.rating;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'INSERT') THEN
+        INSERT INTO subject_rating_stats (subjectType, subjectID, reviewCount, ratedCount, ratingSum) VALUES (NEW.subjectType, NEW.songID, 1, (NEW.rating IS NOT NULL)::int, coalesce(NEW.rating, 0))
+        ON CONFLICT (subjectType, subjectID) DO UPDATE SET reviewCount = subject_rating_stats.reviewCount + 1, ratedCount = subject_rating_stats.ratedCount + EXCLUDED.ratedCount, ratingSum = subject_rating_stats.ratingSum + EXCLUDED.ratingSum;
+
+        IF NEW.rating IS NOT NULL THEN
+            INSERT INTO subject_rating_counts (subjectType, subjectID, rating, reviewCount) VALUES (NEW.subjectType, NEW.songID, NEW.rating, 1)
+            ON CONFLICT (subjectType, subjectID, rating) DO UPDATE SET reviewCount = subject_rating_counts.reviewCount + 1;
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_subject_rating_stats AFTER INSERT OR DELETE OR UPDATE OF rating, subjectType, songID ON posts
+FOR EACH ROW EXECUTE FUNCTION update_subject_rating_stats();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER posts_subject_rating_stats ON posts;
+
+-- Whole stars only, with reviews that have no rating going back to 0
+UPDATE posts SET rating = coalesce(round(rating), 0);
+ALTER TABLE posts ALTER COLUMN rating SET NOT NULL;
+ALTER TABLE posts ALTER COLUMN rating TYPE int;
+
+-- The statistics are rebuilt rather than converted, since rounding merges ratings
+DELETE FROM subject_rating_counts;
+DELETE FROM subject_rating_stats;
+
+ALTER TABLE subject_rating_counts ALTER COLUMN rating TYPE int;
+ALTER TABLE subject_rating_stats ALTER COLUMN ratingSum TYPE bigint;
+ALTER TABLE subject_rating_stats DROP COLUMN ratedCount;
+
+CREATE OR REPLACE FUNCTION update_subject_rating_stats() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE subject_rating_stats SET reviewCount = reviewCount - 1, ratingSum = ratingSum - OLD.rating
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID;
+
+        UPDATE subject_rating_counts SET reviewCount = reviewCount - 1
+        WHERE subjectType = OLD.subjectType AND subjectID = OLD.songID AND rating = OLD.rating;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'INSERT') THEN
+        INSERT INTO subject_rating_stats (subjectType, subjectID, reviewCount, ratingSum) VALUES (NEW.subjectType, NEW.songID, 1, NEW.rating)
+        ON CONFLICT (subjectType, subjectID) DO UPDATE SET reviewCount = subject_rating_stats.reviewCount + 1, ratingSum = subject_rating_stats.ratingSum + NEW.rating;
+
+        INSERT INTO subject_rating_counts (subjectType, subjectID, rating, reviewCount) VALUES (NEW.subjectType, NEW.songID, NEW.rating, 1)
+        ON CONFLICT (subjectType, subjectID, rating) DO UPDATE SET reviewCount = subject_rating_counts.reviewCount + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_subject_rating_stats AFTER INSERT OR DELETE OR UPDATE OF rating, subjectType, songID ON posts
+FOR EACH ROW EXECUTE FUNCTION update_subject_rating_stats();
+
+INSERT INTO subject_rating_stats (subjectType, subjectID, reviewCount, ratingSum)
+SELECT subjectType, songID, count(*), sum(rating) FROM posts GROUP BY subjectType, songID;
+
+INSERT INTO subject_rating_counts (subjectType, subjectID, rating, reviewCount)
+SELECT subjectType, songID, rating, count(*) FROM posts GROUP BY subjectType, songID, rating;
+-- +goose StatementEnd
@@ -0,0 +1,132 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Posts are identified by their own ID rather than by their poster and subject, so a user can log every listen of a
+-- subject as a post of its own. listenedAt is when the listen was, which can be earlier than the post
+ALTER TABLE posts ADD COLUMN postID BIGSERIAL;
+ALTER TABLE posts ADD COLUMN listenedAt timestamp with time zone;
+ALTER TABLE posts ADD COLUMN relisten boolean NOT NULL DEFAULT false;
+UPDATE posts SET listenedAt = createdAt;
+ALTER TABLE posts ALTER COLUMN listenedAt SET NOT NULL;
+
+ALTER TABLE post_votes ADD COLUMN postID bigint;
+ALTER TABLE comments ADD COLUMN postID bigint;
+ALTER TABLE feed_items ADD COLUMN postID bigint;
+ALTER TABLE notifications ADD COLUMN postID bigint;
+
+UPDATE post_votes SET postID = posts.postID FROM posts
+WHERE posts.posterSpotifyID = post_votes.posterSpotifyID AND posts.subjectType = post_votes.postSubjectType AND posts.songID = post_votes.postSongID;
+
+UPDATE comments SET postID = posts.postID FROM posts
+WHERE posts.posterSpotifyID = comments.posterSpotifyID AND posts.subjectType = comments.subjectType AND posts.songID = comments.songID;
+
+UPDATE feed_items SET postID = posts.postID FROM posts
+WHERE posts.posterSpotifyID = feed_items.posterSpotifyID AND posts.subjectType = feed_items.subjectType AND posts.songID = feed_items.songID;
+
+UPDATE notifications SET postID = posts.postID FROM posts
+WHERE posts.posterSpotifyID = notifications.posterSpotifyID AND posts.subjectType = notifications.subjectType AND posts.songID = notifications.songID;
+
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_posterspotifyid_postsubjecttype_postsongid_fkey;
+ALTER TABLE comments DROP CONSTRAINT comments_posterspotifyid_subjecttype_songid_fkey;
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_posterspotifyid_subjecttype_songid_fkey;
+ALTER TABLE notifications DROP CONSTRAINT notifications_posterspotifyid_subjecttype_songid_fkey;
+
+ALTER TABLE posts DROP CONSTRAINT posts_pkey;
+ALTER TABLE posts ADD PRIMARY KEY (postID);
+
+-- A users posts are listed newest first, and their posts about a subject are looked up to flag relistens
+CREATE INDEX posts_poster_createdat_idx ON posts (posterSpotifyID, createdAt DESC, postID DESC);
+CREATE INDEX posts_poster_subject_idx ON posts (posterSpotifyID, subjectType, songID, listenedAt DESC);
+
+-- Votes only need the post they are on
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_pkey;
+ALTER TABLE post_votes ALTER COLUMN postID SET NOT NULL;
+ALTER TABLE post_votes ADD PRIMARY KEY (voterSpotifyID, postID);
+ALTER TABLE post_votes ADD FOREIGN KEY (postID) references posts(postid) ON DELETE CASCADE;
+ALTER TABLE post_votes DROP COLUMN posterSpotifyID;
+ALTER TABLE post_votes DROP COLUMN postSubjectType;
+ALTER TABLE post_votes DROP COLUMN postSongID;
+CREATE INDEX post_votes_post_idx ON post_votes (postID, voterSpotifyID);
+
+-- Comments and notifications keep the poster and subject of their post, which never change, so they can be shown
+-- without reading the post
+ALTER TABLE comments ALTER COLUMN postID SET NOT NULL;
+ALTER TABLE comments ADD FOREIGN KEY (postID) references posts(postid) ON DELETE CASCADE;
+DROP INDEX comments_post_createdat_idx;
+CREATE INDEX comments_post_createdat_idx ON comments (postID, createdAt DESC, commentID DESC) WHERE parentCommentID IS NULL;
+
+ALTER TABLE notifications ADD FOREIGN KEY (postID) references posts(postid) ON DELETE CASCADE;
+
+-- Unread notifications keep collecting actors once their subject keys are the post ID
+UPDATE notifications SET subjectKey = coalesce(postID::text, '') || coalesce(':' || commentID, '');
+
+-- Feeds only need the poster to remove their posts on unfollow
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_pkey;
+ALTER TABLE feed_items ALTER COLUMN postID SET NOT NULL;
+ALTER TABLE feed_items ADD PRIMARY KEY (ownerSpotifyID, postID);
+ALTER TABLE feed_items ADD FOREIGN KEY (postID) references posts(postid) ON DELETE CASCADE;
+DROP INDEX feed_items_post_idx;
+CREATE INDEX feed_items_post_idx ON feed_items (postID);
+CREATE INDEX feed_items_poster_idx ON feed_items (ownerSpotifyID, posterSpotifyID);
+ALTER TABLE feed_items DROP COLUMN subjectType;
+ALTER TABLE feed_items DROP COLUMN songID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Only the first post of each user about each subject can be kept, everything about the rest cascades from the posts
+DELETE FROM posts WHERE EXISTS (
+    SELECT 1 FROM posts AS earlier
+    WHERE earlier.posterSpotifyID = posts.posterSpotifyID AND earlier.subjectType = posts.subjectType AND earlier.songID = posts.songID AND earlier.postID < posts.postID
+);
+
+ALTER TABLE feed_items ADD COLUMN subjectType varchar(16);
+ALTER TABLE feed_items ADD COLUMN songID varchar(255);
+UPDATE feed_items SET subjectType = posts.subjectType, songID = posts.songID FROM posts WHERE posts.postID = feed_items.postID;
+ALTER TABLE feed_items ALTER COLUMN subjectType SET NOT NULL;
+ALTER TABLE feed_items ALTER COLUMN subjectType SET DEFAULT 'TRACK';
+ALTER TABLE feed_items ALTER COLUMN songID SET NOT NULL;
+DROP INDEX feed_items_poster_idx;
+DROP INDEX feed_items_post_idx;
+CREATE INDEX feed_items_post_idx ON feed_items (posterSpotifyID, subjectType, songID);
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_postid_fkey;
+ALTER TABLE feed_items DROP CONSTRAINT feed_items_pkey;
+ALTER TABLE feed_items ADD PRIMARY KEY (ownerSpotifyID, posterSpotifyID, subjectType, songID);
+
+UPDATE notifications SET subjectKey = coalesce(posterSpotifyID, '') || ':' || coalesce(subjectType, '') || ':' || coalesce(songID, '') || coalesce(':' || commentID, '');
+ALTER TABLE notifications DROP CONSTRAINT notifications_postid_fkey;
+
+DROP INDEX comments_post_createdat_idx;
+CREATE INDEX comments_post_createdat_idx ON comments (posterspotifyid, subjecttype, songid, createdat DESC, commentid DESC) WHERE parentcommentid IS NULL;
+ALTER TABLE comments DROP CONSTRAINT comments_postid_fkey;
+
+ALTER TABLE post_votes ADD COLUMN posterSpotifyID varchar(255);
+ALTER TABLE post_votes ADD COLUMN postSubjectType varchar(16);
+ALTER TABLE post_votes ADD COLUMN postSongID varchar(255);
+UPDATE post_votes SET posterSpotifyID = posts.posterSpotifyID, postSubjectType = posts.subjectType, postSongID = posts.songID FROM posts WHERE posts.postID = post_votes.postID;
+ALTER TABLE post_votes ALTER COLUMN posterSpotifyID SET NOT NULL;
+ALTER TABLE post_votes ALTER COLUMN postSubjectType SET NOT NULL;
+ALTER TABLE post_votes ALTER COLUMN postSubjectType SET DEFAULT 'TRACK';
+ALTER TABLE post_votes ALTER COLUMN postSongID SET NOT NULL;
+DROP INDEX post_votes_post_idx;
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_postid_fkey;
+ALTER TABLE post_votes DROP CONSTRAINT post_votes_pkey;
+ALTER TABLE post_votes ADD PRIMARY KEY (voterSpotifyID, posterSpotifyID, postSubjectType, postSongID);
+
+DROP INDEX posts_poster_subject_idx;
+DROP INDEX posts_poster_createdat_idx;
+ALTER TABLE posts DROP CONSTRAINT posts_pkey;
+ALTER TABLE posts ADD PRIMARY KEY (posterSpotifyID, subjectType, songID);
+
+ALTER TABLE post_votes ADD FOREIGN KEY (posterSpotifyID, postSubjectType, postSongID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE comments ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE feed_items ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE notifications ADD FOREIGN KEY (posterSpotifyID, subjectType, songID) references posts(posterspotifyid, subjecttype, songid) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE notifications DROP COLUMN postID;
+ALTER TABLE feed_items DROP COLUMN postID;
+ALTER TABLE comments DROP COLUMN postID;
+ALTER TABLE post_votes DROP COLUMN postID;
+ALTER TABLE posts DROP COLUMN relisten;
+ALTER TABLE posts DROP COLUMN listenedAt;
+ALTER TABLE posts DROP COLUMN postID;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listens imported from Spotify that the user has picked to review later. A draft becomes a post once it is published,
+-- and is not shown to anyone else until then
+CREATE TABLE post_drafts (
+    draftID BIGSERIAL PRIMARY KEY,
+    spotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE NOT NULL,
+    trackID varchar(255) references tracks(trackid) NOT NULL,
+    listenedAt timestamp with time zone NOT NULL,
+    createdAt timestamp with time zone NOT NULL,
+    UNIQUE (spotifyID, trackID, listenedAt)
+);
+
+CREATE INDEX post_drafts_listenedat_idx ON post_drafts (spotifyID, listenedAt DESC, draftID DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE post_drafts;
+-- +goose StatementEnd
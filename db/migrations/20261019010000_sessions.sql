@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per login. refreshTokenID is the jti of the only refresh token that can still be used for the session, and
+-- is replaced every time the session is refreshed. Revoked sessions are kept until they expire so they can be told
+-- apart from sessions that never existed
+CREATE TABLE sessions (
+    sessionID uuid PRIMARY KEY,
+    spotifyID varchar(255) references users(spotifyid) ON DELETE CASCADE NOT NULL,
+    refreshTokenID uuid NOT NULL,
+    createdAt timestamp with time zone NOT NULL,
+    refreshedAt timestamp with time zone NOT NULL,
+    expiresAt timestamp with time zone NOT NULL,
+    revokedAt timestamp with time zone,
+    revokedReason varchar(20)
+);
+
+CREATE INDEX sessions_spotifyid_idx ON sessions (spotifyID);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sessions;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Spotify tokens move out of the JWTs and into the session they were issued for, encrypted with a data key that is
+-- stored wrapped by the key encryption key spotifyTokenKeyID. Existing sessions have no tokens to move, so they are
+-- dropped and their users log in again
+DELETE FROM sessions;
+
+ALTER TABLE sessions
+    ADD COLUMN spotifyTokenKeyID varchar(64) NOT NULL,
+    ADD COLUMN spotifyTokenDataKey bytea NOT NULL,
+    ADD COLUMN spotifyAccessToken bytea NOT NULL,
+    ADD COLUMN spotifyRefreshToken bytea NOT NULL,
+    ADD COLUMN spotifyAccessTokenExpiresAt timestamp with time zone NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM sessions;
+
+ALTER TABLE sessions
+    DROP COLUMN spotifyTokenKeyID,
+    DROP COLUMN spotifyTokenDataKey,
+    DROP COLUMN spotifyAccessToken,
+    DROP COLUMN spotifyRefreshToken,
+    DROP COLUMN spotifyAccessTokenExpiresAt;
+-- +goose StatementEnd
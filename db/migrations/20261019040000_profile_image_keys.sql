@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Profile images are stored as the object keys of their thumbnails rather than URLs, which are presigned as they are
+-- handed out. The keys are the last two segments of the URLs, which were all spotifyID/size.jpg
+UPDATE users SET profileimage = jsonb_build_object(
+    'Small', substring(profileimage->>'Small' from '([^/]+/[^/?]+)(\?.*)?$'),
+    'Medium', substring(profileimage->>'Medium' from '([^/]+/[^/?]+)(\?.*)?$'),
+    'Large', substring(profileimage->>'Large' from '([^/]+/[^/?]+)(\?.*)?$')
+) WHERE profileimage IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The URLs cannot be rebuilt without knowing where the objects are stored, so users upload their pictures again
+UPDATE users SET profileimage = NULL;
+-- +goose StatementEnd
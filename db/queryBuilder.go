@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"time"
@@ -49,6 +50,10 @@ func PatchQueryBuilder(table string, setParams map[string]any, whereParams map[s
 }
 
 func getReflectValue(val reflect.Value) any {
+    if v, ok := val.Interface().(driver.Valuer); ok {
+        return v
+    }
+
     switch val.Kind() {
     case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
         return val.Int()
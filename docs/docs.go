@@ -18,6 +18,81 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Gets every key JWTs can be signed with as a JSON Web Key Set, including keys scheduled to start signing later, so other services can verify JWTs themselves. JWTs name the key that signed them in their kid header",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Gets the public keys JWTs are signed with",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.JWKS"
+                        }
+                    }
+                }
+            }
+        },
+        "/albums/{albumID}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets an album from the catalog along with the rating statistics of the album and of its tracks, and its most liked reviews and the reviews of users the current user follows. The reviews include those of the albums tracks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subjects"
+                ],
+                "summary": "Gets the page of an album",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The Spotify ID of the album",
+                        "name": "albumID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AlbumPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/comments/admin/{commentID}": {
             "delete": {
                 "security": [
@@ -25,7 +100,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a comment. Must be admin",
+                "description": "Deletes a comment. Must be admin. A comment with replies is replaced by a tombstone, so the replies are kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -89,7 +164,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a comment for the current user",
+                "description": "Deletes a comment for the current user. A comment with replies is replaced by a tombstone, so the replies are kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -125,6 +200,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -212,7 +293,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Dislike a comment",
+                "description": "Dislike a comment, and responds with the comment. Disliking a comment that is already disliked changes nothing",
                 "consumes": [
                     "application/json"
                 ],
@@ -233,8 +314,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Comment"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -248,12 +332,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -270,7 +348,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Like a comment",
+                "description": "Like a comment, and responds with the comment. Liking a comment that is already liked changes nothing",
                 "consumes": [
                     "application/json"
                 ],
@@ -291,8 +369,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Comment"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -312,12 +393,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -327,14 +402,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments/votes/current/{commentID}": {
-            "delete": {
+        "/comments/replies/{commentID}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete a vote on a comment for the current user",
+                "description": "Gets the direct replies to a comment, oldest first. Each reply carries its own reply count, so deeper replies can be fetched the same way",
                 "consumes": [
                     "application/json"
                 ],
@@ -344,19 +419,34 @@ const docTemplate = `{
                 "tags": [
                     "Comments"
                 ],
-                "summary": "Delete a vote on a comment for the current user",
+                "summary": "Gets the replies to a comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comment ID of comment to remove the vote from",
+                        "description": "Comment ID of the comment to get the replies of",
                         "name": "commentID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Comment"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -364,6 +454,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -379,14 +475,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments/{commentID}": {
-            "get": {
+        "/comments/votes/current/{commentID}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieves a comment",
+                "description": "Delete a vote on a comment for the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -396,11 +492,11 @@ const docTemplate = `{
                 "tags": [
                     "Comments"
                 ],
-                "summary": "Retrieves a comment",
+                "summary": "Delete a vote on a comment for the current user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Comment ID of comment to retrieve",
+                        "description": "Comment ID of comment to remove the vote from",
                         "name": "commentID",
                         "in": "path",
                         "required": true
@@ -416,12 +512,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -437,14 +527,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/comments/{spotifyID}/{songID}": {
-            "post": {
+        "/comments/votes/{commentID}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Creates a comment for the current user",
+                "description": "Gets the users who voted on a comment along with how they voted",
                 "consumes": [
                     "application/json"
                 ],
@@ -454,37 +544,39 @@ const docTemplate = `{
                 "tags": [
                     "Comments"
                 ],
-                "summary": "Creates a comment for the current user",
+                "summary": "Gets the users who voted on a comment",
                 "parameters": [
-                    {
-                        "description": "Information required to create a commment",
-                        "name": "CreatePostDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.CreateCommentDTO"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "spotifyID of poster",
-                        "name": "spotifyID",
+                        "description": "Comment ID of the comment to get the voters of",
+                        "name": "commentID",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "songID of post to make a comment on",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
+                        "description": "Only return voters who voted this way. Either LIKE or DISLIKE",
+                        "name": "vote",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.Comment"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Voter"
                         }
                     },
                     "400": {
@@ -514,14 +606,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/": {
-            "post": {
+        "/comments/{commentID}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Creates a post for the current user",
+                "description": "Retrieves a comment",
                 "consumes": [
                     "application/json"
                 ],
@@ -529,26 +621,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Comments"
                 ],
-                "summary": "Creates a post for the current user",
+                "summary": "Retrieves a comment",
                 "parameters": [
                     {
-                        "description": "Information required to create a post",
-                        "name": "createPostDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.CreatePostDTO"
-                        }
+                        "type": "string",
+                        "description": "Comment ID of comment to retrieve",
+                        "name": "commentID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -568,12 +655,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -583,14 +664,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/admin/{spotifyID}/{songID}": {
-            "delete": {
+        "/comments/{postID}": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a specific post. Only accessible to admins",
+                "description": "Creates a comment for the current user. Setting ParentCommentID makes the comment a reply, and the parent has to be a comment on the same post",
                 "consumes": [
                     "application/json"
                 ],
@@ -598,28 +679,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Comments"
                 ],
-                "summary": "Deletes a specific post. Only accessible to admins",
+                "summary": "Creates a comment for the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The spotify ID of the user who posted the song",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
+                        "description": "Information required to create a commment",
+                        "name": "CreatePostDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateCommentDTO"
+                        }
                     },
                     {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
+                        "type": "integer",
+                        "description": "ID of the post to make a comment on",
+                        "name": "postID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Comment"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -627,8 +713,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -648,14 +734,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/comments/{spotifyID}/{songID}": {
+        "/diary/drafts": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets the comments of a post",
+                "description": "Gets the current users drafts, most recent listen first. A draft is published by creating a post with its DraftID",
                 "consumes": [
                     "application/json"
                 ],
@@ -663,28 +749,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Diary"
                 ],
-                "summary": "Gets the comments of a post",
+                "summary": "Gets the current users drafts",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "The user who posted the post spotify ID",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Pagination Key. In the form of UTC timestamp",
-                        "name": "createdAt",
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -692,7 +770,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Comment-time_Time"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostDraft"
                         }
                     },
                     "400": {
@@ -707,12 +785,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -720,16 +792,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/posts/current/{songID}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get a specific post for the current user",
+                "description": "Creates a draft for each listen, to be reviewed and published later. Listens are usually candidates from /diary/recent. Only the latest listen of each track is kept, and listens the user has already posted about or drafted are skipped, so only the drafts that were created are returned",
                 "consumes": [
                     "application/json"
                 ],
@@ -737,23 +807,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Diary"
                 ],
-                "summary": "Get a specific post for the current user",
+                "summary": "Creates drafts for the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
+                        "description": "The listens to draft",
+                        "name": "CreateDraftsDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateDraftsDTO"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.PostDraft"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
@@ -775,14 +856,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/diary/drafts/{draftID}": {
             "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a post made by the current user",
+                "description": "Deletes a draft of the current user without publishing it",
                 "consumes": [
                     "application/json"
                 ],
@@ -790,14 +873,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Diary"
                 ],
-                "summary": "Deletes a post made by the current user",
+                "summary": "Deletes a draft of the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
+                        "type": "integer",
+                        "description": "ID of the draft to delete",
+                        "name": "draftID",
                         "in": "path",
                         "required": true
                     }
@@ -806,6 +889,12 @@ const docTemplate = `{
                     "204": {
                         "description": "No Content"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -825,14 +914,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/diary/recent": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Updates a post made by the current user",
+                "description": "Gets the current users recently played tracks from Spotify, newest first, leaving out every listen the user has already posted about or drafted. Only the latest listen of each track is returned, and Relisten is set for tracks the user has posted about before",
                 "consumes": [
                     "application/json"
                 ],
@@ -840,38 +931,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
-                ],
-                "summary": "Updates a post made by the current user",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "The fields to update",
-                        "name": "UpdatePostDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.UpdatePostRequestDTO"
-                        }
-                    }
+                    "Diary"
                 ],
+                "summary": "Gets the tracks the current user recently listened to that they have not logged",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.ListenCandidate"
+                            }
                         }
                     },
                     "401": {
@@ -880,8 +950,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -891,47 +961,60 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/posts/dislikes/{spotifyID}/{songID}": {
-            "post": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "Dislikes a post for the current user",
-                "consumes": [
-                    "application/json"
-                ],
+        "/health": {
+            "get": {
+                "description": "Reports the health of the API and its dependencies. Requests cannot be served without the database, so it being down makes the API unhealthy. Events are written to the outbox and published once the broker is back, so the broker being down only makes the API degraded",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Health"
                 ],
-                "summary": "Dislikes a post for the current user",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Song ID of the post to dislike",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
+                "summary": "Reports the health of the API and its dependencies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Health"
+                        }
                     },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/": {
+            "get": {
+                "description": "Sends the user to Spotify to log in, using a signed state parameter tied to a short lived cookie, and PKCE. Once they are logged in, users are sent to redirect, which has to be on one of the allowed origins. Without a redirect, the callback responds with the user instead",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Starts a login with Spotify",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Spotify ID of the user who posted the song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
+                        "description": "Where to send the user once they have logged in",
+                        "name": "redirect",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "302": {
+                        "description": "Found"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -939,20 +1022,61 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/login/callback": {
+            "get": {
+                "description": "Spotify sends users here once they have logged in. The state has to match the cookie set by /login/, so a login cannot be finished by a browser that did not start it. Sets the JWT cookies, then sends the user to the redirect given to /login/, or responds with the user when there was none. When the user did not log in, they are sent to the redirect with Spotify's error as the error query parameter",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Finishes a login with Spotify",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code from Spotify",
+                        "name": "code",
+                        "in": "query"
                     },
-                    "404": {
-                        "description": "Not Found",
+                    {
+                        "type": "string",
+                        "description": "State handed to Spotify by /login/",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Why Spotify did not log the user in",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.User"
+                        }
+                    },
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -962,18 +1086,19 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/posts/feed": {
+        "/login/jwt": {
             "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "Gets the comments of a post",
+                "description": "Issues a new access JWT along with a new refresh JWT, and extends the session. Each refresh JWT can only be used once. Using one again revokes the session it belongs to, since it means someone else has a copy of it. The users Spotify tokens stay on the session, and are refreshed separately whenever they are needed",
                 "consumes": [
                     "application/json"
                 ],
@@ -981,32 +1106,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Auth"
                 ],
-                "summary": "Gets the comments of a post",
+                "summary": "Refreshes the current users JWT",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Pagination Key. In the form of UTC timestamp",
-                        "name": "createdAt",
-                        "in": "query"
+                        "description": "refresh JWT provided by login endpoint REFRESH_JWT=..., or __Host-REFRESH_JWT=... when cookies are prefixed",
+                        "name": "Cookie",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostPreview-time_Time"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -1020,14 +1142,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/likes/{spotifyID}/{songID}": {
+        "/logout": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Likes a post for the current user",
+                "description": "Revokes the session the access JWT belongs to, so neither its access JWTs nor its refresh JWT can be used again, and clears the JWT cookies",
                 "consumes": [
                     "application/json"
                 ],
@@ -1035,25 +1157,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
-                ],
-                "summary": "Likes a post for the current user",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Song ID of the post to like",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Spotify ID of the user who posted the song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "Logs the current user out",
                 "responses": {
                     "204": {
                         "description": "No Content"
@@ -1064,18 +1170,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "409": {
-                        "description": "Conflict",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1085,14 +1179,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/previews/users/current": {
-            "get": {
+        "/logout/everywhere": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get all of a users post previews",
+                "description": "Revokes every session of the current user, including the one making the request, and clears the JWT cookies",
                 "consumes": [
                     "application/json"
                 ],
@@ -1100,29 +1194,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
-                ],
-                "summary": "Get all of a users post previews",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Pagination Key. Format is UTC timestamp",
-                        "name": "createdAt",
-                        "in": "query"
-                    }
+                    "Auth"
                 ],
+                "summary": "Logs the current user out everywhere",
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -1139,14 +1216,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/previews/users/{spotifyID}": {
+        "/notifications": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get all of a users post previews",
+                "description": "Gets the current users notifications, most recently updated first. Repeats of the same action on the same subject are folded into one unread notification, e.g. \"alice and 4 others liked your review of Song\", until it is read",
                 "consumes": [
                     "application/json"
                 ],
@@ -1154,21 +1231,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Notifications"
                 ],
-                "summary": "Get all of a users post previews",
+                "summary": "Gets the current users notifications",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The user whos posts are recieved. Value is a spotify ID",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Pagination Key. Format is UTC timestamp",
-                        "name": "createdAt",
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -1176,7 +1252,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Notification"
                         }
                     },
                     "400": {
@@ -1191,12 +1267,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1206,14 +1276,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/votes/current/{posterSpotifyID}/{songID}": {
-            "delete": {
+        "/notifications/read": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Removes a vote for the current user on a post",
+                "description": "Marks all of the current users notifications as read",
                 "consumes": [
                     "application/json"
                 ],
@@ -1221,25 +1291,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
-                ],
-                "summary": "Removes a vote for the current user on a post",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "The user who posted the post spotify ID",
-                        "name": "posterSpotifyID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Notifications"
                 ],
+                "summary": "Marks all of the current users notifications as read",
                 "responses": {
                     "204": {
                         "description": "No Content"
@@ -1250,12 +1304,6 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1265,14 +1313,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/{spotifyID}/{songID}": {
-            "get": {
+        "/notifications/read/{notificationID}": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get a specific post",
+                "description": "Marks one of the current users notifications as read. Actions after this start a new notification instead of being added to this one",
                 "consumes": [
                     "application/json"
                 ],
@@ -1280,31 +1328,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Posts"
+                    "Notifications"
                 ],
-                "summary": "Get apath specific post",
+                "summary": "Marks a notification as read",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The user who posted the song",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "The songID of the posted song",
-                        "name": "songID",
+                        "description": "ID of the notification to mark as read",
+                        "name": "notificationID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.PostPreview"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1333,14 +1371,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/admin/{spotifyID}": {
-            "delete": {
+        "/notifications/unreadCount": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes a user account by spotify ID",
+                "description": "Gets the number of unread notifications for the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1348,36 +1386,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
-                ],
-                "summary": "Deletes a user account by spotify ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Spotify ID of the user to delete",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Notifications"
                 ],
+                "summary": "Gets the number of unread notifications for the current user",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/responses.UnreadNotificationCount"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -1389,14 +1409,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/posts/": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Updates a user by their spotify ID. Only accessable to admins",
+                "description": "Creates a post for the current user reviewing a track, an album or an artist. The subject is looked up on Spotify and kept in the catalog. A user can post about the same subject more than once, and every post after the first is flagged as a re-listen. Setting DraftID publishes one of the users drafts instead, taking the subject and ListenedAt from it",
                 "consumes": [
                     "application/json"
                 ],
@@ -1404,32 +1426,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Updates a user by their spotify ID. Only accessable to admins",
+                "summary": "Creates a post for the current user",
                 "parameters": [
                     {
-                        "description": "Information to update",
-                        "name": "UpdateUserDTO",
+                        "description": "Information required to create a post",
+                        "name": "createPostDTO",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.UpdateUserRequestDTO"
+                            "$ref": "#/definitions/requests.CreatePostDTO"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "Spotify ID of the user to update",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.User"
+                            "$ref": "#/definitions/responses.PostPreview"
                         }
                     },
                     "400": {
@@ -1444,14 +1459,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "string"
                         }
@@ -1465,14 +1480,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/current": {
-            "get": {
+        "/posts/admin/{postID}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieves the current user",
+                "description": "Deletes a specific post. Only accessible to admins",
                 "consumes": [
                     "application/json"
                 ],
@@ -1480,18 +1495,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
+                ],
+                "summary": "Deletes a specific post. Only accessible to admins",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Retreives the current user",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/responses.User"
+                            "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -1509,14 +1536,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/posts/albums/{albumID}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Deletes the current user",
+                "description": "Gets the reviews of an album along with the reviews of each of its tracks, newest first. SubjectType tells them apart",
                 "consumes": [
                     "application/json"
                 ],
@@ -1524,15 +1553,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
+                ],
+                "summary": "Gets the reviews of an album",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The Spotify ID of the album",
+                        "name": "albumID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Deletes the current user",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostPreview"
+                        }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -1544,14 +1603,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/posts/comments/{postID}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Updates the current user",
+                "description": "Gets the top level comments of a post, newest first. Replies are not included, each comment carries a reply count and its replies are fetched from /comments/replies/{commentID}",
                 "consumes": [
                     "application/json"
                 ],
@@ -1559,25 +1620,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Updates the current user",
+                "summary": "Gets the comments of a post",
                 "parameters": [
                     {
-                        "description": "Information to update",
-                        "name": "UpdateUserDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.UpdateUserRequestDTO"
-                        }
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.User"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Comment"
                         }
                     },
                     "400": {
@@ -1592,8 +1663,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "string"
                         }
@@ -1607,14 +1678,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/current/follow/{otherUserSpotifyID}": {
-            "post": {
+        "/posts/current/{postID}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Follows a user for the current user",
+                "description": "Deletes a post made by the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1622,14 +1693,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Follows a user for the current user",
+                "summary": "Deletes a post made by the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Spotify ID of other user to follow",
-                        "name": "otherUserSpotifyID",
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
                         "in": "path",
                         "required": true
                     }
@@ -1638,20 +1709,20 @@ const docTemplate = `{
                     "204": {
                         "description": "No Content"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "string"
                         }
@@ -1663,16 +1734,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/current/followers/": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets the current users followers",
+                "description": "Updates a post made by the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1680,22 +1749,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Gets the current users followers",
+                "summary": "Updates a post made by the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Pagination Key for follow up responses. This key is a spotify ID",
-                        "name": "spotifyID",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The fields to update",
+                        "name": "UpdatePostDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdatePostRequestDTO"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User-string"
+                            "$ref": "#/definitions/responses.PostPreview"
                         }
                     },
                     "400": {
@@ -1710,23 +1789,29 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "string"
                         }
-                    }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "/users/current/following/": {
+        "/posts/current/{songID}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets the current users followers",
+                "description": "Get the most recent post the current user made about a subject. Earlier posts about it are re-listened to and show up in the users post previews",
                 "consumes": [
                     "application/json"
                 ],
@@ -1734,14 +1819,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Gets the current users followers",
+                "summary": "Get the current users latest post about a subject",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Pagination Key for follow up responses. This key is a spotify ID",
-                        "name": "spotifyID",
+                        "description": "The songID of the posted song",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The type of the posts subject, one of TRACK, ALBUM or ARTIST. Defaults to TRACK",
+                        "name": "subjectType",
                         "in": "query"
                     }
                 ],
@@ -1749,7 +1841,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User-string"
+                            "$ref": "#/definitions/responses.PostPreview"
                         }
                     },
                     "400": {
@@ -1764,6 +1856,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1773,14 +1871,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/current/unfollow/{otherUserSpotifyID}": {
-            "delete": {
+        "/posts/dislikes/{postID}": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Unfollowers a user for the currently signed in user",
+                "description": "Dislikes a post for the current user, and responds with the post. Disliking a post that is already disliked changes nothing",
                 "consumes": [
                     "application/json"
                 ],
@@ -1788,21 +1886,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Unfollowers a user for the currently signed in user",
+                "summary": "Dislikes a post for the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User to unfollow spotify ID",
-                        "name": "otherUserSpotifyID",
+                        "type": "integer",
+                        "description": "ID of the post to dislike",
+                        "name": "postID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PostPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -1825,14 +1932,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/current/uploadProfilePicture": {
-            "post": {
+        "/posts/feed": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Uploads a user profile picture",
+                "description": "Gets the posts of the users that the current user follows, newest first. Feeds are built when posts are created, so this is a single lookup",
                 "consumes": [
                     "application/json"
                 ],
@@ -1840,24 +1947,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
+                ],
+                "summary": "Gets the current users feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Uploads a user profile picture",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/users/{spotifyID}": {
-            "get": {
+        "/posts/likes/{postID}": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets a tunes user by their spotifyID",
+                "description": "Likes a post for the current user, and responds with the post. Liking a post that is already liked changes nothing",
                 "consumes": [
                     "application/json"
                 ],
@@ -1865,14 +2013,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Gets a tunes user by their spotify ID",
+                "summary": "Likes a post for the current user",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User Spotify ID",
-                        "name": "spotifyID",
+                        "type": "integer",
+                        "description": "ID of the post to like",
+                        "name": "postID",
                         "in": "path",
                         "required": true
                     }
@@ -1881,7 +2029,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.User"
+                            "$ref": "#/definitions/responses.PostPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
@@ -1905,14 +2059,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{spotifyID}/followers/": {
+        "/posts/previews/users/current": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets a users followers by their spotify ID",
+                "description": "Get all of a users post previews",
                 "consumes": [
                     "application/json"
                 ],
@@ -1920,21 +2074,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Gets a users followers by their spotify ID",
+                "summary": "Get all of a users post previews",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User spotify ID",
-                        "name": "spotifyID",
-                        "in": "path",
-                        "required": true
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Pagination Key for follow up responses. This key is a spotify ID",
-                        "name": "spotifyID",
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -1942,17 +2095,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User-string"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostPreview"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -1966,14 +2119,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{spotifyID}/following/": {
+        "/posts/previews/users/{spotifyID}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Gets a users followers by their spotify ID",
+                "description": "Get all of a users post previews",
                 "consumes": [
                     "application/json"
                 ],
@@ -1981,21 +2134,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Posts"
                 ],
-                "summary": "Gets a users followers by their spotify ID",
+                "summary": "Get all of a users post previews",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User spotify ID",
+                        "description": "The user whos posts are recieved. Value is a spotify ID",
                         "name": "spotifyID",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Pagination Key for follow up responses. This key is a spotify ID",
-                        "name": "spotifyID",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -2003,7 +2162,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User-string"
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
@@ -2026,142 +2191,2147 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "requests.CreateCommentDTO": {
-            "type": "object",
-            "properties": {
-                "commentText": {
-                    "type": "string"
-                }
-            }
-        },
-        "requests.CreatePostDTO": {
-            "type": "object",
-            "properties": {
-                "rating": {
-                    "type": "integer"
-                },
-                "songID": {
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
-                }
-            }
-        },
-        "requests.UpdateCommentDTO": {
-            "type": "object",
-            "properties": {
-                "commentText": {
-                    "type": "string"
-                }
-            }
         },
-        "requests.UpdatePostRequestDTO": {
-            "type": "object",
-            "properties": {
-                "rating": {
-                    "type": "integer"
-                },
-                "review": {
-                    "type": "string"
+        "/posts/votes/current/{postID}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Removes a vote for the current user on a post",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Posts"
+                ],
+                "summary": "Removes a vote for the current user on a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "requests.UpdateUserRequestDTO": {
-            "type": "object",
-            "properties": {
-                "bio": {
-                    "type": "string"
-                },
+        "/posts/votes/{postID}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the users who voted on a post along with how they voted. Post listings only carry vote counts, so this is where the voters themselves are found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Posts"
+                ],
+                "summary": "Gets the users who voted on a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return voters who voted this way. Either LIKE or DISLIKE",
+                        "name": "vote",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_Voter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{postID}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get a specific post",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Posts"
+                ],
+                "summary": "Get a specific post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "The ID of the post",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PostPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Full text search over song names, album names, reviews, comments, usernames and bios. Results are split into a section per type and ordered by rank, with matches highlighted in each snippet. Without a type every section is searched and the first page of each is returned. To page through a section, pass its type along with one of its cursors",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Searches posts, comments and users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The search terms. Quoted phrases, OR and -excluded words are supported",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only search one section. One of posts, comments or users",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page. Requires type",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SearchResults"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{songID}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets a song from the catalog along with its rating statistics, its most liked reviews and the reviews of users the current user follows",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subjects"
+                ],
+                "summary": "Gets the page of a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The Spotify ID of the song",
+                        "name": "songID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SongPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/stream": {
+            "get": {
+                "description": "Streams new posts from followed users, comments and replies on the current users posts and comments, votes on their posts and notifications as they happen. Upgrades to a WebSocket when asked to, otherwise falls back to server sent events. Every message is an event envelope, and server sent events are named after the event type. Clients that fall too far behind are disconnected and should reconnect and refetch. Connections are authenticated with a ticket from /stream/tickets, and are closed when the access JWT the ticket was issued with expires or the session is revoked",
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Streams events for the current user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "A ticket from /stream/tickets",
+                        "name": "ticket",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/stream/tickets": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "EventSource and WebSockets in the browser cannot set the Authorization header, so /stream is authenticated with a ticket passed as the ticket query parameter instead. A ticket can only be used once and only for a short while, so it is useless once it has turned up in a log. The connection it opens is closed when the access JWT the ticket was issued with expires",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Issues a ticket for connecting to /stream",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.StreamTicket"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/admin/{spotifyID}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes a user account by spotify ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deletes a user account by spotify ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spotify ID of the user to delete",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates a user by their spotify ID. Only accessable to admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates a user by their spotify ID. Only accessable to admins",
+                "parameters": [
+                    {
+                        "description": "Information to update",
+                        "name": "UpdateUserDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateUserRequestDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Spotify ID of the user to update",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/admin/{spotifyID}/sessions": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revokes every session of a user, which logs them out everywhere. They can log in again afterwards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revokes every session of a user. Only accessible to admins",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The spotify ID of the user",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieves the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Retreives the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deletes the current user",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Updates the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates the current user",
+                "parameters": [
+                    {
+                        "description": "Information to update",
+                        "name": "UpdateUserDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateUserRequestDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/follow/{otherUserSpotifyID}": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Follows a user for the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Follows a user for the current user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Spotify ID of other user to follow",
+                        "name": "otherUserSpotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/followers/": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the current users followers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets the current users followers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/following/": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets the current users followers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets the current users followers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets how the current user is emailed about each type of event, either straight away, in a daily or weekly digest, or not at all. Event types the user has not set are returned with their defaults",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets the current users notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.NotificationPreference"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Sets how the current user is emailed about the given types of event. Event types that are left out are not changed. Digests are sent at the same time every day, and weekly digests on Mondays",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Updates the current users notification preferences",
+                "parameters": [
+                    {
+                        "description": "Delivery for each event type, one of OFF, INSTANT, DAILY or WEEKLY",
+                        "name": "UpdateNotificationPreferencesDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateNotificationPreferencesDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.NotificationPreference"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/unfollow/{otherUserSpotifyID}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Unfollowers a user for the currently signed in user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unfollowers a user for the currently signed in user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User to unfollow spotify ID",
+                        "name": "otherUserSpotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/current/uploadProfilePicture": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Uploads a profile picture for the current user. Accepts a multipart form with a profilePicture file, or a raw octet-stream body. The image is resized into small, medium and large thumbnails",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Uploads a profile picture for the current user",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Profile picture (jpeg, png or gif)",
+                        "name": "profilePicture",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ProfileImage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{spotifyID}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets a tunes user by their spotifyID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a tunes user by their spotify ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User Spotify ID",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{spotifyID}/followers/": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets a users followers by their spotify ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a users followers by their spotify ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User spotify ID",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{spotifyID}/following/": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Gets a users followers by their spotify ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Gets a users followers by their spotify ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User spotify ID",
+                        "name": "spotifyID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from a previous response, used to fetch the next or previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page, defaults to 25 with a maximum of 100",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PaginationResponse-array_responses_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "requests.CreateCommentDTO": {
+            "type": "object",
+            "properties": {
+                "commentText": {
+                    "type": "string"
+                },
+                "parentCommentID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "requests.CreateDraftsDTO": {
+            "type": "object",
+            "properties": {
+                "listens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/requests.ListenDTO"
+                    }
+                }
+            }
+        },
+        "requests.CreatePostDTO": {
+            "type": "object",
+            "properties": {
+                "draftID": {
+                    "type": "integer"
+                },
+                "listenedAt": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "relisten": {
+                    "type": "boolean"
+                },
+                "songID": {
+                    "type": "string"
+                },
+                "subjectID": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.ListenDTO": {
+            "type": "object",
+            "properties": {
+                "listenedAt": {
+                    "type": "string"
+                },
+                "trackID": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.UpdateCommentDTO": {
+            "type": "object",
+            "properties": {
+                "commentText": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.UpdateNotificationPreferencesDTO": {
+            "type": "object",
+            "properties": {
+                "preferences": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/responses.NotificationDelivery"
+                    }
+                }
+            }
+        },
+        "requests.UpdatePostRequestDTO": {
+            "type": "object",
+            "properties": {
+                "rating": {
+                    "type": "number"
+                },
+                "review": {
+                    "type": "string"
+                }
+            }
+        },
+        "requests.UpdateUserRequestDTO": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
-                "userRole": {
-                    "$ref": "#/definitions/responses.Role"
+                "userRole": {
+                    "$ref": "#/definitions/responses.Role"
+                }
+            }
+        },
+        "responses.Album": {
+            "type": "object",
+            "properties": {
+                "albumArtURI": {
+                    "type": "string"
+                },
+                "albumID": {
+                    "type": "string"
+                },
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Artist"
+                    }
+                },
+                "fetchedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "releaseDatePrecision": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.AlbumPage": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "$ref": "#/definitions/responses.Album"
+                },
+                "followingReviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostPreview"
+                    }
+                },
+                "mostLikedReviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostPreview"
+                    }
+                },
+                "stats": {
+                    "$ref": "#/definitions/responses.RatingStats"
+                },
+                "trackStats": {
+                    "$ref": "#/definitions/responses.RatingStats"
+                }
+            }
+        },
+        "responses.Artist": {
+            "type": "object",
+            "properties": {
+                "artistID": {
+                    "type": "string"
+                },
+                "fetchedAt": {
+                    "type": "string"
+                },
+                "imageURI": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Comment": {
+            "type": "object",
+            "properties": {
+                "commentID": {
+                    "type": "integer"
+                },
+                "commentText": {
+                    "type": "string"
+                },
+                "commentorID": {
+                    "type": "string"
+                },
+                "commentorUsername": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currentUserVote": {
+                    "$ref": "#/definitions/responses.Vote"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "dislikes": {
+                    "type": "integer"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "parentCommentID": {
+                    "type": "integer"
+                },
+                "postID": {
+                    "type": "integer"
+                },
+                "postSpotifyID": {
+                    "type": "string"
+                },
+                "replyCount": {
+                    "type": "integer"
+                },
+                "songID": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.CommentSearchResult": {
+            "type": "object",
+            "properties": {
+                "commentID": {
+                    "type": "integer"
+                },
+                "commentText": {
+                    "type": "string"
+                },
+                "commentorID": {
+                    "type": "string"
+                },
+                "commentorUsername": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "currentUserVote": {
+                    "$ref": "#/definitions/responses.Vote"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "dislikes": {
+                    "type": "integer"
+                },
+                "likes": {
+                    "type": "integer"
+                },
+                "parentCommentID": {
+                    "type": "integer"
+                },
+                "postID": {
+                    "type": "integer"
+                },
+                "postSpotifyID": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "replyCount": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "songID": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Health": {
+            "type": "object",
+            "properties": {
+                "database": {
+                    "$ref": "#/definitions/responses.HealthStatus"
+                },
+                "rabbitMQ": {
+                    "$ref": "#/definitions/responses.RabbitMQHealth"
+                },
+                "status": {
+                    "$ref": "#/definitions/responses.HealthStatus"
+                }
+            }
+        },
+        "responses.HealthStatus": {
+            "type": "string",
+            "enum": [
+                "HEALTHY",
+                "DEGRADED",
+                "UNHEALTHY"
+            ],
+            "x-enum-varnames": [
+                "HEALTHY",
+                "DEGRADED",
+                "UNHEALTHY"
+            ]
+        },
+        "responses.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.JWK"
+                    }
+                }
+            }
+        },
+        "responses.ListenCandidate": {
+            "type": "object",
+            "properties": {
+                "albumArtURI": {
+                    "type": "string"
+                },
+                "albumID": {
+                    "type": "string"
+                },
+                "albumName": {
+                    "type": "string"
+                },
+                "listenedAt": {
+                    "type": "string"
+                },
+                "relisten": {
+                    "type": "boolean"
+                },
+                "songName": {
+                    "type": "string"
+                },
+                "trackID": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Notification": {
+            "type": "object",
+            "properties": {
+                "actorCount": {
+                    "type": "integer"
+                },
+                "commentID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "latestActor": {
+                    "$ref": "#/definitions/responses.UserIdentifer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "notificationID": {
+                    "type": "integer"
+                },
+                "postID": {
+                    "type": "integer"
+                },
+                "posterSpotifyID": {
+                    "type": "string"
+                },
+                "read": {
+                    "type": "boolean"
+                },
+                "songID": {
+                    "type": "string"
+                },
+                "songName": {
+                    "type": "string"
+                },
+                "subjectName": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
+                "type": {
+                    "$ref": "#/definitions/responses.NotificationType"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.NotificationDelivery": {
+            "type": "string",
+            "enum": [
+                "OFF",
+                "INSTANT",
+                "DAILY",
+                "WEEKLY"
+            ],
+            "x-enum-varnames": [
+                "DELIVERY_OFF",
+                "DELIVERY_INSTANT",
+                "DELIVERY_DAILY",
+                "DELIVERY_WEEKLY"
+            ]
+        },
+        "responses.NotificationPreference": {
+            "type": "object",
+            "properties": {
+                "delivery": {
+                    "$ref": "#/definitions/responses.NotificationDelivery"
+                },
+                "eventType": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.NotificationType": {
+            "type": "string",
+            "enum": [
+                "FOLLOWED",
+                "POST_LIKED",
+                "POST_COMMENTED",
+                "COMMENT_REPLIED",
+                "COMMENT_LIKED"
+            ],
+            "x-enum-varnames": [
+                "FOLLOWED",
+                "POST_LIKED",
+                "POST_COMMENTED",
+                "COMMENT_REPLIED",
+                "COMMENT_LIKED"
+            ]
+        },
+        "responses.PaginationResponse-array_responses_Comment": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Comment"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_CommentSearchResult": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.CommentSearchResult"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_Notification": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Notification"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_PostDraft": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostDraft"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_PostPreview": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostPreview"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_PostSearchResult": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostSearchResult"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_User": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.User"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_UserSearchResult": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.UserSearchResult"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PaginationResponse-array_responses_Voter": {
+            "type": "object",
+            "properties": {
+                "dataResponse": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Voter"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "prevCursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.PostDraft": {
+            "type": "object",
+            "properties": {
+                "albumArtURI": {
+                    "type": "string"
+                },
+                "albumID": {
+                    "type": "string"
+                },
+                "albumName": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "draftID": {
+                    "type": "integer"
+                },
+                "listenedAt": {
+                    "type": "string"
+                },
+                "relisten": {
+                    "type": "boolean"
+                },
+                "songName": {
+                    "type": "string"
+                },
+                "trackID": {
+                    "type": "string"
                 }
             }
         },
-        "responses.Comment": {
+        "responses.PostPreview": {
             "type": "object",
             "properties": {
-                "commentID": {
-                    "type": "integer"
-                },
-                "commentText": {
+                "albumArtURI": {
                     "type": "string"
                 },
-                "commentorID": {
+                "albumID": {
                     "type": "string"
                 },
-                "commentorUsername": {
+                "albumName": {
                     "type": "string"
                 },
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Artist"
+                    }
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "currentUserVote": {
+                    "$ref": "#/definitions/responses.Vote"
+                },
                 "dislikes": {
                     "type": "integer"
                 },
+                "durationMs": {
+                    "type": "integer"
+                },
+                "imageURI": {
+                    "type": "string"
+                },
                 "likes": {
                     "type": "integer"
                 },
-                "postSpotifyID": {
+                "listenedAt": {
+                    "type": "string"
+                },
+                "postID": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "releaseDate": {
                     "type": "string"
                 },
+                "relisten": {
+                    "type": "boolean"
+                },
                 "songID": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "songName": {
                     "type": "string"
-                }
-            }
-        },
-        "responses.PaginationResponse-array_responses_Comment-time_Time": {
-            "type": "object",
-            "properties": {
-                "dataResponse": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.Comment"
-                    }
                 },
-                "paginationKey": {
+                "spotifyID": {
                     "type": "string"
-                }
-            }
-        },
-        "responses.PaginationResponse-array_responses_PostPreview-time_Time": {
-            "type": "object",
-            "properties": {
-                "dataResponse": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.PostPreview"
-                    }
                 },
-                "paginationKey": {
+                "subjectName": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
-                }
-            }
-        },
-        "responses.PaginationResponse-array_responses_User-string": {
-            "type": "object",
-            "properties": {
-                "dataResponse": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.User"
-                    }
                 },
-                "paginationKey": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "responses.PostPreview": {
+        "responses.PostSearchResult": {
             "type": "object",
             "properties": {
                 "albumArtURI": {
@@ -2173,24 +4343,51 @@ const docTemplate = `{
                 "albumName": {
                     "type": "string"
                 },
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Artist"
+                    }
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "currentUserVote": {
+                    "$ref": "#/definitions/responses.Vote"
+                },
                 "dislikes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.UserIdentifer"
-                    }
+                    "type": "integer"
+                },
+                "durationMs": {
+                    "type": "integer"
+                },
+                "imageURI": {
+                    "type": "string"
                 },
                 "likes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.UserIdentifer"
-                    }
+                    "type": "integer"
                 },
-                "rating": {
+                "listenedAt": {
+                    "type": "string"
+                },
+                "postID": {
                     "type": "integer"
                 },
+                "rank": {
+                    "type": "number"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "relisten": {
+                    "type": "boolean"
+                },
+                "snippet": {
+                    "type": "string"
+                },
                 "songID": {
                     "type": "string"
                 },
@@ -2200,6 +4397,12 @@ const docTemplate = `{
                 "spotifyID": {
                     "type": "string"
                 },
+                "subjectName": {
+                    "type": "string"
+                },
+                "subjectType": {
+                    "$ref": "#/definitions/responses.SubjectType"
+                },
                 "text": {
                     "type": "string"
                 },
@@ -2211,6 +4414,83 @@ const docTemplate = `{
                 }
             }
         },
+        "responses.ProfileImage": {
+            "type": "object",
+            "properties": {
+                "large": {
+                    "type": "string"
+                },
+                "medium": {
+                    "type": "string"
+                },
+                "small": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.RabbitMQConnectionState": {
+            "type": "string",
+            "enum": [
+                "CONNECTING",
+                "CONNECTED",
+                "DISCONNECTED",
+                "CLOSED"
+            ],
+            "x-enum-varnames": [
+                "RABBITMQ_CONNECTING",
+                "RABBITMQ_CONNECTED",
+                "RABBITMQ_DISCONNECTED",
+                "RABBITMQ_CLOSED"
+            ]
+        },
+        "responses.RabbitMQHealth": {
+            "type": "object",
+            "properties": {
+                "connectedAt": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "reconnects": {
+                    "type": "integer"
+                },
+                "state": {
+                    "$ref": "#/definitions/responses.RabbitMQConnectionState"
+                }
+            }
+        },
+        "responses.RatingBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                }
+            }
+        },
+        "responses.RatingStats": {
+            "type": "object",
+            "properties": {
+                "averageRating": {
+                    "type": "number"
+                },
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.RatingBucket"
+                    }
+                },
+                "ratedCount": {
+                    "type": "integer"
+                },
+                "reviewCount": {
+                    "type": "integer"
+                }
+            }
+        },
         "responses.Role": {
             "type": "string",
             "enum": [
@@ -2224,6 +4504,101 @@ const docTemplate = `{
                 "ADMIN"
             ]
         },
+        "responses.SearchResults": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "$ref": "#/definitions/responses.PaginationResponse-array_responses_CommentSearchResult"
+                },
+                "posts": {
+                    "$ref": "#/definitions/responses.PaginationResponse-array_responses_PostSearchResult"
+                },
+                "users": {
+                    "$ref": "#/definitions/responses.PaginationResponse-array_responses_UserSearchResult"
+                }
+            }
+        },
+        "responses.SongPage": {
+            "type": "object",
+            "properties": {
+                "followingReviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostPreview"
+                    }
+                },
+                "mostLikedReviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PostPreview"
+                    }
+                },
+                "stats": {
+                    "$ref": "#/definitions/responses.RatingStats"
+                },
+                "track": {
+                    "$ref": "#/definitions/responses.Track"
+                }
+            }
+        },
+        "responses.StreamTicket": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "ticket": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.SubjectType": {
+            "type": "string",
+            "enum": [
+                "TRACK",
+                "ALBUM",
+                "ARTIST"
+            ],
+            "x-enum-varnames": [
+                "TRACK",
+                "ALBUM",
+                "ARTIST"
+            ]
+        },
+        "responses.Track": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "$ref": "#/definitions/responses.Album"
+                },
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Artist"
+                    }
+                },
+                "durationMs": {
+                    "type": "integer"
+                },
+                "fetchedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "trackID": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.UnreadNotificationCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
         "responses.User": {
             "type": "object",
             "properties": {
@@ -2233,6 +4608,9 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "profileImage": {
+                    "$ref": "#/definitions/responses.ProfileImage"
+                },
                 "role": {
                     "$ref": "#/definitions/responses.Role"
                 },
@@ -2254,6 +4632,54 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "responses.UserSearchResult": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "profileImage": {
+                    "$ref": "#/definitions/responses.ProfileImage"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "spotifyID": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Vote": {
+            "type": "string",
+            "enum": [
+                "LIKE",
+                "DISLIKE"
+            ],
+            "x-enum-varnames": [
+                "LIKE",
+                "DISLIKE"
+            ]
+        },
+        "responses.Voter": {
+            "type": "object",
+            "properties": {
+                "spotifyID": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "vote": {
+                    "$ref": "#/definitions/responses.Vote"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -3,22 +3,39 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/Jack-Gitter/tunes/models/services/auth"
 	"github.com/Jack-Gitter/tunes/models/services/cache"
+	"github.com/Jack-Gitter/tunes/models/services/catalog"
 	"github.com/Jack-Gitter/tunes/models/services/comments"
+	"github.com/Jack-Gitter/tunes/models/services/cookies"
+	"github.com/Jack-Gitter/tunes/models/services/diary"
+	"github.com/Jack-Gitter/tunes/models/services/emails"
+	"github.com/Jack-Gitter/tunes/models/services/encryption"
+	"github.com/Jack-Gitter/tunes/models/services/health"
+	"github.com/Jack-Gitter/tunes/models/services/images"
 	"github.com/Jack-Gitter/tunes/models/services/jwt"
+	"github.com/Jack-Gitter/tunes/models/services/notifications"
+	"github.com/Jack-Gitter/tunes/models/services/outbox"
 	"github.com/Jack-Gitter/tunes/models/services/posts"
 	"github.com/Jack-Gitter/tunes/models/services/rabbitmqservice"
-	"github.com/Jack-Gitter/tunes/models/services/s3Service"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
+	"github.com/Jack-Gitter/tunes/models/services/search"
 	"github.com/Jack-Gitter/tunes/models/services/spotify"
+	"github.com/Jack-Gitter/tunes/models/services/storage"
+	"github.com/Jack-Gitter/tunes/models/services/subjects"
+	"github.com/Jack-Gitter/tunes/models/services/tokenbroker"
 	"github.com/Jack-Gitter/tunes/models/services/users"
 	"github.com/Jack-Gitter/tunes/server"
+	"github.com/Jack-Gitter/tunes/validation"
 	"github.com/joho/godotenv"
 )
 
@@ -44,10 +61,22 @@ func main() {
     defer redisConnection.Close()
 
 
-    rabbitMQService := rabbitmqservice.RabbitMQService{}
+    rabbitMQChannelPoolSize := rabbitmqservice.DEFAULT_CHANNEL_POOL_SIZE
+    rabbitMQChannelPoolSizeString := os.Getenv("RABBIT_MQ_CHANNEL_POOL_SIZE")
+
+    if rabbitMQChannelPoolSizeString != "" {
+        rabbitMQChannelPoolSizeNumber, err := strconv.Atoi(rabbitMQChannelPoolSizeString)
+
+        if err != nil || rabbitMQChannelPoolSizeNumber < 1 {
+            panic("rabbitmq channel pool size must be a positive number")
+        }
+
+        rabbitMQChannelPoolSize = rabbitMQChannelPoolSizeNumber
+    }
+
+    rabbitMQService := rabbitmqservice.RabbitMQService{PoolSize: rabbitMQChannelPoolSize}
     rabbitMQService.Connect()
-    defer rabbitMQService.Conn.Close()
-    defer rabbitMQService.Chan.Close()
+    defer rabbitMQService.Close()
 
     userCacheTTLString := os.Getenv("USER_CACHE_TTL_IN_SECONDS")
     userCacheTTLNumber, err := strconv.Atoi(userCacheTTLString)
@@ -58,22 +87,242 @@ func main() {
 
     userCacheTTLDuration := time.Duration(float64(userCacheTTLNumber) * float64(time.Second))
 
+    profilePictureMaxBytes := int64(5 << 20)
+    profilePictureMaxBytesString := os.Getenv("PROFILE_PICTURE_MAX_BYTES")
+
+    if profilePictureMaxBytesString != "" {
+        profilePictureMaxBytes, err = strconv.ParseInt(profilePictureMaxBytesString, 10, 64)
+
+        if err != nil {
+            panic("profile picture max bytes must be a number")
+        }
+    }
+
+    outboxPollInterval := outbox.DEFAULT_POLL_INTERVAL
+    outboxPollIntervalString := os.Getenv("OUTBOX_POLL_INTERVAL_IN_MILLISECONDS")
+
+    if outboxPollIntervalString != "" {
+        outboxPollIntervalNumber, err := strconv.Atoi(outboxPollIntervalString)
+
+        if err != nil || outboxPollIntervalNumber < 1 {
+            panic("outbox poll interval must be a positive number")
+        }
+
+        outboxPollInterval = time.Duration(outboxPollIntervalNumber) * time.Millisecond
+    }
+
+    outboxBatchSize := outbox.DEFAULT_BATCH_SIZE
+    outboxBatchSizeString := os.Getenv("OUTBOX_BATCH_SIZE")
+
+    if outboxBatchSizeString != "" {
+        outboxBatchSize, err = strconv.Atoi(outboxBatchSizeString)
+
+        if err != nil || outboxBatchSize < 1 {
+            panic("outbox batch size must be a positive number")
+        }
+    }
+
+    realtimeSendBufferSize := realtime.DEFAULT_SEND_BUFFER_SIZE
+    realtimeSendBufferSizeString := os.Getenv("REALTIME_SEND_BUFFER_SIZE")
+
+    if realtimeSendBufferSizeString != "" {
+        realtimeSendBufferSize, err = strconv.Atoi(realtimeSendBufferSizeString)
+
+        if err != nil || realtimeSendBufferSize < 1 {
+            panic("realtime send buffer size must be a positive number")
+        }
+    }
+
+    realtimeHeartbeatInterval := realtime.DEFAULT_HEARTBEAT_INTERVAL
+    realtimeHeartbeatIntervalString := os.Getenv("REALTIME_HEARTBEAT_INTERVAL_IN_SECONDS")
+
+    if realtimeHeartbeatIntervalString != "" {
+        realtimeHeartbeatIntervalNumber, err := strconv.Atoi(realtimeHeartbeatIntervalString)
+
+        if err != nil || realtimeHeartbeatIntervalNumber < 1 {
+            panic("realtime heartbeat interval must be a positive number")
+        }
+
+        realtimeHeartbeatInterval = time.Duration(realtimeHeartbeatIntervalNumber) * time.Second
+    }
+
+    digestHour := emails.DEFAULT_DIGEST_HOUR
+    digestHourString := os.Getenv("DIGEST_HOUR_UTC")
+
+    if digestHourString != "" {
+        digestHour, err = strconv.Atoi(digestHourString)
+
+        if err != nil || digestHour < 0 || digestHour > 23 {
+            panic("digest hour must be a number from 0 to 23")
+        }
+    }
+
+    spotifyTimeout := spotify.DEFAULT_TIMEOUT
+    spotifyTimeoutString := os.Getenv("SPOTIFY_TIMEOUT_IN_SECONDS")
+
+    if spotifyTimeoutString != "" {
+        spotifyTimeoutNumber, err := strconv.Atoi(spotifyTimeoutString)
+
+        if err != nil || spotifyTimeoutNumber < 1 {
+            panic("spotify timeout must be a positive number")
+        }
+
+        spotifyTimeout = time.Duration(spotifyTimeoutNumber) * time.Second
+    }
+
+    spotifyMaxRetries := spotify.DEFAULT_MAX_RETRIES
+    spotifyMaxRetriesString := os.Getenv("SPOTIFY_MAX_RETRIES")
+
+    if spotifyMaxRetriesString != "" {
+        spotifyMaxRetries, err = strconv.Atoi(spotifyMaxRetriesString)
+
+        if err != nil || spotifyMaxRetries < 0 {
+            panic("spotify max retries must be a number")
+        }
+    }
+
+    catalogTTL := catalog.DEFAULT_TTL
+    catalogTTLString := os.Getenv("CATALOG_TTL_IN_HOURS")
+
+    if catalogTTLString != "" {
+        catalogTTLNumber, err := strconv.Atoi(catalogTTLString)
+
+        if err != nil || catalogTTLNumber < 1 {
+            panic("catalog TTL must be a positive number")
+        }
+
+        catalogTTL = time.Duration(catalogTTLNumber) * time.Hour
+    }
+
+    ratingScale := responses.DEFAULT_RATING_SCALE
+    ratingScaleSettings := map[string]*responses.Rating{"RATING_MIN": &ratingScale.Min, "RATING_MAX": &ratingScale.Max, "RATING_STEP": &ratingScale.Step}
+
+    for env, setting := range ratingScaleSettings {
+        settingString := os.Getenv(env)
+
+        if settingString != "" {
+            *setting, err = responses.ParseRating(settingString)
+
+            if err != nil {
+                panic(fmt.Sprintf("%s must be a number with at most two decimal places", env))
+            }
+        }
+    }
+
+    if !ratingScale.IsValid() {
+        panic("ratings must step evenly from RATING_MIN up to a RATING_MAX of at most 999.99")
+    }
+
+    validation.RatingScale = ratingScale
+
+    catalogRefreshInterval := catalog.DEFAULT_REFRESH_INTERVAL
+    catalogRefreshIntervalString := os.Getenv("CATALOG_REFRESH_INTERVAL_IN_MINUTES")
+
+    if catalogRefreshIntervalString != "" {
+        catalogRefreshIntervalNumber, err := strconv.Atoi(catalogRefreshIntervalString)
+
+        if err != nil || catalogRefreshIntervalNumber < 1 {
+            panic("catalog refresh interval must be a positive number")
+        }
+
+        catalogRefreshInterval = time.Duration(catalogRefreshIntervalNumber) * time.Minute
+    }
+
+    cursorSecret := os.Getenv("CURSOR_SECRET")
+
+    if len(cursorSecret) < cursor.MIN_SECRET_LENGTH {
+        panic(fmt.Sprintf("cursor secret must be at least %d characters", cursor.MIN_SECRET_LENGTH))
+    }
+
+    cursor.Secret = []byte(cursorSecret)
+
+    loginStateSecret := os.Getenv("LOGIN_STATE_SECRET")
+
+    if loginStateSecret == "" {
+        panic("login state secret must be set")
+    }
+
+    loginRedirectAllowlistString := os.Getenv("LOGIN_REDIRECT_ALLOWLIST")
+
+    if loginRedirectAllowlistString == "" {
+        loginRedirectAllowlistString = os.Getenv("FRONTEND_URI")
+    }
+
+    loginRedirectAllowlist, err := auth.ParseRedirectAllowlist(loginRedirectAllowlistString)
+
+    if err != nil {
+        panic(err)
+    }
+
+    cookiePolicy, err := cookies.NewCookiePolicyFromEnv()
+
+    if err != nil {
+        panic(err)
+    }
+
     cacheService := &cache.CacheService{Redis: redisConnection, CTX: context.Background()}
 
     usersDAO := &daos.UsersDAO{}
     postsDAO := &daos.PostsDAO{}
     commentsDAO := &daos.CommentsDAO{}
+    feedDAO := &daos.FeedDAO{}
+    searchDAO := &daos.SearchDAO{}
+    outboxDAO := &daos.OutboxDAO{}
+    notificationsDAO := &daos.NotificationsDAO{}
+    preferencesDAO := &daos.PreferencesDAO{}
+    digestDAO := &daos.DigestDAO{}
+    catalogDAO := &daos.CatalogDAO{}
+    subjectStatsDAO := &daos.SubjectStatsDAO{}
+    draftsDAO := &daos.DraftsDAO{}
+    sessionsDAO := &daos.SessionsDAO{}
+
+    storageService, err := storage.NewStorageServiceFromEnv()
+
+    if err != nil {
+        panic(err)
+    }
+
+    imageService := &images.ImageService{MaxBytes: profilePictureMaxBytes}
+    realtimeService := &realtime.RealtimeService{Redis: redisConnection, DB: db, SessionsDAO: sessionsDAO, SendBufferSize: realtimeSendBufferSize, HeartbeatInterval: realtimeHeartbeatInterval}
+    emailDispatcher := &emails.EmailDispatcher{PreferencesDAO: preferencesDAO, DigestDAO: digestDAO, OutboxDAO: outboxDAO}
+    userService := users.UserService{UsersDAO: usersDAO, FeedDAO: feedDAO, DB: db, CacheService: cacheService, TTL: userCacheTTLDuration, StorageService: storageService, ImageService: imageService, OutboxDAO: outboxDAO, NotificationsDAO: notificationsDAO, RealtimeService: realtimeService, PreferencesDAO: preferencesDAO, EmailDispatcher: emailDispatcher}
+    spotifyService := &spotify.SpotifyService{
+        Client: &http.Client{Timeout: spotifyTimeout},
+        AccountsBaseURL: os.Getenv("SPOTIFY_ACCOUNTS_BASE_URL"),
+        APIBaseURL: os.Getenv("SPOTIFY_API_BASE_URL"),
+        MaxRetries: spotifyMaxRetries,
+    }
+    encryptionService, err := encryption.NewEncryptionServiceFromEnv()
+
+    if err != nil {
+        panic(err)
+    }
+
+    tokenBroker := &tokenbroker.TokenBroker{DB: db, SessionsDAO: sessionsDAO, SpotifyService: spotifyService, EncryptionService: encryptionService}
+    catalogService := &catalog.CatalogService{DB: db, CatalogDAO: catalogDAO, SpotifyService: spotifyService, CacheService: cacheService, TTL: catalogTTL, CacheTTL: catalog.DEFAULT_CACHE_TTL, RefreshInterval: catalogRefreshInterval}
+    postsService := posts.PostsService{PostsDAO: postsDAO, UsersDAO: usersDAO, FeedDAO: feedDAO, CatalogService: catalogService, DB: db, OutboxDAO: outboxDAO, NotificationsDAO: notificationsDAO, DraftsDAO: draftsDAO, TokenBroker: tokenBroker, RealtimeService: realtimeService, EmailDispatcher: emailDispatcher}
+    commentsService := comments.CommentsService{CommentsDAO: commentsDAO, DB: db, OutboxDAO: outboxDAO, NotificationsDAO: notificationsDAO, RealtimeService: realtimeService, EmailDispatcher: emailDispatcher}
+    searchService := search.SearchService{SearchDAO: searchDAO, StorageService: storageService, DB: db}
+    healthService := health.HealthService{DB: db, RabbitMQService: &rabbitMQService}
+    notificationsService := notifications.NotificationsService{DB: db, NotificationsDAO: notificationsDAO}
+    subjectsService := subjects.SubjectsService{DB: db, PostsDAO: postsDAO, SubjectStatsDAO: subjectStatsDAO, CatalogService: catalogService, TokenBroker: tokenBroker, RatingScale: ratingScale}
+    diaryService := diary.DiaryService{DB: db, DraftsDAO: draftsDAO, SpotifyService: spotifyService, CatalogService: catalogService, TokenBroker: tokenBroker}
+    jwtService, err := jwt.NewJWTServiceFromEnv()
+
+    if err != nil {
+        panic(err)
+    }
+
+    authService := auth.AuthService{UsersDAO: usersDAO, SessionsDAO: sessionsDAO, SpotifyService: spotifyService, JWTService: jwtService, TokenBroker: tokenBroker, LoginStateSecret: []byte(loginStateSecret), LoginRedirectAllowlist: loginRedirectAllowlist, CookiePolicy: cookiePolicy, RealtimeService: realtimeService, StorageService: storageService, DB: db}
 
-    s3Service := &s3Service.S3Service{}
-    s3Service.InitClient()
-    spotifyService := &spotify.SpotifyService{}
-    userService := users.UserService{UsersDAO: usersDAO, DB: db, CacheService: cacheService, TTL: userCacheTTLDuration, S3Service: s3Service}
-    postsService := posts.PostsService{PostsDAO: postsDAO, UsersDAO: usersDAO, SpotifyService: spotifyService, DB: db, RabbitMQService: &rabbitMQService}
-    commentsService := comments.CommentsService{CommentsDAO: commentsDAO, DB: db}
-    jwtService := &jwt.JWTService{}
-    authService := auth.AuthService{UsersDAO: usersDAO, SpotifyService: spotifyService, JWTService: jwtService, DB: db}
+    outboxRelay := &outbox.OutboxRelay{DB: db, OutboxDAO: outboxDAO, RabbitMQService: &rabbitMQService, PollInterval: outboxPollInterval, BatchSize: outboxBatchSize}
+    go outboxRelay.Run(context.Background())
+    go catalogService.Run(context.Background())
+    go realtimeService.Run(context.Background())
+    digestBuilder := &emails.DigestBuilder{DB: db, DigestDAO: digestDAO, OutboxDAO: outboxDAO, Hour: digestHour}
+    go digestBuilder.Run(context.Background())
 
-	r := server.InitializeHttpServer(&userService, &postsService, &commentsService, &authService)
+	r := server.InitializeHttpServer(&userService, &postsService, &commentsService, &searchService, &authService, storageService, &healthService, &notificationsService, realtimeService, &subjectsService, &diaryService)
 
     port := os.Getenv("PORT")
     r.Run(fmt.Sprintf(":%s", port))
@@ -99,10 +99,14 @@ func wrapJWTErrors(err error, customError *CustomError) bool {
 		customError.StatusCode = http.StatusUnauthorized
 		customError.Msg = "Please refresh JWT"
         return true
-	} else if errors.Is(err, jwt.ErrTokenMalformed) || errors.Is(err, jwt.ErrSignatureInvalid) || errors.Is(err, jwt.ErrTokenUnverifiable) {
+	} else if errors.Is(err, jwt.ErrTokenMalformed) || errors.Is(err, jwt.ErrSignatureInvalid) || errors.Is(err, jwt.ErrTokenSignatureInvalid) || errors.Is(err, jwt.ErrTokenUnverifiable) {
 		customError.StatusCode = http.StatusForbidden
 		customError.Msg = "JWT has been tampered with"
         return true
+	} else if errors.Is(err, jwt.ErrTokenInvalidIssuer) || errors.Is(err, jwt.ErrTokenInvalidAudience) || errors.Is(err, jwt.ErrTokenInvalidSubject) || errors.Is(err, jwt.ErrTokenRequiredClaimMissing) || errors.Is(err, jwt.ErrTokenNotValidYet) || errors.Is(err, jwt.ErrTokenUsedBeforeIssued) {
+		customError.StatusCode = http.StatusUnauthorized
+		customError.Msg = "JWT was not issued for this use"
+        return true
 	}
     return false
 }
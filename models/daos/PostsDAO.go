@@ -2,8 +2,11 @@ package daos
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
@@ -13,113 +16,152 @@ import (
 
 type PostsDAO struct { }
 
+var postsKeyset = cursor.Keyset{SortColumn: "posts.createdat", SortType: "timestamptz", IDColumn: "posts.postid", IDType: "bigint", Descending: true}
+var postCommentsKeyset = cursor.Keyset{SortColumn: "comments.createdat", SortType: "timestamptz", IDColumn: "comments.commentid", IDType: "int", Descending: true}
+var postVotersKeyset = cursor.Keyset{SortColumn: "post_votes.voterspotifyid", SortType: "text", IDColumn: "post_votes.voterspotifyid", IDType: "text"}
+
+// Columns selected by every query that reads posts, in the order scanPostPreview expects them. Queries join the
+// catalog with postCatalogJoins, the poster as users, and the viewers vote as viewer_votes
+var postColumns = fmt.Sprintf(`posts.postid, posts.listenedat, posts.relisten, albums.albumarturi, albums.albumid, albums.name, albums.releasedate, posts.createdat, posts.rating, posts.subjecttype, posts.songid, coalesce(tracks.name, albums.name, subject_artists.name), coalesce(albums.albumarturi, subject_artists.imageuri), tracks.name, tracks.durationms, %s, posts.review, posts.updatedat, posts.posterspotifyid, users.username, posts.likes, posts.dislikes, viewer_votes.liked`, postArtistsColumn)
+
+// Matches the posts shown on the page of each type of subject, with the subject ID as $1. Album pages also show the
+// reviews of the albums tracks
+var subjectPostsConditions = map[responses.SubjectType]string{
+    responses.TRACK: `posts.subjecttype = 'TRACK' AND posts.songid = $1`,
+    responses.ALBUM: `((posts.subjecttype = 'ALBUM' AND posts.songid = $1) OR (posts.subjecttype = 'TRACK' AND posts.songid IN (SELECT trackid FROM tracks WHERE albumid = $1)))`,
+    responses.ARTIST: `posts.subjecttype = 'ARTIST' AND posts.songid = $1`,
+}
+
 type IPostsDAO interface {
-    CreatePost(executor db.QueryExecutor, spotifyID string, songID string, songName string, albumID string, albumName string, albumImage string, rating int, text string, createdAt time.Time, username string) (*responses.PostPreview, error) 
-    GetPostProperties(executor db.QueryExecutor, postID string, spotifyID string) (*responses.PostPreview, error)
-    GetUserPostsProperties(executor db.QueryExecutor, spotifyID string, createdAt time.Time) ([]responses.PostPreview, error)
-    GetPostVotes(executor db.QueryExecutor, postID string, spotifyID string) ([]responses.UserIdentifer, []responses.UserIdentifer, error)
-    RemovePostVote(executor db.QueryExecutor, voterSpotifyID string, posterSpotifyID string, songID string) error 
-    UpdatePost(executor db.QueryExecutor, spotifyID string, songID string, updatePostRequest *requests.UpdatePostRequestDTO, username string) (*responses.PostPreview, error) 
-    LikePost(executor db.QueryExecutor, spotifyID string, posterSpotifyID string, songID string) error
-    DislikePost(executor db.QueryExecutor, spotifyID string, posterSpotifyID string, songID string) error
-    DeletePost(executor db.QueryExecutor, songID string, spotifyID string) error
-    GetPostComments(executor db.QueryExecutor, spotifyID string, songID string, paginationKey time.Time) ([]responses.Comment, error)
+    CreatePost(executor db.QueryExecutor, spotifyID string, subjectType responses.SubjectType, subjectID string, rating *responses.Rating, text string, listenedAt time.Time, relisten bool, createdAt time.Time) (*responses.PostPreview, error) 
+    GetPostProperties(executor db.QueryExecutor, postID int64, viewerSpotifyID string) (*responses.PostPreview, error)
+    GetLatestSubjectPost(executor db.QueryExecutor, spotifyID string, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string) (*responses.PostPreview, error)
+    GetUserPostsProperties(executor db.QueryExecutor, spotifyID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error)
+    GetAlbumPosts(executor db.QueryExecutor, albumID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error)
+    GetMostLikedSubjectPosts(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, limit int) ([]responses.PostPreview, error)
+    GetFollowedSubjectPosts(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, limit int) ([]responses.PostPreview, error)
+    GetPostVoters(executor db.QueryExecutor, postID int64, vote *responses.Vote, page *cursor.PageRequest) ([]responses.Voter, error)
+    RemovePostVote(executor db.QueryExecutor, voterSpotifyID string, postID int64) error 
+    UpdatePost(executor db.QueryExecutor, spotifyID string, postID int64, updatePostRequest *requests.UpdatePostRequestDTO, username string) (*responses.PostPreview, error) 
+    LikePost(executor db.QueryExecutor, spotifyID string, postID int64) (bool, error)
+    DislikePost(executor db.QueryExecutor, spotifyID string, postID int64) (bool, error)
+    DeletePost(executor db.QueryExecutor, postID int64) error
+    GetPostComments(executor db.QueryExecutor, postID int64, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.Comment, error)
 }
 
-func(p *PostsDAO) CreatePost(executor db.QueryExecutor, spotifyID string, songID string, songName string, albumID string, albumName string, albumImage string, rating int, text string, createdAt time.Time, username string) (*responses.PostPreview, error) {
+// The subject must already be in the catalog. The post is flagged as a relisten when asked to, or when the user has
+// already posted about the subject. Returns the post as GetPostProperties would
+func(p *PostsDAO) CreatePost(executor db.QueryExecutor, spotifyID string, subjectType responses.SubjectType, subjectID string, rating *responses.Rating, text string, listenedAt time.Time, relisten bool, createdAt time.Time) (*responses.PostPreview, error) {
 
-	query := `INSERT INTO posts (albumarturi, albumid, albumname, createdat, rating, songid, songname, review, updatedat, posterspotifyid) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `INSERT INTO posts (createdat, rating, subjecttype, songid, review, updatedat, posterspotifyid, listenedat, relisten) 
+              VALUES ($1, $2, $3, $4, $5, $1, $6, $7, $8 OR EXISTS (SELECT 1 FROM posts WHERE posterspotifyid = $6 AND subjecttype = $3 AND songid = $4))
+              RETURNING postid`
 
-	_, err := executor.Exec(query, albumImage, albumID, albumName, createdAt, rating, songID, songName, text, createdAt, spotifyID)
+	postID := int64(0)
+	err := executor.QueryRow(query, createdAt, rating, subjectType, subjectID, text, spotifyID, listenedAt, relisten).Scan(&postID)
 
 	if err != nil {
 		return nil, customerrors.WrapBasicError(err)
 	}
 
-	postPreview := &responses.PostPreview{}
-	postPreview.SpotifyID = spotifyID
-	postPreview.SongID = songID
-	postPreview.SongName = songName
-	postPreview.AlbumID = albumID
-	postPreview.AlbumName = albumName
-	postPreview.AlbumArtURI = albumImage
-	postPreview.Rating = rating
-	postPreview.Text = text
-	postPreview.CreatedAt = createdAt
-	postPreview.UpdatedAt = createdAt
-	postPreview.Username = username
-
-	return postPreview, nil
+	return p.GetPostProperties(executor, postID, spotifyID)
 }
 
-func(p *PostsDAO) GetPostVotes(executor db.QueryExecutor, postID string, spotifyID string)  ([]responses.UserIdentifer, []responses.UserIdentifer, error) {
-        query2 := `SELECT post_votes.voterspotifyid, users.username, post_votes.liked 
-                   FROM post_votes INNER JOIN users ON post_votes.voterspotifyid = users.spotifyid
-                   WHERE post_votes.posterspotifyid = $1 AND post_votes.postsongid = $2`
+func(p *PostsDAO) GetPostVoters(executor db.QueryExecutor, postID int64, vote *responses.Vote, page *cursor.PageRequest) ([]responses.Voter, error) {
 
-       likes := []responses.UserIdentifer{}
-       dislikes := []responses.UserIdentifer{}
+    liked := sql.NullBool{}
 
+    if vote != nil {
+        liked = sql.NullBool{Bool: *vote == responses.LIKE, Valid: true}
+    }
 
-       rows, err := executor.Query(query2, spotifyID, postID)
+    condition, orderBy, args := page.Clause(postVotersKeyset, 3)
 
-        if err != nil {
-            return nil, nil, customerrors.WrapBasicError(err)
-        }
+    query := fmt.Sprintf(`SELECT post_votes.voterspotifyid, users.username, post_votes.liked 
+              FROM post_votes INNER JOIN users ON post_votes.voterspotifyid = users.spotifyid
+              WHERE post_votes.postid = $1 AND ($2::boolean IS NULL OR post_votes.liked = $2) %s 
+              %s 
+              LIMIT %d`, condition, orderBy, page.Limit())
 
-        for rows.Next() {
-            userID := responses.UserIdentifer{}
-            liked := true
-            rows.Scan(&userID.SpotifyID, &userID.Username, &liked)
-            if liked {
-                likes = append(likes, userID)
-            } else {
-                dislikes = append(dislikes, userID)
-            }
+    rows, err := executor.Query(query, append([]any{postID, liked}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    voters := []responses.Voter{}
+
+    for rows.Next() {
+        voter := responses.Voter{}
+        liked := true
+        err := rows.Scan(&voter.SpotifyID, &voter.Username, &liked)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
         }
+        voter.Vote = responses.VoteFromLiked(liked)
+        voters = append(voters, voter)
+    }
 
-        return likes, dislikes, nil
+    return voters, nil
 
 }
-func(p *PostsDAO) GetPostProperties(executor db.QueryExecutor, postID string, spotifyID string) (*responses.PostPreview, error) {
-    post := &responses.PostPreview{}
 
-    query := `SELECT albumarturi, albumid, albumname, createdat, rating, songid, songname, review, updatedat, posterspotifyid, username 
+func(p *PostsDAO) GetPostProperties(executor db.QueryExecutor, postID int64, viewerSpotifyID string) (*responses.PostPreview, error) {
+
+    query := fmt.Sprintf(`SELECT %s 
               FROM posts 
+              %s
               INNER JOIN users ON users.spotifyid = posts.posterspotifyid 
-              WHERE posts.posterspotifyid = $1 AND posts.songid = $2`
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $2
+              WHERE posts.postid = $1`, postColumns, postCatalogJoins)
 
-    row := executor.QueryRow(query, spotifyID, postID)
+    row := executor.QueryRow(query, postID, viewerSpotifyID)
 
-    albumArtUri := sql.NullString{}
-
-    err := row.Scan(&albumArtUri,
-        &post.AlbumID,
-        &post.AlbumName,
-        &post.CreatedAt,
-        &post.Rating,
-        &post.SongID,
-        &post.SongName,
-        &post.Text,
-        &post.UpdatedAt,
-        &post.SpotifyID,
-        &post.Username)
+    post, err := scanPostPreview(row)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
     }
 
-    post.AlbumArtURI = albumArtUri.String
+    return post, nil
+
+}
+
+// The users most recent listen of the subject
+func(p *PostsDAO) GetLatestSubjectPost(executor db.QueryExecutor, spotifyID string, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string) (*responses.PostPreview, error) {
+
+    query := fmt.Sprintf(`SELECT %s 
+              FROM posts 
+              %s
+              INNER JOIN users ON users.spotifyid = posts.posterspotifyid 
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $4
+              WHERE posts.posterspotifyid = $1 AND posts.subjecttype = $2 AND posts.songid = $3
+              ORDER BY posts.listenedat DESC, posts.postid DESC
+              LIMIT 1`, postColumns, postCatalogJoins)
+
+    row := executor.QueryRow(query, spotifyID, subjectType, subjectID, viewerSpotifyID)
+
+    post, err := scanPostPreview(row)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
 
     return post, nil
 
 }
 
-func(p *PostsDAO) RemovePostVote(executor db.QueryExecutor, voterSpotifyID string, posterSpotifyID string, songID string) error {
-	query := `DELETE FROM post_votes WHERE voterspotifyid = $1 AND posterspotifyid = $2 AND postsongid = $3`
+// The vote is removed and the posts counters are decremented in a single statement, so they can never drift apart
+func(p *PostsDAO) RemovePostVote(executor db.QueryExecutor, voterSpotifyID string, postID int64) error {
+	query := `WITH vote AS (
+                  DELETE FROM post_votes WHERE voterspotifyid = $1 AND postid = $2 RETURNING liked
+              )
+              UPDATE posts SET likes = likes - (SELECT count(*) FROM vote WHERE liked), dislikes = dislikes - (SELECT count(*) FROM vote WHERE NOT liked)
+              WHERE postid = $2 AND EXISTS (SELECT 1 FROM vote)`
 
-	res, err := executor.Exec(query, voterSpotifyID, posterSpotifyID, songID)
+	res, err := executor.Exec(query, voterSpotifyID, postID)
 
 	if err != nil {
 		return customerrors.WrapBasicError(err)
@@ -139,10 +181,10 @@ func(p *PostsDAO) RemovePostVote(executor db.QueryExecutor, voterSpotifyID strin
 }
 
 
-func(p *PostsDAO) DeletePost(executor db.QueryExecutor, songID string, spotifyID string) error {
-	query := `DELETE FROM posts WHERE posterspotifyid = $1 AND songid = $2`
+func(p *PostsDAO) DeletePost(executor db.QueryExecutor, postID int64) error {
+	query := `DELETE FROM posts WHERE postid = $1`
 
-	res, err := executor.Exec(query, spotifyID, songID)
+	res, err := executor.Exec(query, postID)
 
 	if err != nil {
 		return customerrors.WrapBasicError(err)
@@ -161,7 +203,8 @@ func(p *PostsDAO) DeletePost(executor db.QueryExecutor, songID string, spotifyID
 	return nil
 }
 
-func(p *PostsDAO) UpdatePost(executor db.QueryExecutor, spotifyID string, songID string, updatePostRequest *requests.UpdatePostRequestDTO, username string) (*responses.PostPreview, error) {
+// Only updates the post if spotifyID posted it
+func(p *PostsDAO) UpdatePost(executor db.QueryExecutor, spotifyID string, postID int64, updatePostRequest *requests.UpdatePostRequestDTO, username string) (*responses.PostPreview, error) {
 
     postPreview := &responses.PostPreview{}
 
@@ -171,31 +214,35 @@ func(p *PostsDAO) UpdatePost(executor db.QueryExecutor, spotifyID string, songID
     t := time.Now().UTC()
     updatedPostRequestMap["updatedat"] = &t
 
+    if updatePostRequest.Rating.Set {
+        updatedPostRequestMap["rating"] = &updatePostRequest.Rating
+    }
+
     conditionals := make(map[string]any)
     conditionals["posterspotifyid"] = spotifyID
-    conditionals["songid"] = songID
+    conditionals["postid"] = postID
 
-    returning := []string{"albumarturi", "albumid", "albumname", "createdat", "rating", "songid", "songname", "review", "updatedat", "posterspotifyid"}
+    returning := []string{"postid", "listenedat", "relisten", "createdat", "rating", "subjecttype", "songid", "review", "updatedat", "posterspotifyid", "likes", "dislikes"}
 
     query, vals := db.PatchQueryBuilder("posts", updatedPostRequestMap, conditionals, returning)
 
     res := executor.QueryRow(query, vals...)
 
-    albumArtUri := sql.NullString{}
-    err := res.Scan(&albumArtUri,
-        &postPreview.AlbumID,
-        &postPreview.AlbumName,
+    err := res.Scan(&postPreview.PostID,
+        &postPreview.ListenedAt,
+        &postPreview.Relisten,
         &postPreview.CreatedAt,
         &postPreview.Rating,
+        &postPreview.SubjectType,
         &postPreview.SongID,
-        &postPreview.SongName,
         &postPreview.Text,
         &postPreview.UpdatedAt,
-        &postPreview.SpotifyID)
+        &postPreview.SpotifyID,
+        &postPreview.Likes,
+        &postPreview.Dislikes)
 
 
     postPreview.Username = username
-    postPreview.AlbumArtURI = albumArtUri.String
 
     if err != nil {
         return nil, err
@@ -204,92 +251,78 @@ func(p *PostsDAO) UpdatePost(executor db.QueryExecutor, spotifyID string, songID
 	return postPreview, nil
 }
 
-func(p *PostsDAO) LikePost(executor db.QueryExecutor, spotifyID string, posterSpotifyID string, songID string) error {
-
-    query := `INSERT INTO post_votes (voterspotifyid, posterspotifyid, postsongid, createdat, updatedat, liked) 
-              VALUES ($1, $2, $3, $4, $5, $6) 
-              ON CONFLICT (voterspotifyid, posterspotifyid, postsongid) DO UPDATE SET updatedat=$5, liked=$6`
-
-    res, err := executor.Exec(query,
-        spotifyID,
-        posterSpotifyID,
-        songID,
-        time.Now().UTC(),
-        time.Now().UTC(),
-        true)
-
-    if err != nil {
-        return customerrors.WrapBasicError(err)
-    }
+func(p *PostsDAO) LikePost(executor db.QueryExecutor, spotifyID string, postID int64) (bool, error) {
 
-    rows, err := res.RowsAffected()
+    return p.votePost(executor, spotifyID, postID, true)
 
-    if err != nil {
-        return customerrors.WrapBasicError(err)
-    }
+}
 
-    if rows < 1 {
-        return customerrors.WrapBasicError(sql.ErrNoRows)
-    }
+func(p *PostsDAO) DislikePost(executor db.QueryExecutor, spotifyID string, postID int64) (bool, error) {
 
-    return nil
+    return p.votePost(executor, spotifyID, postID, false)
 
 }
 
-func(p *PostsDAO) DislikePost(executor db.QueryExecutor, spotifyID string, posterSpotifyID string, songID string) error {
-
-    query := `INSERT INTO post_votes (voterspotifyid, posterspotifyid, postsongid, createdat, updatedat, liked) 
-              VALUES ($1, $2, $3, $4, $5, $6) 
-              ON CONFLICT (voterspotifyid, posterspotifyid, postsongid) DO UPDATE SET updatedat=$5, liked=$6`
-
-    res, err := executor.Exec(query,
-        spotifyID,
-        posterSpotifyID,
-        songID,
-        time.Now().UTC(),
-        time.Now().UTC(),
-        false)
+// Upserts a vote and adjusts the posts counters in a single statement. A vote that changes sides moves one count from
+// the old side to the new one. Voting the same way twice matches no rows and changes nothing. Returns whether the vote
+// changed
+func(p *PostsDAO) votePost(executor db.QueryExecutor, spotifyID string, postID int64, liked bool) (bool, error) {
+
+    query := `WITH vote AS (
+                  INSERT INTO post_votes (voterspotifyid, postid, createdat, updatedat, liked) 
+                  VALUES ($1, $2, $3, $3, $4) 
+                  ON CONFLICT (voterspotifyid, postid) DO UPDATE SET updatedat = $3, liked = $4
+                  WHERE post_votes.liked <> EXCLUDED.liked
+                  RETURNING (xmax = 0) AS inserted
+              )
+              UPDATE posts SET 
+                  likes = likes + CASE WHEN $4 THEN 1 ELSE -(SELECT count(*) FROM vote WHERE NOT inserted) END,
+                  dislikes = dislikes + CASE WHEN $4 THEN -(SELECT count(*) FROM vote WHERE NOT inserted) ELSE 1 END
+              WHERE postid = $2 AND EXISTS (SELECT 1 FROM vote)`
+
+    res, err := executor.Exec(query, spotifyID, postID, time.Now().UTC(), liked)
 
     if err != nil {
-        return customerrors.WrapBasicError(err)
+        return false, customerrors.WrapBasicError(err)
     }
 
     rows, err := res.RowsAffected()
 
     if err != nil {
-        return customerrors.WrapBasicError(err)
-    }
-
-    if rows < 1 {
-        return customerrors.WrapBasicError(sql.ErrNoRows)
+        return false, customerrors.WrapBasicError(err)
     }
 
-    return nil
+    return rows > 0, nil
 
 }
 
-func(p *PostsDAO) GetPostComments(executor db.QueryExecutor, spotifyID string, songID string, paginationKey time.Time) ([]responses.Comment, error) {
+func(p *PostsDAO) GetPostComments(executor db.QueryExecutor, postID int64, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.Comment, error) {
 
-    query := `SELECT commentid, commentorspotifyid, posterspotifyid, songid, commenttext, createdat, updatedat 
+    condition, orderBy, args := page.Clause(postCommentsKeyset, 3)
+
+    query := fmt.Sprintf(`SELECT %s 
               FROM comments
-              WHERE posterspotifyid = $1 AND songid = $2 AND createdAt < $3 
-              ORDER BY createdat DESC 
-              LIMIT 25 `
+              INNER JOIN users ON users.spotifyid = comments.commentorspotifyid
+              LEFT JOIN comment_votes AS viewer_votes ON viewer_votes.commentid = comments.commentid AND viewer_votes.voterspotifyid = $2
+              WHERE comments.postid = $1 AND comments.parentcommentid IS NULL %s 
+              %s 
+              LIMIT %d `, commentColumns, condition, orderBy, page.Limit())
 
 
-    rows, err := executor.Query(query, spotifyID, songID, paginationKey)
+    rows, err := executor.Query(query, append([]any{postID, viewerSpotifyID}, args...)...)
 
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
     }
 
+    defer rows.Close()
+
     comments := []responses.Comment{}
 
     for rows.Next() {
 
-        comment := &responses.Comment{}
-        err := rows.Scan(&comment.CommentID, &comment.CommentorID, &comment.PostSpotifyID, &comment.SongID, &comment.CommentText, &comment.CreatedAt, &comment.UpdatedAt)
+        comment, err := scanComment(rows)
 
         if err != nil {
             return nil, customerrors.WrapBasicError(err)
@@ -304,34 +337,187 @@ func(p *PostsDAO) GetPostComments(executor db.QueryExecutor, spotifyID string, s
 }
 
 
-func(p *PostsDAO) GetUserPostsProperties(executor db.QueryExecutor, spotifyID string, createdAt time.Time) ([]responses.PostPreview, error) {
-    query := `SELECT posts.albumarturi, posts.albumid, posts.albumname, posts.createdat, posts.rating, posts.songid, posts.songname, posts.review, posts.updatedat, posts.posterspotifyid, users.username
+func(p *PostsDAO) GetUserPostsProperties(executor db.QueryExecutor, spotifyID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error) {
+
+    condition, orderBy, args := page.Clause(postsKeyset, 3)
+
+    query := fmt.Sprintf(`SELECT %s
                 FROM posts 
+                %s
                 INNER JOIN users 
                 ON users.spotifyid = posts.posterspotifyid
-                WHERE posts.posterspotifyid = $1 AND posts.createdat < $2 ORDER BY posts.createdat LIMIT 25 `
+                LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $2
+                WHERE posts.posterspotifyid = $1 %s %s LIMIT %d `, postColumns, postCatalogJoins, condition, orderBy, page.Limit())
 
     postPreviews := []responses.PostPreview{}
 
-        rows, err := executor.Query(query, spotifyID, createdAt)
+        rows, err := executor.Query(query, append([]any{spotifyID, viewerSpotifyID}, args...)...)
 
         if err != nil {
             return nil, customerrors.WrapBasicError(err)
         }
 
+        defer rows.Close()
 
         for rows.Next() {
-            post := responses.PostPreview{}
-            albumArtUri := sql.NullString{}
-            err := rows.Scan(&albumArtUri, &post.AlbumID, &post.AlbumName, &post.CreatedAt, &post.Rating, &post.SongID, &post.SongName, &post.Text, &post.UpdatedAt, &post.SpotifyID, &post.Username)
+            post, err := scanPostPreview(rows)
             if err != nil {
                 return nil, customerrors.WrapBasicError(err)
             }
-            post.AlbumArtURI = albumArtUri.String
-            post.Likes = []responses.UserIdentifer{}
-            post.Dislikes = []responses.UserIdentifer{}
-            postPreviews = append(postPreviews, post)
+            postPreviews = append(postPreviews, *post)
         }
 
         return postPreviews, nil
 }
+
+// Reviews of the album itself along with reviews of each of its tracks, newest first
+func(p *PostsDAO) GetAlbumPosts(executor db.QueryExecutor, albumID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error) {
+
+    condition, orderBy, args := page.Clause(postsKeyset, 3)
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM posts 
+              %s
+              INNER JOIN users ON users.spotifyid = posts.posterspotifyid
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $2
+              WHERE %s %s 
+              %s 
+              LIMIT %d`, postColumns, postCatalogJoins, subjectPostsConditions[responses.ALBUM], condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{albumID, viewerSpotifyID}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    postPreviews := []responses.PostPreview{}
+
+    for rows.Next() {
+        post, err := scanPostPreview(rows)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        postPreviews = append(postPreviews, *post)
+    }
+
+    return postPreviews, nil
+}
+
+// The most liked reviews on the page of a subject
+func(p *PostsDAO) GetMostLikedSubjectPosts(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, limit int) ([]responses.PostPreview, error) {
+    return p.getSubjectPosts(executor, subjectType, subjectID, viewerSpotifyID, "", "ORDER BY posts.likes DESC, posts.createdat DESC", limit)
+}
+
+// The newest reviews on the page of a subject written by users the viewer follows
+func(p *PostsDAO) GetFollowedSubjectPosts(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, limit int) ([]responses.PostPreview, error) {
+    return p.getSubjectPosts(executor, subjectType, subjectID, viewerSpotifyID, "AND posts.posterspotifyid IN (SELECT userfollowed FROM followers WHERE follower = $2)", "ORDER BY posts.createdat DESC", limit)
+}
+
+func(p *PostsDAO) getSubjectPosts(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, filter string, orderBy string, limit int) ([]responses.PostPreview, error) {
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM posts 
+              %s
+              INNER JOIN users ON users.spotifyid = posts.posterspotifyid
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $2
+              WHERE %s %s 
+              %s 
+              LIMIT %d`, postColumns, postCatalogJoins, subjectPostsConditions[subjectType], filter, orderBy, limit)
+
+    rows, err := executor.Query(query, subjectID, viewerSpotifyID)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    postPreviews := []responses.PostPreview{}
+
+    for rows.Next() {
+        post, err := scanPostPreview(rows)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        postPreviews = append(postPreviews, *post)
+    }
+
+    return postPreviews, nil
+}
+
+func voteFromNullBool(liked sql.NullBool) *responses.Vote {
+
+    if !liked.Valid {
+        return nil
+    }
+
+    vote := responses.VoteFromLiked(liked.Bool)
+
+    return &vote
+}
+
+// Any extra destinations are scanned from the columns following postColumns
+func scanPostPreview(row rowScanner, extra ...any) (*responses.PostPreview, error) {
+
+    post := &responses.PostPreview{}
+    albumArtURI := sql.NullString{}
+    albumID := sql.NullString{}
+    albumName := sql.NullString{}
+    releaseDate := sql.NullString{}
+    subjectName := sql.NullString{}
+    imageURI := sql.NullString{}
+    songName := sql.NullString{}
+    durationMs := sql.NullInt64{}
+    artists := []byte{}
+    liked := sql.NullBool{}
+
+    dest := []any{&post.PostID,
+        &post.ListenedAt,
+        &post.Relisten,
+        &albumArtURI,
+        &albumID,
+        &albumName,
+        &releaseDate,
+        &post.CreatedAt,
+        &post.Rating,
+        &post.SubjectType,
+        &post.SongID,
+        &subjectName,
+        &imageURI,
+        &songName,
+        &durationMs,
+        &artists,
+        &post.Text,
+        &post.UpdatedAt,
+        &post.SpotifyID,
+        &post.Username,
+        &post.Likes,
+        &post.Dislikes,
+        &liked}
+
+    err := row.Scan(append(dest, extra...)...)
+
+    if err != nil {
+        return nil, err
+    }
+
+    err = json.Unmarshal(artists, &post.Artists)
+
+    if err != nil {
+        return nil, err
+    }
+
+    post.AlbumArtURI = albumArtURI.String
+    post.AlbumID = albumID.String
+    post.AlbumName = albumName.String
+    post.ReleaseDate = releaseDate.String
+    post.SubjectName = subjectName.String
+    post.ImageURI = imageURI.String
+    post.SongName = songName.String
+    post.DurationMs = int(durationMs.Int64)
+    post.CurrentUserVote = voteFromNullBool(liked)
+
+    return post, nil
+}
@@ -0,0 +1,328 @@
+package daos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/lib/pq"
+)
+
+type CatalogDAO struct { }
+
+// The artists of a track or album as a JSON array, in the order Spotify lists them
+func artistsColumn(joinTable string, keyColumn string, key string) string {
+    return fmt.Sprintf(`coalesce((SELECT json_agg(json_build_object('ArtistID', artists.artistid, 'Name', artists.name, 'ImageURI', coalesce(artists.imageuri, ''), 'FetchedAt', artists.fetchedat) ORDER BY %[1]s.position)
+              FROM %[1]s INNER JOIN artists ON artists.artistid = %[1]s.artistid
+              WHERE %[1]s.%[2]s = %[3]s), '[]')`, joinTable, keyColumn, key)
+}
+
+var trackArtistsColumn = artistsColumn("track_artists", "trackid", "tracks.trackid")
+var albumArtistsColumn = artistsColumn("album_artists", "albumid", "albums.albumid")
+
+// The artists of whatever a post is about. Artist posts are about a single artist, joined as subject_artists
+var postArtistsColumn = fmt.Sprintf(`CASE posts.subjecttype
+                  WHEN 'TRACK' THEN %s
+                  WHEN 'ALBUM' THEN %s
+                  ELSE json_build_array(json_build_object('ArtistID', subject_artists.artistid, 'Name', subject_artists.name, 'ImageURI', coalesce(subject_artists.imageuri, ''), 'FetchedAt', subject_artists.fetchedat))
+              END`, trackArtistsColumn, albumArtistsColumn)
+
+// Joins the subject of each post, which every query that reads posts needs. Track posts join their track and its
+// album, album posts just the album, and artist posts just the artist
+const postCatalogJoins = `LEFT JOIN tracks ON posts.subjecttype = 'TRACK' AND tracks.trackid = posts.songid
+              LEFT JOIN albums ON albums.albumid = CASE posts.subjecttype WHEN 'ALBUM' THEN posts.songid ELSE tracks.albumid END
+              LEFT JOIN artists AS subject_artists ON posts.subjecttype = 'ARTIST' AND subject_artists.artistid = posts.songid`
+
+// The catalog table and key column holding each type of subject
+var catalogTables = map[responses.SubjectType][2]string{
+    responses.TRACK: {"tracks", "trackid"},
+    responses.ALBUM: {"albums", "albumid"},
+    responses.ARTIST: {"artists", "artistid"},
+}
+
+type ICatalogDAO interface {
+    GetTrack(executor db.QueryExecutor, trackID string) (*responses.Track, error)
+    GetAlbum(executor db.QueryExecutor, albumID string) (*responses.Album, error)
+    GetArtist(executor db.QueryExecutor, artistID string) (*responses.Artist, error)
+    UpsertTrack(executor db.QueryExecutor, track *responses.Track) error
+    UpsertAlbum(executor db.QueryExecutor, album *responses.Album) error
+    UpsertArtist(executor db.QueryExecutor, artist *responses.Artist) error
+    GetStaleSubjectIDs(executor db.QueryExecutor, subjectType responses.SubjectType, fetchedBefore time.Time, limit int) ([]string, error)
+    MarkSubjectsFetched(executor db.QueryExecutor, subjectType responses.SubjectType, subjectIDs []string, fetchedAt time.Time) error
+}
+
+// Returns a 404 if the track is not in the catalog yet
+func(c *CatalogDAO) GetTrack(executor db.QueryExecutor, trackID string) (*responses.Track, error) {
+
+    query := fmt.Sprintf(`SELECT tracks.trackid, tracks.name, tracks.durationms, tracks.fetchedat, %s,
+                  albums.albumid, albums.name, albums.albumarturi, albums.releasedate, albums.releasedateprecision, albums.fetchedat, %s
+              FROM tracks
+              INNER JOIN albums ON albums.albumid = tracks.albumid
+              WHERE tracks.trackid = $1`, trackArtistsColumn, albumArtistsColumn)
+
+    track := &responses.Track{}
+    durationMs := sql.NullInt64{}
+    trackArtists := []byte{}
+    albumArtURI := sql.NullString{}
+    releaseDate := sql.NullString{}
+    releaseDatePrecision := sql.NullString{}
+    albumArtists := []byte{}
+
+    err := executor.QueryRow(query, trackID).Scan(
+        &track.TrackID,
+        &track.Name,
+        &durationMs,
+        &track.FetchedAt,
+        &trackArtists,
+        &track.Album.AlbumID,
+        &track.Album.Name,
+        &albumArtURI,
+        &releaseDate,
+        &releaseDatePrecision,
+        &track.Album.FetchedAt,
+        &albumArtists,
+    )
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    track.DurationMs = int(durationMs.Int64)
+    track.Album.AlbumArtURI = albumArtURI.String
+    track.Album.ReleaseDate = releaseDate.String
+    track.Album.ReleaseDatePrecision = releaseDatePrecision.String
+
+    err = json.Unmarshal(trackArtists, &track.Artists)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    err = json.Unmarshal(albumArtists, &track.Album.Artists)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return track, nil
+}
+
+// Returns a 404 if the album is not in the catalog yet
+func(c *CatalogDAO) GetAlbum(executor db.QueryExecutor, albumID string) (*responses.Album, error) {
+
+    query := fmt.Sprintf(`SELECT albums.albumid, albums.name, albums.albumarturi, albums.releasedate, albums.releasedateprecision, albums.fetchedat, %s
+              FROM albums
+              WHERE albums.albumid = $1`, albumArtistsColumn)
+
+    album := &responses.Album{}
+    albumArtURI := sql.NullString{}
+    releaseDate := sql.NullString{}
+    releaseDatePrecision := sql.NullString{}
+    artists := []byte{}
+
+    err := executor.QueryRow(query, albumID).Scan(
+        &album.AlbumID,
+        &album.Name,
+        &albumArtURI,
+        &releaseDate,
+        &releaseDatePrecision,
+        &album.FetchedAt,
+        &artists,
+    )
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    album.AlbumArtURI = albumArtURI.String
+    album.ReleaseDate = releaseDate.String
+    album.ReleaseDatePrecision = releaseDatePrecision.String
+
+    err = json.Unmarshal(artists, &album.Artists)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return album, nil
+}
+
+// Returns a 404 if the artist is not in the catalog yet
+func(c *CatalogDAO) GetArtist(executor db.QueryExecutor, artistID string) (*responses.Artist, error) {
+
+    query := `SELECT artistid, name, imageuri, fetchedat FROM artists WHERE artistid = $1`
+
+    artist := &responses.Artist{}
+    imageURI := sql.NullString{}
+
+    err := executor.QueryRow(query, artistID).Scan(&artist.ArtistID, &artist.Name, &imageURI, &artist.FetchedAt)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    artist.ImageURI = imageURI.String
+
+    return artist, nil
+}
+
+// Writes the track along with its album and artists, replacing whatever was stored for them before. Must be run
+// inside of a transaction
+func(c *CatalogDAO) UpsertTrack(executor db.QueryExecutor, track *responses.Track) error {
+
+    err := c.UpsertAlbum(executor, &track.Album)
+
+    if err != nil {
+        return err
+    }
+
+    err = c.upsertArtists(executor, track.Artists)
+
+    if err != nil {
+        return err
+    }
+
+    query := `INSERT INTO tracks (trackid, name, albumid, durationms, fetchedat) VALUES ($1, $2, $3, $4, $5)
+             ON CONFLICT (trackid) DO UPDATE SET name = EXCLUDED.name, albumid = EXCLUDED.albumid, durationms = EXCLUDED.durationms, fetchedat = EXCLUDED.fetchedat`
+
+    _, err = executor.Exec(query, track.TrackID, track.Name, track.Album.AlbumID, track.DurationMs, track.FetchedAt)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return c.replaceArtists(executor, "track_artists", "trackid", track.TrackID, track.Artists)
+}
+
+// Writes the album along with its artists, replacing whatever was stored for them before. Must be run inside of a
+// transaction
+func(c *CatalogDAO) UpsertAlbum(executor db.QueryExecutor, album *responses.Album) error {
+
+    err := c.upsertArtists(executor, album.Artists)
+
+    if err != nil {
+        return err
+    }
+
+    query := `INSERT INTO albums (albumid, name, albumarturi, releasedate, releasedateprecision, fetchedat) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+              ON CONFLICT (albumid) DO UPDATE SET name = EXCLUDED.name, albumarturi = EXCLUDED.albumarturi, releasedate = EXCLUDED.releasedate, releasedateprecision = EXCLUDED.releasedateprecision, fetchedat = EXCLUDED.fetchedat`
+
+    _, err = executor.Exec(query, album.AlbumID, album.Name, album.AlbumArtURI, album.ReleaseDate, album.ReleaseDatePrecision, album.FetchedAt)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return c.replaceArtists(executor, "album_artists", "albumid", album.AlbumID, album.Artists)
+}
+
+// For artists fetched on their own, which is the only way their image is known
+func(c *CatalogDAO) UpsertArtist(executor db.QueryExecutor, artist *responses.Artist) error {
+
+    query := `INSERT INTO artists (artistid, name, imageuri, fetchedat) VALUES ($1, $2, NULLIF($3, ''), $4)
+              ON CONFLICT (artistid) DO UPDATE SET name = EXCLUDED.name, imageuri = EXCLUDED.imageuri, fetchedat = EXCLUDED.fetchedat`
+
+    _, err := executor.Exec(query, artist.ArtistID, artist.Name, artist.ImageURI, artist.FetchedAt)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Only subjects that are posted about are kept fresh. The albums of track posts are refreshed along with their tracks
+func(c *CatalogDAO) GetStaleSubjectIDs(executor db.QueryExecutor, subjectType responses.SubjectType, fetchedBefore time.Time, limit int) ([]string, error) {
+
+    table := catalogTables[subjectType]
+
+    query := fmt.Sprintf(`SELECT %[1]s.%[2]s FROM %[1]s
+              WHERE %[1]s.fetchedat < $1 AND EXISTS (SELECT 1 FROM posts WHERE posts.subjecttype = $2 AND posts.songid = %[1]s.%[2]s)
+              ORDER BY %[1]s.fetchedat
+              LIMIT $3`, table[0], table[1])
+
+    rows, err := executor.Query(query, fetchedBefore, subjectType, limit)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    subjectIDs := []string{}
+
+    for rows.Next() {
+        subjectID := ""
+        err := rows.Scan(&subjectID)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        subjectIDs = append(subjectIDs, subjectID)
+    }
+
+    return subjectIDs, nil
+}
+
+// Used for subjects that Spotify no longer has, so they are not asked for again until they are next due
+func(c *CatalogDAO) MarkSubjectsFetched(executor db.QueryExecutor, subjectType responses.SubjectType, subjectIDs []string, fetchedAt time.Time) error {
+
+    table := catalogTables[subjectType]
+
+    query := fmt.Sprintf(`UPDATE %s SET fetchedat = $2 WHERE %s = ANY($1)`, table[0], table[1])
+
+    _, err := executor.Exec(query, pq.Array(subjectIDs), fetchedAt)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Stores the artists of a track or album. Only their names are known, so artists that are new to the catalog are
+// marked as fetched at the epoch, and are fetched on their own the first time they are posted about
+func(c *CatalogDAO) upsertArtists(executor db.QueryExecutor, artists []responses.Artist) error {
+
+    query := `INSERT INTO artists (artistid, name, fetchedat) VALUES ($1, $2, 'epoch')
+              ON CONFLICT (artistid) DO UPDATE SET name = EXCLUDED.name`
+
+    for _, artist := range artists {
+
+        _, err := executor.Exec(query, artist.ArtistID, artist.Name)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+    }
+
+    return nil
+}
+
+// The artists must already be stored
+func(c *CatalogDAO) replaceArtists(executor db.QueryExecutor, joinTable string, keyColumn string, key string, artists []responses.Artist) error {
+
+    query := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, joinTable, keyColumn)
+
+    _, err := executor.Exec(query, key)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    query = fmt.Sprintf(`INSERT INTO %s (%s, artistid, position) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, joinTable, keyColumn)
+
+    for position, artist := range artists {
+
+        _, err := executor.Exec(query, key, artist.ArtistID, position)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+    }
+
+    return nil
+}
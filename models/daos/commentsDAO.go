@@ -1,9 +1,12 @@
 package daos
 
 import (
+	"database/sql"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
@@ -13,25 +16,44 @@ import (
 
 type CommentsDAO struct { }
 
+var commentVotersKeyset = cursor.Keyset{SortColumn: "comment_votes.voterspotifyid", SortType: "text", IDColumn: "comment_votes.voterspotifyid", IDType: "text"}
+var commentRepliesKeyset = cursor.Keyset{SortColumn: "comments.createdat", SortType: "timestamptz", IDColumn: "comments.commentid", IDType: "int"}
+
+// Columns selected by every query that reads comments, in the order scanComment expects them. Queries join the
+// commentor as users, and the viewers vote as viewer_votes
+const commentColumns = `comments.commentid, comments.commentorspotifyid, users.username, comments.postid, comments.posterspotifyid, comments.subjecttype, comments.songid, comments.commenttext, comments.createdat, comments.updatedat, comments.likes, comments.dislikes, viewer_votes.liked, comments.parentcommentid, comments.depth, comments.replycount, comments.deleted`
+
+type rowScanner interface {
+    Scan(dest ...any) error
+}
+
 type ICommentsDAO interface {
-    CreateComment(executor db.QueryExecutor, commentorID string, posterID string, songID string, commentText string) (*responses.Comment, error)
+    CreateComment(executor db.QueryExecutor, commentorID string, postID int64, commentText string, parentCommentID *int, depth int) (*responses.Comment, error)
     DeleteComment(executor db.QueryExecutor, commentID string) error
-    GetCommentProperties(executor db.QueryExecutor, commentID string) (*responses.Comment, error) 
-    GetCommentVotes(executor db.QueryExecutor, commentID string) ([]responses.UserIdentifer, []responses.UserIdentifer, error)
-    LikeComment(executor db.QueryExecutor, commentID string, spotifyID string) error 
-    DislikeComment(executor db.QueryExecutor, commentID string, spotifyID string) error 
+    GetCommentProperties(executor db.QueryExecutor, commentID string, viewerSpotifyID string) (*responses.Comment, error) 
+    GetCommentReplies(executor db.QueryExecutor, commentID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.Comment, error)
+    GetCommentVoters(executor db.QueryExecutor, commentID string, vote *responses.Vote, page *cursor.PageRequest) ([]responses.Voter, error)
+    LikeComment(executor db.QueryExecutor, commentID string, spotifyID string) (bool, error) 
+    DislikeComment(executor db.QueryExecutor, commentID string, spotifyID string) (bool, error) 
     RemoveCommentVote(executor db.QueryExecutor, commentID string, spotifyID string) error 
     UpdateComment(executor db.QueryExecutor, commentID string, updateCommentDTO *requests.UpdateCommentDTO) (*responses.Comment, error) 
 }
 
-func(c *CommentsDAO) CreateComment(executor db.QueryExecutor, commentorID string, posterID string, songID string, commentText string) (*responses.Comment, error){
+// Replies also bump the reply count of their parent, in the same statement. The comment keeps the poster and subject of
+// the post, and a post that does not exist is reported as not found
+func(c *CommentsDAO) CreateComment(executor db.QueryExecutor, commentorID string, postID int64, commentText string, parentCommentID *int, depth int) (*responses.Comment, error){
 
-    query := `INSERT INTO comments (commentorspotifyid, posterspotifyid, songid, commenttext, createdAt, updatedAt) values ($1, $2, $3, $4, $5, $5) RETURNING commentid, commentorspotifyid, posterspotifyid, songid, commenttext`
+    query := `WITH parent AS (
+                  UPDATE comments SET replycount = replycount + 1 WHERE commentid = $5 AND EXISTS (SELECT 1 FROM posts WHERE postid = $2)
+              )
+              INSERT INTO comments (commentorspotifyid, postid, posterspotifyid, subjecttype, songid, commenttext, createdAt, updatedAt, parentcommentid, depth) 
+              SELECT $1, postid, posterspotifyid, subjecttype, songid, $3, $4, $4, $5, $6 FROM posts WHERE postid = $2
+              RETURNING commentid, commentorspotifyid, postid, posterspotifyid, subjecttype, songid, commenttext, createdat, updatedat, parentcommentid, depth`
 
-    res := executor.QueryRow(query, commentorID, posterID, songID, commentText, time.Now().UTC())
+    res := executor.QueryRow(query, commentorID, postID, commentText, time.Now().UTC(), parentCommentID, depth)
 
     commentResp := &responses.Comment{}
-    err := res.Scan(&commentResp.CommentID, &commentResp.CommentorID, &commentResp.PostSpotifyID, &commentResp.SongID, &commentResp.CommentText)
+    err := res.Scan(&commentResp.CommentID, &commentResp.CommentorID, &commentResp.PostID, &commentResp.PostSpotifyID, &commentResp.SubjectType, &commentResp.SongID, &commentResp.CommentText, &commentResp.CreatedAt, &commentResp.UpdatedAt, &commentResp.ParentCommentID, &commentResp.Depth)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
@@ -42,8 +64,10 @@ func(c *CommentsDAO) CreateComment(executor db.QueryExecutor, commentorID string
 
 }
 
+// A comment that has replies is tombstoned, so the thread under it stays intact. A comment without replies is removed,
+// and removing the last reply of a tombstoned parent removes the parent as well. Must be run inside of a transaction
 func(c *CommentsDAO) DeleteComment(executor db.QueryExecutor, commentID string) error {
-    query := `DELETE FROM comments WHERE commentid = $1`
+    query := `UPDATE comments SET deleted = true, commenttext = '' WHERE commentid = $1 AND replycount > 0 AND NOT deleted`
 
     resp, err := executor.Exec(query, commentID)
 
@@ -57,127 +81,197 @@ func(c *CommentsDAO) DeleteComment(executor db.QueryExecutor, commentID string)
         return customerrors.WrapBasicError(err)
     }
 
-    if rows < 1 {
+    if rows > 0 {
+        return nil
+    }
+
+    query = `DELETE FROM comments WHERE commentid = $1 AND replycount = 0 RETURNING parentcommentid`
+
+    parentCommentID := sql.NullInt64{}
+    err = executor.QueryRow(query, commentID).Scan(&parentCommentID)
+
+    if err == sql.ErrNoRows {
         return &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "resource not found"}
     }
 
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    for parentCommentID.Valid {
+
+        query = `UPDATE comments SET replycount = replycount - 1 WHERE commentid = $1 RETURNING deleted, replycount, parentcommentid`
+
+        deleted := false
+        replyCount := 0
+        grandparentCommentID := sql.NullInt64{}
+
+        err = executor.QueryRow(query, parentCommentID.Int64).Scan(&deleted, &replyCount, &grandparentCommentID)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        if !deleted || replyCount > 0 {
+            return nil
+        }
+
+        _, err = executor.Exec(`DELETE FROM comments WHERE commentid = $1`, parentCommentID.Int64)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        parentCommentID = grandparentCommentID
+    }
+
     return nil
 
 }
 
-func(c *CommentsDAO) GetCommentProperties(executor db.QueryExecutor, commentID string) (*responses.Comment, error) {
+func(c *CommentsDAO) GetCommentProperties(executor db.QueryExecutor, commentID string, viewerSpotifyID string) (*responses.Comment, error) {
 
-    commentResponse := &responses.Comment{}
-
-    query := `SELECT comments.commentid, comments.commentorspotifyid, comments.posterspotifyid, comments.songid, comments.commenttext, comments.createdat, comments.updatedat, users.username 
+    query := fmt.Sprintf(`SELECT %s 
               FROM comments INNER JOIN users ON commentorspotifyid = spotifyid 
-              WHERE commentid = $1`
+              LEFT JOIN comment_votes AS viewer_votes ON viewer_votes.commentid = comments.commentid AND viewer_votes.voterspotifyid = $2
+              WHERE comments.commentid = $1`, commentColumns)
 
-    res := executor.QueryRow(query, commentID)
+    res := executor.QueryRow(query, commentID, viewerSpotifyID)
 
-    err := res.Scan(&commentResponse.CommentID, 
-                &commentResponse.CommentorID, 
-                &commentResponse.PostSpotifyID, 
-                &commentResponse.SongID, 
-                &commentResponse.CommentText,
-                &commentResponse.CreatedAt,
-                &commentResponse.UpdatedAt,
-                &commentResponse.CommentorUsername)
-                
+    commentResponse, err := scanComment(res)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
     }
 
-
     return commentResponse, nil
 
 }
 
-func(cs *CommentsDAO) GetCommentVotes(executor db.QueryExecutor, commentID string) ([]responses.UserIdentifer, []responses.UserIdentifer, error) {
-    query := `SELECT comment_votes.voterspotifyid, users.username, comment_votes.liked
-              FROM comment_votes INNER JOIN users ON comment_votes.voterspotifyid = users.spotifyid
-              WHERE commentid = $1`
+func(c *CommentsDAO) GetCommentReplies(executor db.QueryExecutor, commentID string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.Comment, error) {
+
+    condition, orderBy, args := page.Clause(commentRepliesKeyset, 3)
 
-    row, err := executor.Query(query, commentID)
+    query := fmt.Sprintf(`SELECT %s 
+              FROM comments INNER JOIN users ON commentorspotifyid = spotifyid 
+              LEFT JOIN comment_votes AS viewer_votes ON viewer_votes.commentid = comments.commentid AND viewer_votes.voterspotifyid = $2
+              WHERE comments.parentcommentid = $1 %s 
+              %s 
+              LIMIT %d`, commentColumns, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{commentID, viewerSpotifyID}, args...)...)
 
     if err != nil {
-        return nil, nil, customerrors.WrapBasicError(err)
+        return nil, customerrors.WrapBasicError(err)
     }
 
-    likes := []responses.UserIdentifer{}
-    dislikes := []responses.UserIdentifer{}
+    defer rows.Close()
 
-    for row.Next() {
-       user := responses.UserIdentifer{}
-       liked := true
-       err := row.Scan(&user.SpotifyID, &user.Username, &liked)
-       if err != nil {
-           return nil, nil, customerrors.WrapBasicError(err)
-       }
-       if liked {
-           likes = append(likes, user)
-       } else {
-           dislikes = append(dislikes, user)
-       }
-    }
+    replies := []responses.Comment{}
 
-    return likes, dislikes, nil
+    for rows.Next() {
+        reply, err := scanComment(rows)
 
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+
+        replies = append(replies, *reply)
+    }
+
+    return replies, nil
 }
 
-func(c *CommentsDAO) LikeComment(executor db.QueryExecutor, commentID string, spotifyID string) error {
-    
-    query := `INSERT INTO comment_votes (commentid, liked, voterspotifyid) values ($1, $2, $3) ON CONFLICT (commentid, voterspotifyid) DO UPDATE set liked = $2`
+func(cs *CommentsDAO) GetCommentVoters(executor db.QueryExecutor, commentID string, vote *responses.Vote, page *cursor.PageRequest) ([]responses.Voter, error) {
 
-    res, err := executor.Exec(query, commentID, true, spotifyID)
+    liked := sql.NullBool{}
 
-    if err != nil {
-        return customerrors.WrapBasicError(err)
+    if vote != nil {
+        liked = sql.NullBool{Bool: *vote == responses.LIKE, Valid: true}
     }
 
-    rows, err := res.RowsAffected()
+    condition, orderBy, args := page.Clause(commentVotersKeyset, 3)
+
+    query := fmt.Sprintf(`SELECT comment_votes.voterspotifyid, users.username, comment_votes.liked
+              FROM comment_votes INNER JOIN users ON comment_votes.voterspotifyid = users.spotifyid
+              WHERE comment_votes.commentid = $1 AND ($2::boolean IS NULL OR comment_votes.liked = $2) %s 
+              %s 
+              LIMIT %d`, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{commentID, liked}, args...)...)
 
     if err != nil {
-        return customerrors.WrapBasicError(err)
+        return nil, customerrors.WrapBasicError(err)
     }
 
-    if rows < 1 {
-        return customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "comment not found"}
+    defer rows.Close()
+
+    voters := []responses.Voter{}
+
+    for rows.Next() {
+       voter := responses.Voter{}
+       liked := true
+       err := rows.Scan(&voter.SpotifyID, &voter.Username, &liked)
+       if err != nil {
+           return nil, customerrors.WrapBasicError(err)
+       }
+       voter.Vote = responses.VoteFromLiked(liked)
+       voters = append(voters, voter)
     }
 
+    return voters, nil
 
-    return nil
+}
+
+func(c *CommentsDAO) LikeComment(executor db.QueryExecutor, commentID string, spotifyID string) (bool, error) {
+
+    return c.voteComment(executor, commentID, spotifyID, true)
+
+}
+
+func(c *CommentsDAO) DislikeComment(executor db.QueryExecutor, commentID string, spotifyID string) (bool, error) {
+
+    return c.voteComment(executor, commentID, spotifyID, false)
 
 }
 
-func(c *CommentsDAO) DislikeComment(executor db.QueryExecutor, commentID string, spotifyID string) error {
+// Upserts a vote and adjusts the comments counters in a single statement, the same way as PostsDAO.votePost. Returns
+// whether the vote changed
+func(c *CommentsDAO) voteComment(executor db.QueryExecutor, commentID string, spotifyID string, liked bool) (bool, error) {
 
-    query := `INSERT INTO comment_votes (commentid, liked, voterspotifyid) values ($1, $2, $3) ON CONFLICT (commentid, voterspotifyid) DO UPDATE SET liked = $2`
+    query := `WITH vote AS (
+                  INSERT INTO comment_votes (commentid, liked, voterspotifyid) VALUES ($1, $2, $3) 
+                  ON CONFLICT (commentid, voterspotifyid) DO UPDATE SET liked = $2
+                  WHERE comment_votes.liked IS DISTINCT FROM EXCLUDED.liked
+                  RETURNING (xmax = 0) AS inserted
+              )
+              UPDATE comments SET 
+                  likes = likes + CASE WHEN $2 THEN 1 ELSE -(SELECT count(*) FROM vote WHERE NOT inserted) END,
+                  dislikes = dislikes + CASE WHEN $2 THEN -(SELECT count(*) FROM vote WHERE NOT inserted) ELSE 1 END
+              WHERE commentid = $1 AND EXISTS (SELECT 1 FROM vote)`
 
-    res, err := executor.Exec(query, commentID, false, spotifyID)
+    res, err := executor.Exec(query, commentID, liked, spotifyID)
 
     if err != nil {
-        return customerrors.WrapBasicError(err)
+        return false, customerrors.WrapBasicError(err)
     }
 
     rows, err := res.RowsAffected()
 
     if err != nil {
-        return customerrors.WrapBasicError(err)
-    }
-
-    if rows < 1 {
-        return customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "comment not found"}
+        return false, customerrors.WrapBasicError(err)
     }
 
-
-    return nil
+    return rows > 0, nil
 
 }
 
 func(c *CommentsDAO) RemoveCommentVote(executor db.QueryExecutor, commentID string, spotifyID string) error {
-    query := `DELETE FROM comment_votes WHERE commentid = $1 AND voterspotifyid = $2`
+    query := `WITH vote AS (
+                  DELETE FROM comment_votes WHERE commentid = $1 AND voterspotifyid = $2 RETURNING liked
+              )
+              UPDATE comments SET likes = likes - (SELECT count(*) FROM vote WHERE liked), dislikes = dislikes - (SELECT count(*) FROM vote WHERE NOT liked)
+              WHERE commentid = $1 AND EXISTS (SELECT 1 FROM vote)`
 
     res, err := executor.Exec(query, commentID, spotifyID)
 
@@ -211,7 +305,7 @@ func(c *CommentsDAO) UpdateComment(executor db.QueryExecutor, commentID string,
     conditionals := make(map[string]any)
     conditionals["commentid"] = commentID
 
-    returning := []string{"commentid", "commentorspotifyid", "posterspotifyid", "songid", "commenttext", "createdat", "updatedat"}
+    returning := []string{"commentid", "commentorspotifyid", "postid", "posterspotifyid", "subjecttype", "songid", "commenttext", "createdat", "updatedat", "likes", "dislikes"}
 
     query, vals := db.PatchQueryBuilder("comments", updateCommentMap, conditionals, returning)
 
@@ -220,7 +314,7 @@ func(c *CommentsDAO) UpdateComment(executor db.QueryExecutor, commentID string,
 
     row := executor.QueryRow(query, vals...)
 
-    err := row.Scan(&comment.CommentID, &comment.CommentorID, &comment.PostSpotifyID, &comment.SongID, &comment.CommentText, &comment.CreatedAt, &comment.UpdatedAt)
+    err := row.Scan(&comment.CommentID, &comment.CommentorID, &comment.PostID, &comment.PostSpotifyID, &comment.SubjectType, &comment.SongID, &comment.CommentText, &comment.CreatedAt, &comment.UpdatedAt, &comment.Likes, &comment.Dislikes)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
@@ -229,3 +323,44 @@ func(c *CommentsDAO) UpdateComment(executor db.QueryExecutor, commentID string,
     return comment, nil
 
 }
+
+// Tombstoned comments keep their place in the thread, but not their text or author. Any extra destinations are scanned
+// from the columns following commentColumns
+func scanComment(row rowScanner, extra ...any) (*responses.Comment, error) {
+
+    comment := &responses.Comment{}
+    liked := sql.NullBool{}
+
+    dest := []any{&comment.CommentID,
+        &comment.CommentorID,
+        &comment.CommentorUsername,
+        &comment.PostID,
+        &comment.PostSpotifyID,
+        &comment.SubjectType,
+        &comment.SongID,
+        &comment.CommentText,
+        &comment.CreatedAt,
+        &comment.UpdatedAt,
+        &comment.Likes,
+        &comment.Dislikes,
+        &liked,
+        &comment.ParentCommentID,
+        &comment.Depth,
+        &comment.ReplyCount,
+        &comment.Deleted}
+
+    err := row.Scan(append(dest, extra...)...)
+
+    if err != nil {
+        return nil, err
+    }
+
+    comment.CurrentUserVote = voteFromNullBool(liked)
+
+    if comment.Deleted {
+        comment.CommentorID = ""
+        comment.CommentorUsername = ""
+    }
+
+    return comment, nil
+}
@@ -0,0 +1,83 @@
+package daos
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+type DigestDAO struct { }
+
+type IDigestDAO interface {
+    QueueDigestEvent(executor db.QueryExecutor, recipientSpotifyID string, delivery responses.NotificationDelivery, eventType string, event []byte) error
+    ClaimDigestRecipient(executor db.QueryExecutor, delivery responses.NotificationDelivery, before time.Time) (string, error)
+    TakeDigestEvents(executor db.QueryExecutor, recipientSpotifyID string, delivery responses.NotificationDelivery, before time.Time) ([]responses.PendingDigestEvent, error)
+}
+
+func(d *DigestDAO) QueueDigestEvent(executor db.QueryExecutor, recipientSpotifyID string, delivery responses.NotificationDelivery, eventType string, event []byte) error {
+
+    query := `INSERT INTO pending_digest_events (recipientspotifyid, delivery, eventtype, event, createdat) VALUES ($1, $2, $3, $4, $5)`
+
+    _, err := executor.Exec(query, recipientSpotifyID, delivery, eventType, event, time.Now().UTC())
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Finds a user with events waiting for a digest, skipping users another instance is building a digest for. Returns an
+// empty string once there are none left. Must be run inside of a transaction
+func(d *DigestDAO) ClaimDigestRecipient(executor db.QueryExecutor, delivery responses.NotificationDelivery, before time.Time) (string, error) {
+
+    query := `SELECT recipientspotifyid FROM pending_digest_events WHERE delivery = $1 AND createdat < $2 LIMIT 1 FOR UPDATE SKIP LOCKED`
+
+    recipientSpotifyID := ""
+    err := executor.QueryRow(query, delivery, before).Scan(&recipientSpotifyID)
+
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", nil
+    }
+
+    if err != nil {
+        return "", customerrors.WrapBasicError(err)
+    }
+
+    return recipientSpotifyID, nil
+}
+
+// Removes and returns the users events waiting for a digest, oldest first
+func(d *DigestDAO) TakeDigestEvents(executor db.QueryExecutor, recipientSpotifyID string, delivery responses.NotificationDelivery, before time.Time) ([]responses.PendingDigestEvent, error) {
+
+    query := `WITH taken AS (
+                  DELETE FROM pending_digest_events WHERE recipientspotifyid = $1 AND delivery = $2 AND createdat < $3
+                  RETURNING id, recipientspotifyid, eventtype, event, createdat
+              )
+              SELECT id, recipientspotifyid, eventtype, event, createdat FROM taken ORDER BY createdat, id`
+
+    rows, err := executor.Query(query, recipientSpotifyID, delivery, before)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    pendingEvents := []responses.PendingDigestEvent{}
+
+    for rows.Next() {
+        pendingEvent := responses.PendingDigestEvent{}
+        err := rows.Scan(&pendingEvent.ID, &pendingEvent.RecipientSpotifyID, &pendingEvent.EventType, &pendingEvent.Event, &pendingEvent.CreatedAt)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        pendingEvents = append(pendingEvents, pendingEvent)
+    }
+
+    return pendingEvents, nil
+}
@@ -0,0 +1,227 @@
+package daos
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/requests"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/lib/pq"
+)
+
+type DraftsDAO struct { }
+
+var draftsKeyset = cursor.Keyset{SortColumn: "post_drafts.listenedat", SortType: "timestamptz", IDColumn: "post_drafts.draftid", IDType: "bigint", Descending: true}
+
+const draftColumns = `post_drafts.draftid, post_drafts.trackid, tracks.name, albums.albumid, albums.name, albums.albumarturi, post_drafts.listenedat,
+                      EXISTS (SELECT 1 FROM posts WHERE posts.posterspotifyid = post_drafts.spotifyid AND posts.subjecttype = 'TRACK' AND posts.songid = post_drafts.trackid),
+                      post_drafts.createdat`
+
+// A listen of the track in listens is already logged when the user has a post or a draft about the track from the
+// same listen or a later one. Expects the user as $1
+const listenNotLogged = `NOT EXISTS (SELECT 1 FROM posts WHERE posts.posterspotifyid = $1 AND posts.subjecttype = 'TRACK' AND posts.songid = listens.trackid AND posts.listenedat >= listens.listenedat)
+                         AND NOT EXISTS (SELECT 1 FROM post_drafts WHERE post_drafts.spotifyid = $1 AND post_drafts.trackid = listens.trackid AND post_drafts.listenedat >= listens.listenedat)`
+
+type IDraftsDAO interface {
+    GetListenCandidates(executor db.QueryExecutor, spotifyID string, listens []requests.ListenDTO) ([]responses.ListenCandidate, error)
+    CreateDrafts(executor db.QueryExecutor, spotifyID string, listens []requests.ListenDTO) ([]responses.PostDraft, error)
+    GetDraft(executor db.QueryExecutor, spotifyID string, draftID int64) (*responses.PostDraft, error)
+    GetDrafts(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.PostDraft, error)
+    DeleteDraft(executor db.QueryExecutor, spotifyID string, draftID int64) error
+}
+
+// Leaves out the listens that are already logged, and listens of tracks that are not in the catalog. Newest first
+func(d *DraftsDAO) GetListenCandidates(executor db.QueryExecutor, spotifyID string, listens []requests.ListenDTO) ([]responses.ListenCandidate, error) {
+
+    trackIDs, listenedAts := listenArrays(listens)
+
+    query := fmt.Sprintf(`SELECT listens.trackid, tracks.name, albums.albumid, albums.name, albums.albumarturi, listens.listenedat,
+                  EXISTS (SELECT 1 FROM posts WHERE posts.posterspotifyid = $1 AND posts.subjecttype = 'TRACK' AND posts.songid = listens.trackid)
+              FROM unnest($2::text[], $3::timestamptz[]) AS listens(trackid, listenedat)
+              INNER JOIN tracks ON tracks.trackid = listens.trackid
+              INNER JOIN albums ON albums.albumid = tracks.albumid
+              WHERE %s
+              ORDER BY listens.listenedat DESC`, listenNotLogged)
+
+    rows, err := executor.Query(query, spotifyID, trackIDs, listenedAts)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    candidates := []responses.ListenCandidate{}
+
+    for rows.Next() {
+        candidate := responses.ListenCandidate{}
+        albumArtURI := sql.NullString{}
+
+        err := rows.Scan(&candidate.TrackID, &candidate.SongName, &candidate.AlbumID, &candidate.AlbumName, &albumArtURI, &candidate.ListenedAt, &candidate.Relisten)
+
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+
+        candidate.AlbumArtURI = albumArtURI.String
+        candidates = append(candidates, candidate)
+    }
+
+    return candidates, nil
+}
+
+// Listens that are already logged are skipped, so only the drafts that were created are returned. The tracks must
+// already be in the catalog
+func(d *DraftsDAO) CreateDrafts(executor db.QueryExecutor, spotifyID string, listens []requests.ListenDTO) ([]responses.PostDraft, error) {
+
+    trackIDs, listenedAts := listenArrays(listens)
+
+    query := fmt.Sprintf(`WITH created AS (
+                  INSERT INTO post_drafts (spotifyid, trackid, listenedat, createdat)
+                  SELECT $1, listens.trackid, listens.listenedat, $4
+                  FROM unnest($2::text[], $3::timestamptz[]) AS listens(trackid, listenedat)
+                  WHERE %s
+                  ON CONFLICT DO NOTHING
+                  RETURNING draftid, spotifyid, trackid, listenedat, createdat
+              )
+              SELECT %s
+              FROM created AS post_drafts
+              INNER JOIN tracks ON tracks.trackid = post_drafts.trackid
+              INNER JOIN albums ON albums.albumid = tracks.albumid
+              ORDER BY post_drafts.listenedat DESC`, listenNotLogged, draftColumns)
+
+    rows, err := executor.Query(query, spotifyID, trackIDs, listenedAts, time.Now().UTC())
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    return scanDrafts(rows)
+}
+
+func(d *DraftsDAO) GetDraft(executor db.QueryExecutor, spotifyID string, draftID int64) (*responses.PostDraft, error) {
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM post_drafts
+              INNER JOIN tracks ON tracks.trackid = post_drafts.trackid
+              INNER JOIN albums ON albums.albumid = tracks.albumid
+              WHERE post_drafts.spotifyid = $1 AND post_drafts.draftid = $2`, draftColumns)
+
+    rows, err := executor.Query(query, spotifyID, draftID)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    drafts, err := scanDrafts(rows)
+
+    if err != nil {
+        return nil, err
+    }
+
+    if len(drafts) < 1 {
+        return nil, customerrors.WrapBasicError(sql.ErrNoRows)
+    }
+
+    return &drafts[0], nil
+}
+
+// Most recent listen first
+func(d *DraftsDAO) GetDrafts(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.PostDraft, error) {
+
+    condition, orderBy, args := page.Clause(draftsKeyset, 2)
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM post_drafts
+              INNER JOIN tracks ON tracks.trackid = post_drafts.trackid
+              INNER JOIN albums ON albums.albumid = tracks.albumid
+              WHERE post_drafts.spotifyid = $1 %s
+              %s
+              LIMIT %d`, draftColumns, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{spotifyID}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    return scanDrafts(rows)
+}
+
+func(d *DraftsDAO) DeleteDraft(executor db.QueryExecutor, spotifyID string, draftID int64) error {
+
+    query := `DELETE FROM post_drafts WHERE spotifyid = $1 AND draftid = $2`
+
+    res, err := executor.Exec(query, spotifyID, draftID)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    rows, err := res.RowsAffected()
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    if rows < 1 {
+        return customerrors.WrapBasicError(sql.ErrNoRows)
+    }
+
+    return nil
+}
+
+func scanDrafts(rows *sql.Rows) ([]responses.PostDraft, error) {
+
+    drafts := []responses.PostDraft{}
+
+    for rows.Next() {
+        draft := responses.PostDraft{}
+        albumArtURI := sql.NullString{}
+
+        err := rows.Scan(
+            &draft.DraftID,
+            &draft.TrackID,
+            &draft.SongName,
+            &draft.AlbumID,
+            &draft.AlbumName,
+            &albumArtURI,
+            &draft.ListenedAt,
+            &draft.Relisten,
+            &draft.CreatedAt,
+        )
+
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+
+        draft.AlbumArtURI = albumArtURI.String
+        drafts = append(drafts, draft)
+    }
+
+    return drafts, nil
+}
+
+// Listens are passed to Postgres as two arrays that are unnested back into rows
+func listenArrays(listens []requests.ListenDTO) (any, any) {
+
+    trackIDs := []string{}
+    listenedAts := []string{}
+
+    for _, listen := range listens {
+        trackIDs = append(trackIDs, listen.TrackID)
+        listenedAts = append(listenedAts, listen.ListenedAt.UTC().Format(time.RFC3339Nano))
+    }
+
+    return pq.Array(trackIDs), pq.Array(listenedAts)
+}
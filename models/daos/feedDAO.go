@@ -0,0 +1,121 @@
+package daos
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// how many of a users most recent posts are copied into a new followers feed
+const feedBackfillLimit = 100
+
+type FeedDAO struct { }
+
+var feedKeyset = cursor.Keyset{SortColumn: "feed_items.createdat", SortType: "timestamptz", IDColumn: "feed_items.postid", IDType: "bigint", Descending: true}
+
+type IFeedDAO interface {
+    FanOutPost(executor db.QueryExecutor, posterSpotifyID string, postID int64, createdAt time.Time) ([]string, error)
+    BackfillFollow(executor db.QueryExecutor, followerSpotifyID string, followedSpotifyID string) error
+    PruneUnfollow(executor db.QueryExecutor, followerSpotifyID string, unfollowedSpotifyID string) error
+    GetFeed(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error)
+}
+
+// Returns the followers the post was added to the feeds of
+func(f *FeedDAO) FanOutPost(executor db.QueryExecutor, posterSpotifyID string, postID int64, createdAt time.Time) ([]string, error) {
+
+    query := `INSERT INTO feed_items (ownerspotifyid, posterspotifyid, postid, createdat) 
+              SELECT follower, $1, $2, $3 FROM followers WHERE userfollowed = $1
+              ON CONFLICT DO NOTHING
+              RETURNING ownerspotifyid`
+
+    rows, err := executor.Query(query, posterSpotifyID, postID, createdAt)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    followers := []string{}
+
+    for rows.Next() {
+        follower := ""
+        err := rows.Scan(&follower)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        followers = append(followers, follower)
+    }
+
+    return followers, nil
+}
+
+func(f *FeedDAO) BackfillFollow(executor db.QueryExecutor, followerSpotifyID string, followedSpotifyID string) error {
+
+    query := `INSERT INTO feed_items (ownerspotifyid, posterspotifyid, postid, createdat) 
+              SELECT $1, posterspotifyid, postid, createdat FROM posts 
+              WHERE posterspotifyid = $2 
+              ORDER BY createdat DESC 
+              LIMIT $3
+              ON CONFLICT DO NOTHING`
+
+    _, err := executor.Exec(query, followerSpotifyID, followedSpotifyID, feedBackfillLimit)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(f *FeedDAO) PruneUnfollow(executor db.QueryExecutor, followerSpotifyID string, unfollowedSpotifyID string) error {
+
+    query := `DELETE FROM feed_items WHERE ownerspotifyid = $1 AND posterspotifyid = $2`
+
+    _, err := executor.Exec(query, followerSpotifyID, unfollowedSpotifyID)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(f *FeedDAO) GetFeed(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.PostPreview, error) {
+
+    condition, orderBy, args := page.Clause(feedKeyset, 2)
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM feed_items 
+              INNER JOIN posts ON posts.postid = feed_items.postid
+              %s
+              INNER JOIN users ON users.spotifyid = posts.posterspotifyid
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = feed_items.ownerspotifyid
+              WHERE feed_items.ownerspotifyid = $1 %s 
+              %s 
+              LIMIT %d`, postColumns, postCatalogJoins, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{spotifyID}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    postPreviews := []responses.PostPreview{}
+
+    for rows.Next() {
+        post, err := scanPostPreview(rows)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        postPreviews = append(postPreviews, *post)
+    }
+
+    return postPreviews, nil
+}
@@ -0,0 +1,228 @@
+package daos
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+type NotificationsDAO struct { }
+
+var notificationsKeyset = cursor.Keyset{SortColumn: "notifications.updatedat", SortType: "timestamptz", IDColumn: "notifications.notificationid", IDType: "bigint", Descending: true}
+
+type INotificationsDAO interface {
+    Notify(executor db.QueryExecutor, recipientSpotifyID string, notificationType responses.NotificationType, actorSpotifyID string, postID *int64, commentID *int) (int64, error)
+    GetNotifications(executor db.QueryExecutor, recipientSpotifyID string, page *cursor.PageRequest) ([]responses.Notification, error)
+    GetUnreadNotificationCount(executor db.QueryExecutor, recipientSpotifyID string) (int, error)
+    MarkNotificationRead(executor db.QueryExecutor, recipientSpotifyID string, notificationID int64) error
+    MarkAllNotificationsRead(executor db.QueryExecutor, recipientSpotifyID string) error
+}
+
+// Adds the actor to the recipient's unread notification for the subject, creating it if there is not one. An actor
+// who repeats an action, e.g. by liking a post, removing the like and liking it again, is only counted once. Leave
+// postID and commentID nil for notifications that are not about a post. The poster and subject are copied from the
+// post. Returns the ID of the notification, or 0 if the actor was already counted in it. Must be run inside of a
+// transaction
+func(n *NotificationsDAO) Notify(executor db.QueryExecutor, recipientSpotifyID string, notificationType responses.NotificationType, actorSpotifyID string, postID *int64, commentID *int) (int64, error) {
+
+    subjectKey := ""
+
+    if postID != nil {
+        subjectKey = fmt.Sprint(*postID)
+    }
+
+    if commentID != nil {
+        subjectKey = fmt.Sprintf("%s:%d", subjectKey, *commentID)
+    }
+
+    now := time.Now().UTC()
+
+    query := `INSERT INTO notifications (recipientspotifyid, notificationtype, subjectkey, postid, posterspotifyid, subjecttype, songid, commentid, createdat, updatedat)
+              VALUES ($1, $2, $3, $4, (SELECT posterspotifyid FROM posts WHERE postid = $4), (SELECT subjecttype FROM posts WHERE postid = $4), (SELECT songid FROM posts WHERE postid = $4), $5, $6, $6)
+              ON CONFLICT (recipientspotifyid, notificationtype, subjectkey) WHERE NOT read
+              DO UPDATE SET subjectkey = EXCLUDED.subjectkey
+              RETURNING notificationid`
+
+    var notificationID int64
+    err := executor.QueryRow(query, recipientSpotifyID, notificationType, subjectKey, postID, commentID, now).Scan(&notificationID)
+
+    if err != nil {
+        return 0, customerrors.WrapBasicError(err)
+    }
+
+    query = `INSERT INTO notification_actors (notificationid, actorspotifyid) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+    res, err := executor.Exec(query, notificationID, actorSpotifyID)
+
+    if err != nil {
+        return 0, customerrors.WrapBasicError(err)
+    }
+
+    rows, err := res.RowsAffected()
+
+    if err != nil {
+        return 0, customerrors.WrapBasicError(err)
+    }
+
+    if rows < 1 {
+        return 0, nil
+    }
+
+    query = `UPDATE notifications SET actorcount = actorcount + 1, latestactorspotifyid = $2, updatedat = $3 WHERE notificationid = $1`
+
+    _, err = executor.Exec(query, notificationID, actorSpotifyID, now)
+
+    if err != nil {
+        return 0, customerrors.WrapBasicError(err)
+    }
+
+    return notificationID, nil
+}
+
+func(n *NotificationsDAO) GetNotifications(executor db.QueryExecutor, recipientSpotifyID string, page *cursor.PageRequest) ([]responses.Notification, error) {
+
+    condition, orderBy, args := page.Clause(notificationsKeyset, 2)
+
+    query := fmt.Sprintf(`SELECT notifications.notificationid, notifications.notificationtype, notifications.actorcount, notifications.latestactorspotifyid, users.username, notifications.postid, notifications.posterspotifyid, notifications.subjecttype, notifications.songid, coalesce(tracks.name, albums.name, artists.name), tracks.name, notifications.commentid, notifications.read, notifications.createdat, notifications.updatedat
+              FROM notifications
+              LEFT JOIN users ON users.spotifyid = notifications.latestactorspotifyid
+              LEFT JOIN tracks ON notifications.subjecttype = 'TRACK' AND tracks.trackid = notifications.songid
+              LEFT JOIN albums ON notifications.subjecttype = 'ALBUM' AND albums.albumid = notifications.songid
+              LEFT JOIN artists ON notifications.subjecttype = 'ARTIST' AND artists.artistid = notifications.songid
+              WHERE notifications.recipientspotifyid = $1 AND notifications.actorcount > 0 %s
+              %s
+              LIMIT %d`, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(query, append([]any{recipientSpotifyID}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    notifications := []responses.Notification{}
+
+    for rows.Next() {
+        notification := responses.Notification{}
+        latestActorSpotifyID := sql.NullString{}
+        latestActorUsername := sql.NullString{}
+        postID := sql.NullInt64{}
+        posterSpotifyID := sql.NullString{}
+        subjectType := sql.NullString{}
+        songID := sql.NullString{}
+        subjectName := sql.NullString{}
+        songName := sql.NullString{}
+        commentID := sql.NullInt64{}
+
+        err := rows.Scan(
+            &notification.NotificationID,
+            &notification.Type,
+            &notification.ActorCount,
+            &latestActorSpotifyID,
+            &latestActorUsername,
+            &postID,
+            &posterSpotifyID,
+            &subjectType,
+            &songID,
+            &subjectName,
+            &songName,
+            &commentID,
+            &notification.Read,
+            &notification.CreatedAt,
+            &notification.UpdatedAt,
+        )
+
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+
+        notification.LatestActor.SpotifyID = latestActorSpotifyID.String
+        notification.LatestActor.Username = latestActorUsername.String
+        notification.PosterSpotifyID = nullStringPointer(posterSpotifyID)
+        notification.SongID = nullStringPointer(songID)
+        notification.SubjectName = nullStringPointer(subjectName)
+        notification.SongName = nullStringPointer(songName)
+
+        if subjectType.Valid {
+            postSubjectType := responses.SubjectType(subjectType.String)
+            notification.SubjectType = &postSubjectType
+        }
+
+        if postID.Valid {
+            notification.PostID = &postID.Int64
+        }
+
+        if commentID.Valid {
+            id := int(commentID.Int64)
+            notification.CommentID = &id
+        }
+
+        notifications = append(notifications, notification)
+    }
+
+    return notifications, nil
+}
+
+func(n *NotificationsDAO) GetUnreadNotificationCount(executor db.QueryExecutor, recipientSpotifyID string) (int, error) {
+
+    query := `SELECT count(*) FROM notifications WHERE recipientspotifyid = $1 AND NOT read AND actorcount > 0`
+
+    count := 0
+    err := executor.QueryRow(query, recipientSpotifyID).Scan(&count)
+
+    if err != nil {
+        return 0, customerrors.WrapBasicError(err)
+    }
+
+    return count, nil
+}
+
+// Returns a 404 if the notification does not exist or belongs to someone else
+func(n *NotificationsDAO) MarkNotificationRead(executor db.QueryExecutor, recipientSpotifyID string, notificationID int64) error {
+
+    query := `UPDATE notifications SET read = true WHERE notificationid = $1 AND recipientspotifyid = $2`
+
+    res, err := executor.Exec(query, notificationID, recipientSpotifyID)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    rows, err := res.RowsAffected()
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    if rows < 1 {
+        return customerrors.WrapBasicError(sql.ErrNoRows)
+    }
+
+    return nil
+}
+
+func(n *NotificationsDAO) MarkAllNotificationsRead(executor db.QueryExecutor, recipientSpotifyID string) error {
+
+    query := `UPDATE notifications SET read = true WHERE recipientspotifyid = $1 AND NOT read`
+
+    _, err := executor.Exec(query, recipientSpotifyID)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func nullStringPointer(s sql.NullString) *string {
+    if !s.Valid {
+        return nil
+    }
+    return &s.String
+}
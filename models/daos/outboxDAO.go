@@ -0,0 +1,142 @@
+package daos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+type OutboxDAO struct { }
+
+type IOutboxDAO interface {
+    EnqueueMessage(executor db.QueryExecutor, exchange string, routingKey string, message any) error
+    EnqueueEvent(executor db.QueryExecutor, event events.IEvent) error
+    ClaimPendingMessages(executor db.QueryExecutor, limit int) ([]responses.OutboxMessage, error)
+    MarkMessageSent(executor db.QueryExecutor, id int64) error
+    MarkMessageFailed(executor db.QueryExecutor, id int64, nextAttemptAt time.Time, reason string) error
+    DeleteSentMessages(executor db.QueryExecutor, sentBefore time.Time) error
+    GetOutboxStats(executor db.QueryExecutor) (*responses.OutboxStats, error)
+}
+
+// Writes a message to be published by the outbox relay. Call this with the same transaction as the change the message
+// describes, so the message is stored if and only if the change is
+func(o *OutboxDAO) EnqueueMessage(executor db.QueryExecutor, exchange string, routingKey string, message any) error {
+
+    payload, err := json.Marshal(message)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    query := `INSERT INTO outbox_messages (exchange, routingkey, payload, createdat, nextattemptat) VALUES ($1, $2, $3, $4, $4)`
+
+    _, err = executor.Exec(query, exchange, routingKey, payload, time.Now().UTC())
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Writes a domain event to be published to the events exchange, under its routing key
+func(o *OutboxDAO) EnqueueEvent(executor db.QueryExecutor, event events.IEvent) error {
+    return o.EnqueueMessage(executor, events.EXCHANGE, event.RoutingKey(), event)
+}
+
+// Locks the oldest messages that are due to be published. Rows locked by another relay are skipped, so several
+// instances can relay at once. Must be run inside of a transaction
+func(o *OutboxDAO) ClaimPendingMessages(executor db.QueryExecutor, limit int) ([]responses.OutboxMessage, error) {
+
+    query := `SELECT id, exchange, routingkey, payload, createdat, attempts 
+              FROM outbox_messages 
+              WHERE sentat IS NULL AND nextattemptat <= $1 
+              ORDER BY id 
+              LIMIT $2 
+              FOR UPDATE SKIP LOCKED`
+
+    rows, err := executor.Query(query, time.Now().UTC(), limit)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    messages := []responses.OutboxMessage{}
+
+    for rows.Next() {
+        message := responses.OutboxMessage{}
+        err := rows.Scan(&message.ID, &message.Exchange, &message.RoutingKey, &message.Payload, &message.CreatedAt, &message.Attempts)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        messages = append(messages, message)
+    }
+
+    return messages, nil
+}
+
+func(o *OutboxDAO) MarkMessageSent(executor db.QueryExecutor, id int64) error {
+
+    query := `UPDATE outbox_messages SET sentat = $2, attempts = attempts + 1, lasterror = NULL WHERE id = $1`
+
+    _, err := executor.Exec(query, id, time.Now().UTC())
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(o *OutboxDAO) MarkMessageFailed(executor db.QueryExecutor, id int64, nextAttemptAt time.Time, reason string) error {
+
+    query := `UPDATE outbox_messages SET attempts = attempts + 1, nextattemptat = $2, lasterror = $3 WHERE id = $1`
+
+    _, err := executor.Exec(query, id, nextAttemptAt, reason)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(o *OutboxDAO) DeleteSentMessages(executor db.QueryExecutor, sentBefore time.Time) error {
+
+    query := `DELETE FROM outbox_messages WHERE sentat < $1`
+
+    _, err := executor.Exec(query, sentBefore)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(o *OutboxDAO) GetOutboxStats(executor db.QueryExecutor) (*responses.OutboxStats, error) {
+
+    query := `SELECT count(*), min(createdat) FROM outbox_messages WHERE sentat IS NULL`
+
+    stats := &responses.OutboxStats{}
+    oldestPendingAt := sql.NullTime{}
+
+    err := executor.QueryRow(query).Scan(&stats.Pending, &oldestPendingAt)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    if oldestPendingAt.Valid {
+        stats.OldestPendingAt = &oldestPendingAt.Time
+    }
+
+    return stats, nil
+}
@@ -0,0 +1,93 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/lib/pq"
+)
+
+type PreferencesDAO struct { }
+
+type IPreferencesDAO interface {
+    GetPreferences(executor db.QueryExecutor, spotifyID string) (map[string]responses.NotificationDelivery, error)
+    UpsertPreferences(executor db.QueryExecutor, spotifyID string, preferences map[string]responses.NotificationDelivery) error
+    GetDeliveries(executor db.QueryExecutor, spotifyIDs []string, eventType string) (map[string]responses.NotificationDelivery, error)
+}
+
+// Only returns the preferences the user has set, event types they have not set are left out
+func(p *PreferencesDAO) GetPreferences(executor db.QueryExecutor, spotifyID string) (map[string]responses.NotificationDelivery, error) {
+
+    query := `SELECT eventtype, delivery FROM notification_preferences WHERE spotifyid = $1`
+
+    rows, err := executor.Query(query, spotifyID)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    preferences := make(map[string]responses.NotificationDelivery)
+
+    for rows.Next() {
+        eventType := ""
+        delivery := responses.NotificationDelivery("")
+        err := rows.Scan(&eventType, &delivery)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        preferences[eventType] = delivery
+    }
+
+    return preferences, nil
+}
+
+func(p *PreferencesDAO) UpsertPreferences(executor db.QueryExecutor, spotifyID string, preferences map[string]responses.NotificationDelivery) error {
+
+    query := `INSERT INTO notification_preferences (spotifyid, eventtype, delivery, updatedat) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (spotifyid, eventtype) DO UPDATE SET delivery = EXCLUDED.delivery, updatedat = EXCLUDED.updatedat`
+
+    now := time.Now().UTC()
+
+    for eventType, delivery := range preferences {
+
+        _, err := executor.Exec(query, spotifyID, eventType, delivery, now)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+    }
+
+    return nil
+}
+
+// Looks up how each of the users wants one type of event delivered. Users who have not set it are left out
+func(p *PreferencesDAO) GetDeliveries(executor db.QueryExecutor, spotifyIDs []string, eventType string) (map[string]responses.NotificationDelivery, error) {
+
+    query := `SELECT spotifyid, delivery FROM notification_preferences WHERE spotifyid = ANY($1) AND eventtype = $2`
+
+    rows, err := executor.Query(query, pq.Array(spotifyIDs), eventType)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    deliveries := make(map[string]responses.NotificationDelivery)
+
+    for rows.Next() {
+        spotifyID := ""
+        delivery := responses.NotificationDelivery("")
+        err := rows.Scan(&spotifyID, &delivery)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        deliveries[spotifyID] = delivery
+    }
+
+    return deliveries, nil
+}
@@ -0,0 +1,154 @@
+package daos
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+type SearchDAO struct { }
+
+// Posts are matched on the names of their subject from the catalog as well as their review. Only some of the catalog
+// is joined for each type of subject, so the rest is missing
+const postSearchVector = "(coalesce(tracks.searchvector, '') || coalesce(albums.searchvector, '') || coalesce(subject_artists.searchvector, '') || posts.searchvector)"
+
+// Results are ordered by rank, so the rank is the sort key of the cursor. It is compared as a float8, which round trips
+// through the cursor exactly
+var postSearchKeyset = cursor.Keyset{SortColumn: "ts_rank(" + postSearchVector + ", query)::float8", SortType: "float8", IDColumn: "posts.postid", IDType: "bigint", Descending: true}
+var commentSearchKeyset = cursor.Keyset{SortColumn: "ts_rank(comments.searchvector, query)::float8", SortType: "float8", IDColumn: "comments.commentid", IDType: "int", Descending: true}
+var userSearchKeyset = cursor.Keyset{SortColumn: "ts_rank(users.searchvector, query)::float8", SortType: "float8", IDColumn: "users.spotifyid", IDType: "text", Descending: true}
+
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2, FragmentDelimiter=\" ... \""
+
+type ISearchDAO interface {
+    SearchPosts(executor db.QueryExecutor, query string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostSearchResult, error)
+    SearchComments(executor db.QueryExecutor, query string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.CommentSearchResult, error)
+    SearchUsers(executor db.QueryExecutor, query string, page *cursor.PageRequest) ([]responses.UserSearchResult, error)
+}
+
+func(s *SearchDAO) SearchPosts(executor db.QueryExecutor, query string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.PostSearchResult, error) {
+
+    condition, orderBy, args := page.Clause(postSearchKeyset, 4)
+
+    sqlQuery := fmt.Sprintf(`SELECT %s,
+                  ts_rank(%s, query)::float8, 
+                  ts_headline('english', concat_ws(' - ', tracks.name, albums.name, subject_artists.name, posts.review), query, $3)
+              FROM posts 
+              CROSS JOIN websearch_to_tsquery('english', $1) AS query
+              %s
+              INNER JOIN users ON users.spotifyid = posts.posterspotifyid
+              LEFT JOIN post_votes AS viewer_votes ON viewer_votes.postid = posts.postid AND viewer_votes.voterspotifyid = $2
+              WHERE %s @@ query %s 
+              %s 
+              LIMIT %d`, postColumns, postSearchVector, postCatalogJoins, postSearchVector, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(sqlQuery, append([]any{query, viewerSpotifyID, searchHeadlineOptions}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    results := []responses.PostSearchResult{}
+
+    for rows.Next() {
+        result := responses.PostSearchResult{}
+        post, err := scanPostPreview(rows, &result.Rank, &result.Snippet)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        result.PostPreview = *post
+        results = append(results, result)
+    }
+
+    return results, nil
+}
+
+// Tombstoned comments are never returned
+func(s *SearchDAO) SearchComments(executor db.QueryExecutor, query string, viewerSpotifyID string, page *cursor.PageRequest) ([]responses.CommentSearchResult, error) {
+
+    condition, orderBy, args := page.Clause(commentSearchKeyset, 4)
+
+    sqlQuery := fmt.Sprintf(`SELECT %s,
+                  ts_rank(comments.searchvector, query)::float8,
+                  ts_headline('english', coalesce(comments.commenttext, ''), query, $3)
+              FROM comments 
+              CROSS JOIN websearch_to_tsquery('english', $1) AS query
+              INNER JOIN users ON users.spotifyid = comments.commentorspotifyid
+              LEFT JOIN comment_votes AS viewer_votes ON viewer_votes.commentid = comments.commentid AND viewer_votes.voterspotifyid = $2
+              WHERE comments.searchvector @@ query AND NOT comments.deleted %s 
+              %s 
+              LIMIT %d`, commentColumns, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(sqlQuery, append([]any{query, viewerSpotifyID, searchHeadlineOptions}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    results := []responses.CommentSearchResult{}
+
+    for rows.Next() {
+        result := responses.CommentSearchResult{}
+        comment, err := scanComment(rows, &result.Rank, &result.Snippet)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        result.Comment = *comment
+        results = append(results, result)
+    }
+
+    return results, nil
+}
+
+// Usernames are searched without stemming, since they are names rather than words
+func(s *SearchDAO) SearchUsers(executor db.QueryExecutor, query string, page *cursor.PageRequest) ([]responses.UserSearchResult, error) {
+
+    condition, orderBy, args := page.Clause(userSearchKeyset, 3)
+
+    sqlQuery := fmt.Sprintf(`SELECT users.spotifyid, users.username, users.bio, users.profileimage,
+                  ts_rank(users.searchvector, query)::float8,
+                  ts_headline('simple', concat_ws(' - ', users.username, users.bio), query, $2)
+              FROM users 
+              CROSS JOIN websearch_to_tsquery('simple', $1) AS query
+              WHERE users.searchvector @@ query %s 
+              %s 
+              LIMIT %d`, condition, orderBy, page.Limit())
+
+    rows, err := executor.Query(sqlQuery, append([]any{query, searchHeadlineOptions}, args...)...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    results := []responses.UserSearchResult{}
+
+    for rows.Next() {
+        result := responses.UserSearchResult{}
+        username := sql.NullString{}
+        bio := sql.NullString{}
+        profileImage := sql.NullString{}
+        err := rows.Scan(&result.SpotifyID, &username, &bio, &profileImage, &result.Rank, &result.Snippet)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        result.Username = username.String
+        result.Bio = bio.String
+        result.ProfileImage, err = scanProfileImage(profileImage)
+        if err != nil {
+            return nil, err
+        }
+        results = append(results, result)
+    }
+
+    return results, nil
+}
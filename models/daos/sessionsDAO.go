@@ -0,0 +1,163 @@
+package daos
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+type SessionsDAO struct { }
+
+const sessionColumns = `sessions.sessionid, sessions.spotifyid, COALESCE(users.username, ''), users.userrole, sessions.createdat, sessions.refreshedat, sessions.expiresat`
+
+type ISessionsDAO interface {
+    CreateSession(executor db.QueryExecutor, sessionID string, spotifyID string, refreshTokenID string, expiresAt time.Time, tokens *responses.EncryptedSpotifyTokens) error
+    GetActiveSession(executor db.QueryExecutor, sessionID string) (*responses.Session, error)
+    RotateRefreshToken(executor db.QueryExecutor, sessionID string, refreshTokenID string, newRefreshTokenID string, expiresAt time.Time) (*responses.Session, error)
+    RevokeSession(executor db.QueryExecutor, sessionID string, reason responses.SessionRevokedReason) error
+    RevokeUserSessions(executor db.QueryExecutor, spotifyID string, reason responses.SessionRevokedReason) error
+    GetSpotifyTokens(executor db.QueryExecutor, sessionID string, lock bool) (*responses.EncryptedSpotifyTokens, error)
+    UpdateSpotifyTokens(executor db.QueryExecutor, sessionID string, tokens *responses.EncryptedSpotifyTokens) error
+}
+
+// Also clears out the users sessions that have expired, since nothing can be done with them anymore
+func(s *SessionsDAO) CreateSession(executor db.QueryExecutor, sessionID string, spotifyID string, refreshTokenID string, expiresAt time.Time, tokens *responses.EncryptedSpotifyTokens) error {
+
+    now := time.Now().UTC()
+
+    query := `WITH expired AS (
+                  DELETE FROM sessions WHERE spotifyid = $2 AND expiresat < $5
+              )
+              INSERT INTO sessions (sessionid, spotifyid, refreshtokenid, createdat, refreshedat, expiresat,
+                                    spotifytokenkeyid, spotifytokendatakey, spotifyaccesstoken, spotifyrefreshtoken, spotifyaccesstokenexpiresat)
+              VALUES ($1, $2, $3, $5, $5, $4, $6, $7, $8, $9, $10)`
+
+    _, err := executor.Exec(query, sessionID, spotifyID, refreshTokenID, expiresAt, now,
+        tokens.KeyID, tokens.DataKey, tokens.AccessToken, tokens.RefreshToken, tokens.AccessTokenExpiresAt.UTC())
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Returns a 404 if the session does not exist, has been revoked or has expired
+func(s *SessionsDAO) GetActiveSession(executor db.QueryExecutor, sessionID string) (*responses.Session, error) {
+
+    query := fmt.Sprintf(`SELECT %s
+              FROM sessions
+              INNER JOIN users ON users.spotifyid = sessions.spotifyid
+              WHERE sessions.sessionid = $1 AND sessions.revokedat IS NULL AND sessions.expiresat > $2`, sessionColumns)
+
+    return scanSession(executor.QueryRow(query, sessionID, time.Now().UTC()))
+}
+
+// Swaps the sessions refresh token for a new one and extends the session to expiresAt, as long as refreshTokenID is
+// still the sessions refresh token. Returns a 404 if it is not, or if the session is no longer active
+func(s *SessionsDAO) RotateRefreshToken(executor db.QueryExecutor, sessionID string, refreshTokenID string, newRefreshTokenID string, expiresAt time.Time) (*responses.Session, error) {
+
+    query := fmt.Sprintf(`WITH rotated AS (
+                  UPDATE sessions SET refreshtokenid = $3, refreshedat = $5, expiresat = $4
+                  WHERE sessionid = $1 AND refreshtokenid = $2 AND revokedat IS NULL AND expiresat > $5
+                  RETURNING *
+              )
+              SELECT %s
+              FROM rotated AS sessions
+              INNER JOIN users ON users.spotifyid = sessions.spotifyid`, sessionColumns)
+
+    return scanSession(executor.QueryRow(query, sessionID, refreshTokenID, newRefreshTokenID, expiresAt, time.Now().UTC()))
+}
+
+// Revoking a session that is no longer active does nothing
+func(s *SessionsDAO) RevokeSession(executor db.QueryExecutor, sessionID string, reason responses.SessionRevokedReason) error {
+
+    query := `UPDATE sessions SET revokedat = $2, revokedreason = $3 WHERE sessionid = $1 AND revokedat IS NULL`
+
+    _, err := executor.Exec(query, sessionID, time.Now().UTC(), reason)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(s *SessionsDAO) RevokeUserSessions(executor db.QueryExecutor, spotifyID string, reason responses.SessionRevokedReason) error {
+
+    now := time.Now().UTC()
+
+    query := `UPDATE sessions SET revokedat = $2, revokedreason = $3 WHERE spotifyid = $1 AND revokedat IS NULL AND expiresat > $2`
+
+    _, err := executor.Exec(query, spotifyID, now, reason)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// With lock, the session is locked until the transaction ends, so only one request at a time can replace its tokens
+func(s *SessionsDAO) GetSpotifyTokens(executor db.QueryExecutor, sessionID string, lock bool) (*responses.EncryptedSpotifyTokens, error) {
+
+    query := `SELECT spotifytokenkeyid, spotifytokendatakey, spotifyaccesstoken, spotifyrefreshtoken, spotifyaccesstokenexpiresat
+              FROM sessions
+              WHERE sessionid = $1`
+
+    if lock {
+        query += " FOR UPDATE"
+    }
+
+    tokens := &responses.EncryptedSpotifyTokens{}
+
+    err := executor.QueryRow(query, sessionID).Scan(&tokens.KeyID, &tokens.DataKey, &tokens.AccessToken, &tokens.RefreshToken, &tokens.AccessTokenExpiresAt)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return tokens, nil
+}
+
+func(s *SessionsDAO) UpdateSpotifyTokens(executor db.QueryExecutor, sessionID string, tokens *responses.EncryptedSpotifyTokens) error {
+
+    query := `UPDATE sessions
+              SET spotifytokenkeyid = $2, spotifytokendatakey = $3, spotifyaccesstoken = $4, spotifyrefreshtoken = $5, spotifyaccesstokenexpiresat = $6
+              WHERE sessionid = $1`
+
+    res, err := executor.Exec(query, sessionID, tokens.KeyID, tokens.DataKey, tokens.AccessToken, tokens.RefreshToken, tokens.AccessTokenExpiresAt.UTC())
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    rows, err := res.RowsAffected()
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    if rows < 1 {
+        return customerrors.WrapBasicError(sql.ErrNoRows)
+    }
+
+    return nil
+}
+
+func scanSession(row *sql.Row) (*responses.Session, error) {
+
+    session := &responses.Session{}
+
+    err := row.Scan(&session.SessionID, &session.SpotifyID, &session.Username, &session.Role, &session.CreatedAt, &session.RefreshedAt, &session.ExpiresAt)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return session, nil
+}
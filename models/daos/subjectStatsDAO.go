@@ -0,0 +1,73 @@
+package daos
+
+import (
+	"fmt"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// Rating statistics are kept up to date by a trigger on posts, so reading them never scans the posts themselves
+type SubjectStatsDAO struct { }
+
+type ISubjectStatsDAO interface {
+    GetRatingStats(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string) (*responses.RatingStats, error)
+    GetAlbumTrackRatingStats(executor db.QueryExecutor, albumID string) (*responses.RatingStats, error)
+}
+
+func(s *SubjectStatsDAO) GetRatingStats(executor db.QueryExecutor, subjectType responses.SubjectType, subjectID string) (*responses.RatingStats, error) {
+    return s.getRatingStats(executor, "", "stats.subjecttype = $1 AND stats.subjectid = $2", subjectType, subjectID)
+}
+
+// The statistics of every track on the album combined
+func(s *SubjectStatsDAO) GetAlbumTrackRatingStats(executor db.QueryExecutor, albumID string) (*responses.RatingStats, error) {
+    return s.getRatingStats(executor, "INNER JOIN tracks ON tracks.trackid = stats.subjectid", "stats.subjecttype = $1 AND tracks.albumid = $2", responses.TRACK, albumID)
+}
+
+// The histogram only has buckets for ratings that have been given
+func(s *SubjectStatsDAO) getRatingStats(executor db.QueryExecutor, joins string, condition string, args ...any) (*responses.RatingStats, error) {
+
+    query := fmt.Sprintf(`SELECT coalesce(sum(stats.reviewcount), 0), coalesce(sum(stats.ratedcount), 0), coalesce(sum(stats.ratingsum), 0) FROM subject_rating_stats AS stats %s WHERE %s`, joins, condition)
+
+    stats := &responses.RatingStats{}
+    ratingSum := responses.Rating(0)
+
+    err := executor.QueryRow(query, args...).Scan(&stats.ReviewCount, &stats.RatedCount, &ratingSum)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    if stats.RatedCount > 0 {
+        average := ratingSum.Float64() / float64(stats.RatedCount)
+        stats.AverageRating = &average
+    }
+
+    query = fmt.Sprintf(`SELECT stats.rating, sum(stats.reviewcount)
+              FROM subject_rating_counts AS stats %s
+              WHERE %s AND stats.reviewcount > 0
+              GROUP BY stats.rating
+              ORDER BY stats.rating`, joins, condition)
+
+    rows, err := executor.Query(query, args...)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    defer rows.Close()
+
+    stats.Histogram = []responses.RatingBucket{}
+
+    for rows.Next() {
+        bucket := responses.RatingBucket{}
+        err := rows.Scan(&bucket.Rating, &bucket.Count)
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+        stats.Histogram = append(stats.Histogram, bucket)
+    }
+
+    return stats, nil
+}
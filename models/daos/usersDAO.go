@@ -2,6 +2,10 @@ package daos
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
@@ -12,6 +16,8 @@ import (
 
 type UsersDAO struct { }
 
+var usersKeyset = cursor.Keyset{SortColumn: "users.spotifyid", SortType: "text", IDColumn: "users.spotifyid", IDType: "text"}
+
 type IUsersDAO interface {
     UpsertUser(executor db.QueryExecutor, username string, spotifyID string) (*responses.User, error)
     GetUser(executor db.QueryExecutor, spotifyID string) (*responses.User, error)
@@ -19,14 +25,14 @@ type IUsersDAO interface {
     DeleteUser(executor db.QueryExecutor, spotifyID string) error
     UnfollowUser(executor db.QueryExecutor, spotifyID string, otherUserSpotifyID string) error
     FollowUser(executor db.QueryExecutor, spotifyID string, otherUserSpotifyID string) error 
-    GetUserFollowers(executor db.QueryExecutor, spotifyID string, paginationKey string) ([]responses.User, error)
-    GetUserFollowing(executor db.QueryExecutor, spotifyID string, paginationKey string) ([]responses.User, error)
+    GetUserFollowers(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.User, error)
+    GetUserFollowing(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.User, error)
     GetAllUserFollowing(executor db.QueryExecutor, spotifyID string) ([]responses.User, error)
-    UpsertUserProfilePicture(executor db.QueryExecutor, spotifyID string) (*responses.ProfileImage, error)
+    UpsertUserProfilePicture(executor db.QueryExecutor, spotifyID string, profileImage *responses.ProfileImage) (*responses.ProfileImage, error)
 }
 
 func(u *UsersDAO) UpsertUser(executor db.QueryExecutor, username string, spotifyID string) (*responses.User, error) {
-	query := "INSERT INTO users (spotifyid, username, userrole) values ($1, $2, 'BASIC') ON CONFLICT (spotifyID) DO UPDATE SET username=$2 RETURNING bio, userrole"
+	query := "INSERT INTO users (spotifyid, username, userrole) values ($1, $2, 'BASIC') ON CONFLICT (spotifyID) DO UPDATE SET username=$2 RETURNING bio, userrole, profileimage"
 	row := executor.QueryRow(query, spotifyID, username)
 
 	userResponse := &responses.User{}
@@ -34,7 +40,8 @@ func(u *UsersDAO) UpsertUser(executor db.QueryExecutor, username string, spotify
 	userResponse.SpotifyID = spotifyID
 
 	bio := sql.NullString{}
-	err := row.Scan(&bio, &userResponse.Role)
+    profileImage := sql.NullString{}
+	err := row.Scan(&bio, &userResponse.Role, &profileImage)
 
 	if err != nil {
 		return nil, customerrors.WrapBasicError(err)
@@ -42,19 +49,25 @@ func(u *UsersDAO) UpsertUser(executor db.QueryExecutor, username string, spotify
 
 	userResponse.Bio = bio.String
     userResponse.Email = ""
+    userResponse.ProfileImage, err = scanProfileImage(profileImage)
+
+	if err != nil {
+		return nil, err
+	}
 
 	return userResponse, nil
 }
 
 func(u *UsersDAO) GetUser(executor db.QueryExecutor, spotifyID string) (*responses.User, error) {
-	query := "SELECT spotifyid, userrole, username, bio, email FROM users WHERE spotifyid = $1"
+	query := "SELECT spotifyid, userrole, username, bio, email, profileimage FROM users WHERE spotifyid = $1"
 	row := executor.QueryRow(query, spotifyID)
 
 	userResponse := &responses.User{}
 
 	bio := sql.NullString{}
     email := sql.NullString{}
-	err := row.Scan(&userResponse.SpotifyID, &userResponse.Role, &userResponse.Username, &bio, &email)
+    profileImage := sql.NullString{}
+	err := row.Scan(&userResponse.SpotifyID, &userResponse.Role, &userResponse.Username, &bio, &email, &profileImage)
 
 	if err != nil {
 		return nil, customerrors.WrapBasicError(err)
@@ -62,6 +75,11 @@ func(u *UsersDAO) GetUser(executor db.QueryExecutor, spotifyID string) (*respons
 
 	userResponse.Bio = bio.String
     userResponse.Email = email.String
+    userResponse.ProfileImage, err = scanProfileImage(profileImage)
+
+	if err != nil {
+		return nil, err
+	}
 
 	return userResponse, nil
 }
@@ -74,7 +92,7 @@ func(u *UsersDAO) UpdateUser(executor db.QueryExecutor, spotifyID string, update
     conditionals := make(map[string]any)
     conditionals["spotifyID"] = spotifyID
 
-    returning := []string{"bio", "userrole", "spotifyid", "username", "email"}
+    returning := []string{"bio", "userrole", "spotifyid", "username", "email", "profileimage"}
 
     query, values := db.PatchQueryBuilder("users", updateUserMap, conditionals, returning)
 
@@ -83,7 +101,8 @@ func(u *UsersDAO) UpdateUser(executor db.QueryExecutor, spotifyID string, update
 	userResponse := &responses.User{}
 	bio := sql.NullString{}
     email := sql.NullString{}
-	err := res.Scan(&bio, &userResponse.Role, &userResponse.SpotifyID, &userResponse.Username, &email)
+    profileImage := sql.NullString{}
+	err := res.Scan(&bio, &userResponse.Role, &userResponse.SpotifyID, &userResponse.Username, &email, &profileImage)
 	userResponse.Bio = bio.String
     userResponse.Email = email.String
 
@@ -91,12 +110,37 @@ func(u *UsersDAO) UpdateUser(executor db.QueryExecutor, spotifyID string, update
 		return nil, customerrors.WrapBasicError(err)
 	}
 
+    userResponse.ProfileImage, err = scanProfileImage(profileImage)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return userResponse, nil
 
 }
 
+// Votes cast by the user are removed by the cascade, so the counters on the posts and comments they voted on are
+// decremented in the same statement
 func(u *UsersDAO) DeleteUser(executor db.QueryExecutor, spotifyID string) error {
-	query := "DELETE FROM users WHERE spotifyID = $1"
+	query := `WITH post_counts AS (
+                  UPDATE posts SET likes = posts.likes - votes.likes, dislikes = posts.dislikes - votes.dislikes
+                  FROM (
+                      SELECT postid, count(*) FILTER (WHERE liked) AS likes, count(*) FILTER (WHERE NOT liked) AS dislikes
+                      FROM post_votes WHERE voterspotifyid = $1
+                      GROUP BY postid
+                  ) AS votes
+                  WHERE posts.postid = votes.postid AND posts.posterspotifyid <> $1
+              ), comment_counts AS (
+                  UPDATE comments SET likes = comments.likes - votes.likes, dislikes = comments.dislikes - votes.dislikes
+                  FROM (
+                      SELECT commentid, count(*) FILTER (WHERE liked) AS likes, count(*) FILTER (WHERE NOT liked) AS dislikes
+                      FROM comment_votes WHERE voterspotifyid = $1
+                      GROUP BY commentid
+                  ) AS votes
+                  WHERE comments.commentid = votes.commentid AND comments.commentorspotifyid <> $1
+              )
+              DELETE FROM users WHERE spotifyID = $1`
 	res, err := executor.Exec(query, spotifyID)
 
 	if err != nil {
@@ -160,15 +204,17 @@ func(u *UsersDAO) FollowUser(executor db.QueryExecutor, spotifyID string, otherU
 	return nil
 }
 
-func(u *UsersDAO) GetUserFollowers(executor db.QueryExecutor, spotifyID string, paginationKey string) ([]responses.User, error) {
+func(u *UsersDAO) GetUserFollowers(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.User, error) {
+
+    condition, orderBy, args := page.Clause(usersKeyset, 2)
 
-    query := ` SELECT users.spotifyid, users.username, users.bio, users.userrole 
+    query := fmt.Sprintf(` SELECT users.spotifyid, users.username, users.bio, users.userrole, users.profileimage 
                 FROM followers 
                 INNER JOIN  users 
                 ON users.spotifyid = followers.follower 
-                WHERE followers.userfollowed = $1 AND followers.follower > $2 ORDER BY users.spotifyid LIMIT 25 `
+                WHERE followers.userfollowed = $1 %s %s LIMIT %d `, condition, orderBy, page.Limit())
 
-    rows, err := executor.Query(query, spotifyID, paginationKey)
+    rows, err := executor.Query(query, append([]any{spotifyID}, args...)...)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
@@ -176,29 +222,36 @@ func(u *UsersDAO) GetUserFollowers(executor db.QueryExecutor, spotifyID string,
 
     followers := []responses.User{}
     bio := sql.NullString{}
+    profileImage := sql.NullString{}
 
     for rows.Next() {
         user := responses.User{}
-        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role)
+        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role, &profileImage)
         if err != nil {
             return nil, customerrors.WrapBasicError(err)
         }
         user.Bio = bio.String
+        user.ProfileImage, err = scanProfileImage(profileImage)
+        if err != nil {
+            return nil, err
+        }
         followers = append(followers, user)
     }
 
     return followers, nil
 }
 
-func(u *UsersDAO) GetUserFollowing(executor db.QueryExecutor, spotifyID string, paginationKey string) ([]responses.User, error) {
+func(u *UsersDAO) GetUserFollowing(executor db.QueryExecutor, spotifyID string, page *cursor.PageRequest) ([]responses.User, error) {
+
+    condition, orderBy, args := page.Clause(usersKeyset, 2)
 
-    query := ` SELECT users.spotifyid, users.username, users.bio, users.userrole 
+    query := fmt.Sprintf(` SELECT users.spotifyid, users.username, users.bio, users.userrole, users.profileimage 
                 FROM followers 
                 INNER JOIN  users 
                 ON users.spotifyid = followers.userfollowed 
-                WHERE followers.follower = $1 AND users.spotifyid > $2 ORDER BY users.spotifyid LIMIT 25 `
+                WHERE followers.follower = $1 %s %s LIMIT %d `, condition, orderBy, page.Limit())
 
-    rows, err := executor.Query(query, spotifyID, paginationKey)
+    rows, err := executor.Query(query, append([]any{spotifyID}, args...)...)
 
     if err != nil {
         return nil, customerrors.WrapBasicError(err)
@@ -206,14 +259,19 @@ func(u *UsersDAO) GetUserFollowing(executor db.QueryExecutor, spotifyID string,
 
     following := []responses.User{}
     bio := sql.NullString{}
+    profileImage := sql.NullString{}
 
     for rows.Next() {
         user := responses.User{}
-        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role)
+        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role, &profileImage)
         if err != nil {
             return nil, customerrors.WrapBasicError(err)
         }
         user.Bio = bio.String
+        user.ProfileImage, err = scanProfileImage(profileImage)
+        if err != nil {
+            return nil, err
+        }
         following = append(following, user)
     }
 
@@ -223,7 +281,7 @@ func(u *UsersDAO) GetUserFollowing(executor db.QueryExecutor, spotifyID string,
 
 func(u *UsersDAO) GetAllUserFollowing(executor db.QueryExecutor, spotifyID string) ([]responses.User, error) {
 
-    query := ` SELECT users.spotifyid, users.username, users.bio, users.userrole 
+    query := ` SELECT users.spotifyid, users.username, users.bio, users.userrole, users.profileimage 
                 FROM followers 
                 INNER JOIN  users 
                 ON users.spotifyid = followers.userfollowed 
@@ -237,14 +295,19 @@ func(u *UsersDAO) GetAllUserFollowing(executor db.QueryExecutor, spotifyID strin
 
     following := []responses.User{}
     bio := sql.NullString{}
+    profileImage := sql.NullString{}
 
     for rows.Next() {
         user := responses.User{}
-        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role)
+        err := rows.Scan(&user.SpotifyID, &user.Username, &bio, &user.Role, &profileImage)
         if err != nil {
             return nil, customerrors.WrapBasicError(err)
         }
         user.Bio = bio.String
+        user.ProfileImage, err = scanProfileImage(profileImage)
+        if err != nil {
+            return nil, err
+        }
         following = append(following, user)
     }
 
@@ -252,6 +315,40 @@ func(u *UsersDAO) GetAllUserFollowing(executor db.QueryExecutor, spotifyID strin
 
 }
 
-func(u *UsersDAO) UpsertUserProfilePicture(executor db.QueryExecutor, spotifyID string) (*responses.ProfileImage, error) {
-    return nil, nil
+func(u *UsersDAO) UpsertUserProfilePicture(executor db.QueryExecutor, spotifyID string, profileImage *responses.ProfileImage) (*responses.ProfileImage, error) {
+
+    profileImageJSON, err := json.Marshal(profileImage)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    query := "UPDATE users SET profileimage = $1 WHERE spotifyid = $2 RETURNING profileimage"
+
+    row := executor.QueryRow(query, string(profileImageJSON), spotifyID)
+
+    updatedProfileImage := sql.NullString{}
+    err = row.Scan(&updatedProfileImage)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return scanProfileImage(updatedProfileImage)
+}
+
+func scanProfileImage(profileImage sql.NullString) (*responses.ProfileImage, error) {
+
+    if !profileImage.Valid {
+        return nil, nil
+    }
+
+    image := &responses.ProfileImage{}
+    err := json.Unmarshal([]byte(profileImage.String), image)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return image, nil
 }
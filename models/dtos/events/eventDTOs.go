@@ -0,0 +1,208 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/google/uuid"
+)
+
+// Every event is published to this topic exchange, with a routing key of the event type followed by the schema
+// version, e.g. post.created.v1. Consumers bind to the events they care about, e.g. post.* or comment.#
+const EXCHANGE = "tunes.events"
+
+type EventType string
+
+const (
+	POST_CREATED      EventType = "post.created"
+	POST_UPDATED      EventType = "post.updated"
+	POST_DELETED      EventType = "post.deleted"
+	POST_LIKED        EventType = "post.liked"
+	POST_DISLIKED     EventType = "post.disliked"
+	COMMENT_CREATED   EventType = "comment.created"
+	COMMENT_REPLIED   EventType = "comment.replied"
+	USER_FOLLOWED     EventType = "user.followed"
+	USER_ROLE_CHANGED EventType = "user.roleChanged"
+)
+
+// Only pushed to connected clients, never published to the exchange
+const NOTIFICATION_CREATED EventType = "notification.created"
+
+// Emails for the email service. Each one is addressed to a single user, and is only sent if their preferences allow it
+const (
+	EMAIL_INSTANT EventType = "email.instant"
+	EMAIL_DIGEST  EventType = "email.digest"
+)
+
+// The events a user can be emailed about, and how they are delivered until the user chooses otherwise
+var DEFAULT_EMAIL_DELIVERIES = map[EventType]responses.NotificationDelivery{
+	POST_CREATED:    responses.DELIVERY_INSTANT,
+	COMMENT_CREATED: responses.DELIVERY_INSTANT,
+	COMMENT_REPLIED: responses.DELIVERY_INSTANT,
+	POST_LIKED:      responses.DELIVERY_DAILY,
+	USER_FOLLOWED:   responses.DELIVERY_DAILY,
+}
+
+// Bumped whenever a payload changes in a way that is not backwards compatible. Consumers of an old version keep
+// working off of its routing key until they are moved over
+const SCHEMA_VERSION = 1
+
+// Events whose payloads have changed since SCHEMA_VERSION
+var SCHEMA_VERSIONS = map[EventType]int{
+	// Rating became a nullable number of stars, so that posts can have half stars or no rating at all
+	POST_CREATED: 2,
+	POST_UPDATED: 2,
+}
+
+func SchemaVersion(eventType EventType) int {
+	if version, found := SCHEMA_VERSIONS[eventType]; found {
+		return version
+	}
+	return SCHEMA_VERSION
+}
+
+type IEvent interface {
+	Type() EventType
+	RoutingKey() string
+}
+
+type Event[T any] struct {
+	EventID       string
+	EventType     EventType
+	SchemaVersion int
+	OccurredAt    time.Time
+	Payload       T
+}
+
+func New[T any](eventType EventType, payload T) Event[T] {
+	return Event[T]{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		SchemaVersion: SchemaVersion(eventType),
+		OccurredAt:    time.Now().UTC(),
+		Payload:       payload,
+	}
+}
+
+func (e Event[T]) Type() EventType {
+	return e.EventType
+}
+
+func (e Event[T]) RoutingKey() string {
+	return fmt.Sprintf("%s.v%d", e.EventType, e.SchemaVersion)
+}
+
+// Used by post.created and post.updated. The song and album fields are only set for the subject types that have them
+type PostPayload struct {
+	PostID          int64
+	PosterSpotifyID string
+	PosterUsername  string
+	SubjectType     responses.SubjectType
+	SongID          string
+	SubjectName     string
+	SongName        string
+	AlbumID         string
+	AlbumName       string
+	AlbumArtURI     string
+	Rating          *responses.Rating
+	Review          string
+	Relisten        bool
+	ListenedAt      time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type PostDeletedPayload struct {
+	PostID             int64
+	PosterSpotifyID    string
+	SubjectType        responses.SubjectType
+	SongID             string
+	DeletedBySpotifyID string
+}
+
+// Used by post.liked and post.disliked
+type PostVotePayload struct {
+	VoterSpotifyID  string
+	VoterUsername   string
+	PostID          int64
+	PosterSpotifyID string
+	SubjectType     responses.SubjectType
+	SongID          string
+	SubjectName     string
+	SongName        string
+}
+
+// Used by comment.created for top level comments, and comment.replied for replies. Replies also carry who wrote the
+// comment being replied to
+type CommentPayload struct {
+	CommentID                int
+	CommentorSpotifyID       string
+	CommentorUsername        string
+	PostID                   int64
+	PosterSpotifyID          string
+	SubjectType              responses.SubjectType
+	SongID                   string
+	CommentText              string
+	ParentCommentID          *int
+	ParentCommentorSpotifyID string
+}
+
+type UserFollowedPayload struct {
+	FollowerSpotifyID string
+	FollowerUsername  string
+	FollowedSpotifyID string
+}
+
+type RoleChangedPayload struct {
+	SpotifyID          string
+	OldRole            responses.Role
+	NewRole            responses.Role
+	ChangedBySpotifyID string
+}
+
+// Sent when a notification is created, or another user is added to an unread one
+type NotificationPayload struct {
+	NotificationID     int64
+	RecipientSpotifyID string
+	NotificationType   responses.NotificationType
+	ActorSpotifyID     string
+}
+
+// Event is the envelope of the event the email is about
+type EmailPayload struct {
+	RecipientSpotifyID string
+	Event              json.RawMessage
+}
+
+// Events holds the envelopes of the events since the last digest, oldest first. Long digests are cut short, so
+// TotalEvents is how many there were in all
+type EmailDigestPayload struct {
+	RecipientSpotifyID string
+	Delivery           responses.NotificationDelivery
+	PeriodEnd          time.Time
+	TotalEvents        int
+	Events             []json.RawMessage
+}
+
+func NewPostPayload(post *responses.PostPreview) PostPayload {
+	return PostPayload{
+		PostID:          post.PostID,
+		PosterSpotifyID: post.SpotifyID,
+		PosterUsername:  post.Username,
+		SubjectType:     post.SubjectType,
+		SongID:          post.SongID,
+		SubjectName:     post.SubjectName,
+		SongName:        post.SongName,
+		AlbumID:         post.AlbumID,
+		AlbumName:       post.AlbumName,
+		AlbumArtURI:     post.AlbumArtURI,
+		Rating:          post.Rating,
+		Review:          post.Text,
+		Relisten:        post.Relisten,
+		ListenedAt:      post.ListenedAt,
+		CreatedAt:       post.CreatedAt,
+		UpdatedAt:       post.UpdatedAt,
+	}
+}
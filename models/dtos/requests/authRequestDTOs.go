@@ -1,21 +1,21 @@
 package requests
 
 import (
-	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// The jti is unique to every JWT, and SessionID ties the JWT to the session it was issued for. JWTs are only signed,
+// so anything secret, like the users Spotify tokens, is kept on the session instead
 type JWTClaims struct {
-	SpotifyID            string
-	AccessToken          string
-	AccessTokenExpiresAt int
-	UserRole             responses.Role
-	Username             string
+	SessionID string
+	SpotifyID string
+	Username  string
 	jwt.RegisteredClaims
 }
 
+// The jti is the sessions refresh token ID, which changes every time the session is refreshed
 type RefreshJWTClaims struct {
-	RefreshToken string
+	SessionID string
 	jwt.RegisteredClaims
 }
 
@@ -2,6 +2,7 @@ package requests
 
 type CreateCommentDTO struct {
     CommentText string
+    ParentCommentID *int
 }
 
 type CommentIDPathParams struct {
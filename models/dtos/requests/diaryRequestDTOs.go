@@ -0,0 +1,17 @@
+package requests
+
+import "time"
+
+// Listens are usually candidates from /diary/recent, sent back as they were returned
+type CreateDraftsDTO struct {
+	Listens []ListenDTO
+}
+
+type ListenDTO struct {
+	TrackID    string
+	ListenedAt time.Time
+}
+
+type DraftIDPathParams struct {
+	DraftID int64 `uri:"draftID" binding:"required,numeric"`
+}
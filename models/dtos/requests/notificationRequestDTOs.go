@@ -0,0 +1,5 @@
+package requests
+
+type NotificationIDPathParams struct {
+    NotificationID int64 `uri:"notificationID" binding:"required,numeric"`
+}
@@ -1,12 +1,52 @@
 package requests
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// A missing Rating leaves the rating alone, and a null Rating removes it
 type UpdatePostRequestDTO struct {
-    Rating *int 
+    Rating OptionalRating `mapstructure:"-" swaggertype:"number"`
 	Review   *string  
 }
 
+// SubjectType defaults to TRACK. SubjectID is the Spotify ID of the track, album or artist being reviewed, and SongID
+// is still accepted in its place for tracks. A missing or null Rating posts a review without a rating. ListenedAt
+// defaults to now. A post is a re-listen when Relisten is true or the poster has already posted about the subject.
+// Setting DraftID publishes the draft, which supplies the subject and ListenedAt
 type CreatePostDTO struct {
+	DraftID *int64
+	SubjectType *responses.SubjectType
+	SubjectID *string
 	SongID *string
-	Rating *int
+	Rating *responses.Rating `swaggertype:"number"`
 	Text   *string
+	ListenedAt *time.Time
+	Relisten *bool
+}
+
+type PostIDPathParams struct {
+    PostID int64 `uri:"postID" binding:"required,numeric"`
+}
+
+// Tells a rating that was sent as null apart from one that was not sent at all. A null rating is written as NULL
+type OptionalRating struct {
+    Set bool
+    Rating *responses.Rating
+}
+
+func(o *OptionalRating) UnmarshalJSON(data []byte) error {
+    o.Set = true
+    return json.Unmarshal(data, &o.Rating)
+}
+
+func(o OptionalRating) Value() (driver.Value, error) {
+    if o.Rating == nil {
+        return nil, nil
+    }
+    return o.Rating.Value()
 }
@@ -7,3 +7,8 @@ type UpdateUserRequestDTO struct {
     Email *string
 	UserRole *responses.Role 
 }
+
+// Maps event types to how they should be delivered. Event types that are left out are not changed
+type UpdateNotificationPreferencesDTO struct {
+    Preferences map[string]responses.NotificationDelivery
+}
@@ -0,0 +1,31 @@
+package responses
+
+import "time"
+
+// Artists that are only known as the artists of a track or album have no image, and were never fetched on their own
+type Artist struct {
+	ArtistID  string
+	Name      string
+	ImageURI  string
+	FetchedAt time.Time
+}
+
+// ReleaseDate is as precise as ReleaseDatePrecision, one of year, month or day, e.g. 1969, 1969-09 or 1969-09-26
+type Album struct {
+	AlbumID              string
+	Name                 string
+	AlbumArtURI          string
+	ReleaseDate          string
+	ReleaseDatePrecision string
+	Artists              []Artist
+	FetchedAt            time.Time
+}
+
+type Track struct {
+	TrackID    string
+	Name       string
+	DurationMs int
+	Album      Album
+	Artists    []Artist
+	FetchedAt  time.Time
+}
@@ -5,14 +5,21 @@ import "time"
 type Comment struct {
 
     CommentID int
+    ParentCommentID *int
+    Depth int
+    ReplyCount int
+    Deleted bool
     Likes int
     Dislikes int
+    CurrentUserVote *Vote
 	CommentText string
     CommentorID string
     CommentorUsername string
+    PostID int64
     PostSpotifyID string
     CreatedAt time.Time
     UpdatedAt time.Time
+    SubjectType SubjectType
     SongID string
     
 
@@ -0,0 +1,22 @@
+package responses
+
+import "time"
+
+// A track the user listened to on Spotify that they have not posted about or drafted since. Relisten is set when the
+// user has posted about the track before
+type ListenCandidate struct {
+	TrackID     string
+	SongName    string
+	AlbumID     string
+	AlbumName   string
+	AlbumArtURI string
+	ListenedAt  time.Time
+	Relisten    bool
+}
+
+// A listen the user picked to review later. Publishing it with a rating and a review turns it into a post
+type PostDraft struct {
+	DraftID         int64
+	ListenCandidate `mapstructure:",squash"`
+	CreatedAt       time.Time
+}
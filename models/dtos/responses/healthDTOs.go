@@ -0,0 +1,33 @@
+package responses
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HEALTHY   HealthStatus = "HEALTHY"
+	DEGRADED  HealthStatus = "DEGRADED"
+	UNHEALTHY HealthStatus = "UNHEALTHY"
+)
+
+type RabbitMQConnectionState string
+
+const (
+	RABBITMQ_CONNECTING   RabbitMQConnectionState = "CONNECTING"
+	RABBITMQ_CONNECTED    RabbitMQConnectionState = "CONNECTED"
+	RABBITMQ_DISCONNECTED RabbitMQConnectionState = "DISCONNECTED"
+	RABBITMQ_CLOSED       RabbitMQConnectionState = "CLOSED"
+)
+
+type RabbitMQHealth struct {
+	State       RabbitMQConnectionState
+	LastError   string
+	ConnectedAt *time.Time
+	Reconnects  int
+}
+
+type Health struct {
+	Status   HealthStatus
+	Database HealthStatus
+	RabbitMQ RabbitMQHealth
+}
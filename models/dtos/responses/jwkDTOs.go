@@ -0,0 +1,16 @@
+package responses
+
+// A JSON Web Key Set, as described in RFC 7517. Names follow the RFC rather than the rest of the API
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// The public half of a JWT signing key. X is the Ed25519 public key
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
@@ -0,0 +1,37 @@
+package responses
+
+import "time"
+
+type NotificationType string
+
+const (
+	FOLLOWED        NotificationType = "FOLLOWED"
+	POST_LIKED      NotificationType = "POST_LIKED"
+	POST_COMMENTED  NotificationType = "POST_COMMENTED"
+	COMMENT_REPLIED NotificationType = "COMMENT_REPLIED"
+	COMMENT_LIKED   NotificationType = "COMMENT_LIKED"
+)
+
+// Every user who did the same thing to the same subject while the notification was unread is counted in ActorCount,
+// and the most recent of them is LatestActor
+type Notification struct {
+	NotificationID  int64
+	Type            NotificationType
+	Message         string
+	ActorCount      int
+	LatestActor     UserIdentifer
+	PostID          *int64
+	PosterSpotifyID *string
+	SubjectType     *SubjectType
+	SongID          *string
+	SubjectName     *string
+	SongName        *string
+	CommentID       *int
+	Read            bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type UnreadNotificationCount struct {
+	Count int
+}
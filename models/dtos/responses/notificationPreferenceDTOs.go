@@ -0,0 +1,38 @@
+package responses
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationDelivery string
+
+const (
+	DELIVERY_OFF     NotificationDelivery = "OFF"
+	DELIVERY_INSTANT NotificationDelivery = "INSTANT"
+	DELIVERY_DAILY   NotificationDelivery = "DAILY"
+	DELIVERY_WEEKLY  NotificationDelivery = "WEEKLY"
+)
+
+func IsValidNotificationDelivery(delivery NotificationDelivery) bool {
+	switch delivery {
+	case DELIVERY_OFF, DELIVERY_INSTANT, DELIVERY_DAILY, DELIVERY_WEEKLY:
+		return true
+	}
+	return false
+}
+
+// How a user is emailed about one type of event
+type NotificationPreference struct {
+	EventType string
+	Delivery  NotificationDelivery
+}
+
+// An event waiting to be sent out in a digest
+type PendingDigestEvent struct {
+	ID                 int64
+	RecipientSpotifyID string
+	EventType          string
+	Event              json.RawMessage
+	CreatedAt          time.Time
+}
@@ -0,0 +1,17 @@
+package responses
+
+import "time"
+
+type OutboxMessage struct {
+	ID         int64
+	Exchange   string
+	RoutingKey string
+	Payload    []byte
+	CreatedAt  time.Time
+	Attempts   int
+}
+
+type OutboxStats struct {
+	Pending         int
+	OldestPendingAt *time.Time
+}
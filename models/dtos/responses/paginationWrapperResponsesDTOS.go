@@ -1,6 +1,9 @@
 package responses
 
-type PaginationResponse[T any, U any] struct {
-	PaginationKey U
-	DataResponse  T
+type PaginationResponse[T any] struct {
+	DataResponse T
+	NextCursor   string
+	PrevCursor   string
+	HasMore      bool
+	PageSize     int
 }
@@ -4,17 +4,43 @@ import (
 	"time"
 )
 
+// What a post reviews. The Spotify ID of the subject is carried in SongID whatever its type
+type SubjectType string
+
+const (
+	TRACK  SubjectType = "TRACK"
+	ALBUM  SubjectType = "ALBUM"
+	ARTIST SubjectType = "ARTIST"
+)
+
+func IsValidSubjectType(subjectType SubjectType) bool {
+	return subjectType == TRACK || subjectType == ALBUM || subjectType == ARTIST
+}
+
+// SubjectName and ImageURI are set for every subject type. The song fields are only set for track posts, and the album
+// fields for track and album posts. Rating is nil when the post was reviewed without being rated. Relisten is set when
+// the user had listened to the subject before, and ListenedAt is when they listened to it this time
 type PostPreview struct {
-	UserIdentifer `mapstructure:",squash"`
-	SongID        string
-	SongName      string
-	AlbumName     string
-	AlbumArtURI   string
-	AlbumID       string
-	Rating        int
-	Text          string
-	Likes         []UserIdentifer
-	Dislikes      []UserIdentifer
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	UserIdentifer   `mapstructure:",squash"`
+	PostID          int64
+	Relisten        bool
+	ListenedAt      time.Time
+	SubjectType     SubjectType
+	SongID          string
+	SubjectName     string
+	ImageURI        string
+	SongName        string
+	AlbumName       string
+	AlbumArtURI     string
+	AlbumID         string
+	ReleaseDate     string
+	Artists         []Artist
+	DurationMs      int
+	Rating          *Rating `swaggertype:"number"`
+	Text            string
+	Likes           int
+	Dislikes        int
+	CurrentUserVote *Vote
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
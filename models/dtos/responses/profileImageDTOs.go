@@ -1,5 +1,9 @@
 package responses
 
+// Each size is a URL to its thumbnail. In the database and the user cache, they are the object keys of the thumbnails
+// instead, which are only turned into URLs when they are handed out
 type ProfileImage struct {
-    url string
+    Small  string
+    Medium string
+    Large  string
 }
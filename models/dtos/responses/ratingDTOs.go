@@ -0,0 +1,135 @@
+package responses
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// How many units of a Rating make up one star
+const RATING_PRECISION = 100
+
+// A number of stars kept as fixed-point hundredths of a star, so half stars and other steps are exact. It is a plain
+// number in JSON and a numeric(5,2) in the database
+type Rating int64
+
+func RatingFromFloat(stars float64) Rating {
+	return Rating(math.Round(stars * RATING_PRECISION))
+}
+
+func (r Rating) Float64() float64 {
+	return float64(r) / RATING_PRECISION
+}
+
+func (r Rating) String() string {
+	return strconv.FormatFloat(r.Float64(), 'f', -1, 64)
+}
+
+func (r Rating) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Rejects numbers more precise than a Rating can hold rather than rounding them
+func ParseRating(value string) (Rating, error) {
+
+	stars, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		return 0, err
+	}
+
+	rating := RatingFromFloat(stars)
+
+	if math.Abs(stars*RATING_PRECISION-float64(rating)) > 1e-6 {
+		return 0, fmt.Errorf("rating %s has more than two decimal places", value)
+	}
+
+	return rating, nil
+}
+
+func (r *Rating) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	rating, err := ParseRating(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	*r = rating
+
+	return nil
+}
+
+func (r Rating) Value() (driver.Value, error) {
+	return strconv.FormatFloat(r.Float64(), 'f', 2, 64), nil
+}
+
+func (r *Rating) Scan(src any) error {
+
+	switch value := src.(type) {
+	case []byte:
+		return r.scanString(string(value))
+	case string:
+		return r.scanString(value)
+	case int64:
+		*r = Rating(value * RATING_PRECISION)
+		return nil
+	case float64:
+		*r = RatingFromFloat(value)
+		return nil
+	}
+
+	return fmt.Errorf("cannot scan %T into a rating", src)
+}
+
+func (r *Rating) scanString(value string) error {
+
+	stars, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		return err
+	}
+
+	*r = RatingFromFloat(stars)
+
+	return nil
+}
+
+// The ratings posts accept, from Min to Max in multiples of Step
+type RatingScale struct {
+	Min  Rating
+	Max  Rating
+	Step Rating
+}
+
+// Every rating on the scale, in order
+func (s RatingScale) Ratings() []Rating {
+
+	ratings := []Rating{}
+
+	for rating := s.Min; rating <= s.Max; rating += s.Step {
+		ratings = append(ratings, rating)
+	}
+
+	return ratings
+}
+
+func (s RatingScale) Contains(rating Rating) bool {
+	return rating >= s.Min && rating <= s.Max && (rating-s.Min)%s.Step == 0
+}
+
+// The largest rating a numeric(5,2) holds
+const MAX_STORED_RATING Rating = 99999
+
+// Whether the scale has at least two ratings that can all be stored
+func (s RatingScale) IsValid() bool {
+	return s.Step > 0 && s.Min >= 0 && s.Max > s.Min && s.Max <= MAX_STORED_RATING && (s.Max-s.Min)%s.Step == 0
+}
+
+// Half stars from 0 to 5
+var DEFAULT_RATING_SCALE = RatingScale{Min: 0, Max: 5 * RATING_PRECISION, Step: RATING_PRECISION / 2}
@@ -0,0 +1,171 @@
+package responses
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRating(t *testing.T) {
+
+	tests := []struct {
+		value     string
+		want      Rating
+		wantError bool
+	}{
+		{value: "0", want: 0},
+		{value: "3", want: 300},
+		{value: "3.5", want: 350},
+		{value: "4.25", want: 425},
+		{value: "0.1", want: 10},
+		{value: "2.675", wantError: true},
+		{value: "1.001", wantError: true},
+		{value: "-1.5", want: -150},
+		{value: "", wantError: true},
+		{value: "four", wantError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+
+			rating, err := ParseRating(test.value)
+
+			if test.wantError {
+				if err == nil {
+					t.Fatalf("ParseRating = %v, want an error", rating)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rating != test.want {
+				t.Fatalf("ParseRating = %d, want %d", rating, test.want)
+			}
+		})
+	}
+}
+
+func TestRatingJSON(t *testing.T) {
+
+	tests := []struct {
+		json string
+		want Rating
+	}{
+		{json: "4.5", want: 450},
+		{json: "5", want: 500},
+		{json: "0.05", want: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+
+			rating := Rating(0)
+
+			if err := json.Unmarshal([]byte(test.json), &rating); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rating != test.want {
+				t.Fatalf("unmarshalled %d, want %d", rating, test.want)
+			}
+
+			marshalled, err := json.Marshal(rating)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(marshalled) != test.json {
+				t.Fatalf("marshalled %s, want %s", marshalled, test.json)
+			}
+		})
+	}
+}
+
+func TestRatingScan(t *testing.T) {
+
+	tests := []struct {
+		name string
+		src  any
+		want Rating
+	}{
+		{name: "numeric", src: []byte("3.50"), want: 350},
+		{name: "string", src: "0.25", want: 25},
+		{name: "integer", src: int64(4), want: 400},
+		{name: "float", src: 2.5, want: 250},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			rating := Rating(0)
+
+			if err := rating.Scan(test.src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rating != test.want {
+				t.Fatalf("scanned %d, want %d", rating, test.want)
+			}
+		})
+	}
+}
+
+func TestRatingScale(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		scale RatingScale
+		valid bool
+	}{
+		{name: "default", scale: DEFAULT_RATING_SCALE, valid: true},
+		{name: "whole stars out of ten", scale: RatingScale{Min: 100, Max: 1000, Step: 100}, valid: true},
+		{name: "zero step", scale: RatingScale{Min: 0, Max: 500, Step: 0}},
+		{name: "negative step", scale: RatingScale{Min: 0, Max: 500, Step: -50}},
+		{name: "negative min", scale: RatingScale{Min: -100, Max: 500, Step: 50}},
+		{name: "max equal to min", scale: RatingScale{Min: 500, Max: 500, Step: 50}},
+		{name: "max below min", scale: RatingScale{Min: 500, Max: 100, Step: 50}},
+		{name: "max too large to store", scale: RatingScale{Min: 0, Max: MAX_STORED_RATING + 1, Step: 1}},
+		{name: "step does not reach max", scale: RatingScale{Min: 0, Max: 500, Step: 30}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if valid := test.scale.IsValid(); valid != test.valid {
+				t.Fatalf("IsValid = %v, want %v", valid, test.valid)
+			}
+		})
+	}
+}
+
+func TestDefaultRatingScaleContains(t *testing.T) {
+
+	tests := []struct {
+		rating Rating
+		want   bool
+	}{
+		{rating: 0, want: true},
+		{rating: 50, want: true},
+		{rating: 350, want: true},
+		{rating: 500, want: true},
+		{rating: 25},
+		{rating: 499},
+		{rating: 550},
+		{rating: -50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rating.String(), func(t *testing.T) {
+			if got := DEFAULT_RATING_SCALE.Contains(test.rating); got != test.want {
+				t.Fatalf("Contains(%v) = %v, want %v", test.rating, got, test.want)
+			}
+		})
+	}
+
+	if ratings := DEFAULT_RATING_SCALE.Ratings(); len(ratings) != 11 || ratings[0] != 0 || ratings[10] != 500 {
+		t.Fatalf("Ratings = %v, want the 11 half stars from 0 to 5", ratings)
+	}
+}
@@ -0,0 +1,40 @@
+package responses
+
+type SearchType string
+
+const (
+	POST_SEARCH    SearchType = "posts"
+	COMMENT_SEARCH SearchType = "comments"
+	USER_SEARCH    SearchType = "users"
+)
+
+func IsValidSearchType(searchType SearchType) bool {
+	return searchType == POST_SEARCH || searchType == COMMENT_SEARCH || searchType == USER_SEARCH
+}
+
+type PostSearchResult struct {
+	PostPreview `mapstructure:",squash"`
+	Rank        float64
+	Snippet     string
+}
+
+type CommentSearchResult struct {
+	Comment `mapstructure:",squash"`
+	Rank    float64
+	Snippet string
+}
+
+type UserSearchResult struct {
+	UserIdentifer `mapstructure:",squash"`
+	Bio           string
+	ProfileImage  *ProfileImage
+	Rank          float64
+	Snippet       string
+}
+
+// Each section is only present when it was searched
+type SearchResults struct {
+	Posts    *PaginationResponse[[]PostSearchResult]
+	Comments *PaginationResponse[[]CommentSearchResult]
+	Users    *PaginationResponse[[]UserSearchResult]
+}
@@ -0,0 +1,35 @@
+package responses
+
+import "time"
+
+type SessionRevokedReason string
+
+const (
+	LOGGED_OUT            SessionRevokedReason = "LOGGED_OUT"
+	LOGGED_OUT_EVERYWHERE SessionRevokedReason = "LOGGED_OUT_EVERYWHERE"
+	REVOKED_BY_ADMIN      SessionRevokedReason = "REVOKED_BY_ADMIN"
+	// A refresh token was used after it had already been swapped for a new one, so one of the two is in the wrong hands
+	REFRESH_TOKEN_REUSED SessionRevokedReason = "REFRESH_TOKEN_REUSED"
+)
+
+// A session that has not been revoked or expired. Username and Role are read from the user on every lookup, so they are
+// always current
+type Session struct {
+	SessionID   string
+	SpotifyID   string
+	Username    string
+	Role        Role
+	CreatedAt   time.Time
+	RefreshedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// The Spotify tokens of a session, as stored. AccessToken and RefreshToken are encrypted with a data key, which is
+// stored as DataKey wrapped by the key encryption key KeyID
+type EncryptedSpotifyTokens struct {
+	KeyID                string
+	DataKey              []byte
+	AccessToken          []byte
+	RefreshToken         []byte
+	AccessTokenExpiresAt time.Time
+}
@@ -1,5 +1,7 @@
 package responses
 
+import "time"
+
 type AccessTokenResponnse struct {
 	Access_token  string
 	Token_type    string
@@ -19,18 +21,58 @@ type RefreshTokenResponse struct {
 	Refresh_token string
 }
 
+// Issued to the app rather than a user, through the client credentials flow
+type AppAccessTokenResponse struct {
+	Access_token string
+	Token_type   string
+	Expires_in   int
+}
+
 type SongResponse struct {
-	Id    string
-	Name  string
-	Album AlbumResponse
+	Id          string
+	Name        string
+	Duration_ms int
+	Album       AlbumResponse
+	Artists     []ArtistResponse
+}
+
+type TracksResponse struct {
+	Tracks []*SongResponse
 }
 
 type AlbumResponse struct {
+	Id                     string
+	Name                   string
+	Images                 []Images
+	Release_date           string
+	Release_date_precision string
+	Artists                []ArtistResponse
+}
+
+type AlbumsResponse struct {
+	Albums []*AlbumResponse
+}
+
+// Images are only included when the artist is fetched on its own, not as the artist of a track or album
+type ArtistResponse struct {
 	Id     string
 	Name   string
 	Images []Images
 }
 
+type ArtistsResponse struct {
+	Artists []*ArtistResponse
+}
+
+type RecentlyPlayedResponse struct {
+	Items []PlayHistoryResponse
+}
+
+type PlayHistoryResponse struct {
+	Track     SongResponse
+	Played_at time.Time
+}
+
 type Images struct {
 	Url string
 }
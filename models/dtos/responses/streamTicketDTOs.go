@@ -0,0 +1,9 @@
+package responses
+
+import "time"
+
+// Authenticates a single connection to /stream. It can only be used once, and only until ExpiresAt
+type StreamTicket struct {
+	Ticket    string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,31 @@
+package responses
+
+type RatingBucket struct {
+	Rating Rating `swaggertype:"number"`
+	Count  int
+}
+
+// ReviewCount counts every review and RatedCount only the ones with a rating. AverageRating is nil until the subject has
+// been rated. Histogram has a bucket for every rating on the scale, in order
+type RatingStats struct {
+	ReviewCount   int
+	RatedCount    int
+	AverageRating *float64
+	Histogram     []RatingBucket
+}
+
+type SongPage struct {
+	Track            Track
+	Stats            RatingStats
+	MostLikedReviews []PostPreview
+	FollowingReviews []PostPreview
+}
+
+// Stats covers reviews of the album itself and TrackStats reviews of its tracks. The reviews include both
+type AlbumPage struct {
+	Album            Album
+	Stats            RatingStats
+	TrackStats       RatingStats
+	MostLikedReviews []PostPreview
+	FollowingReviews []PostPreview
+}
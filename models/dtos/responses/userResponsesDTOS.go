@@ -17,6 +17,7 @@ type User struct {
     Bio           string
     Email         string
     Role          Role
+    ProfileImage  *ProfileImage
 
 }
 
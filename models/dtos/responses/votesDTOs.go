@@ -0,0 +1,24 @@
+package responses
+
+type Vote string
+
+const (
+	LIKE    Vote = "LIKE"
+	DISLIKE Vote = "DISLIKE"
+)
+
+func IsValidVote(vote Vote) bool {
+	return vote == LIKE || vote == DISLIKE
+}
+
+func VoteFromLiked(liked bool) Vote {
+	if liked {
+		return LIKE
+	}
+	return DISLIKE
+}
+
+type Voter struct {
+	UserIdentifer `mapstructure:",squash"`
+	Vote          Vote
+}
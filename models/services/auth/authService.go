@@ -1,25 +1,51 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
+	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/daos"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/cookies"
 	"github.com/Jack-Gitter/tunes/models/services/jwt"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
 	"github.com/Jack-Gitter/tunes/models/services/spotify"
+	"github.com/Jack-Gitter/tunes/models/services/storage"
+	"github.com/Jack-Gitter/tunes/models/services/tokenbroker"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Keys should be scheduled at least this far ahead, so every cached copy of the JWKS has them by the time they sign
+const JWKS_MAX_AGE = time.Hour
+
+var (
+	// Not http only, since the frontend reads the access JWT to put it in the Authorization header
+	ACCESS_JWT_COOKIE = cookies.Cookie{Name: "ACCESS_JWT"}
+	REFRESH_JWT_COOKIE = cookies.Cookie{Name: "REFRESH_JWT", HttpOnly: true}
 )
 
 type AuthService struct {
     DB *sql.DB
     UsersDAO daos.IUsersDAO
+    SessionsDAO daos.ISessionsDAO
     SpotifyService spotify.ISpotifyService
     JWTService jwt.IJWTService
+    TokenBroker tokenbroker.ITokenBroker
+    // Signs the login state and the cookie that goes with it
+    LoginStateSecret []byte
+    // Origins users can be sent back to once they have logged in
+    LoginRedirectAllowlist []string
+    CookiePolicy cookies.ICookiePolicy
+    // Closes the realtime connections of sessions as they are revoked
+    RealtimeService realtime.IRealtimeService
+    StorageService storage.IStorageService
 }
 
 type IAuthService interface {
@@ -28,22 +54,106 @@ type IAuthService interface {
     ValidateAdminUser(c *gin.Context) 
     Login(c *gin.Context) 
     LoginCallback(c *gin.Context) 
+    Logout(c *gin.Context)
+    LogoutEverywhere(c *gin.Context)
+    RevokeUserSessions(c *gin.Context)
+    GetJWKS(c *gin.Context)
 }
 
+// @Summary Starts a login with Spotify
+// @Description Sends the user to Spotify to log in, using a signed state parameter tied to a short lived cookie, and PKCE. Once they are logged in, users are sent to redirect, which has to be on one of the allowed origins. Without a redirect, the callback responds with the user instead
+// @Tags Auth
+// @Param redirect query string false "Where to send the user once they have logged in"
+// @Success 302
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /login/ [get]
 func(a *AuthService) Login(c *gin.Context) {
 
-	client_id := os.Getenv("CLIENT_ID")
-	scope := os.Getenv("SCOPES")
-	redirect_uri := os.Getenv("REDIRECT_URI")
+	redirect := c.Query("redirect")
+
+	if redirect != "" && !isAllowedRedirect(a.LoginRedirectAllowlist, redirect) {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "redirect is not on an allowed origin"})
+		c.Abort()
+		return
+	}
 
-	endpoint := fmt.Sprintf("https://accounts.spotify.com/authorize?response_type=code&client_id=%s&scope=%s&redirect_uri=%s", client_id, scope, redirect_uri)
+	attempt, err := newLoginAttempt(redirect)
 
-	c.Redirect(http.StatusMovedPermanently, endpoint)
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	state, err := encodeSigned(a.LoginStateSecret, "state", loginState{Nonce: attempt.Nonce, ExpiresAt: attempt.ExpiresAt})
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	cookie, err := encodeSigned(a.LoginStateSecret, LOGIN_ATTEMPT_PURPOSE, attempt)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	a.CookiePolicy.Set(c, LOGIN_ATTEMPT_COOKIE, cookie, attempt.ExpiresAt)
+
+	// A 302 rather than a 301, since browsers cache a 301 and would skip straight to Spotify with a stale state
+	c.Redirect(http.StatusFound, a.SpotifyService.AuthorizeURL(state, attempt.codeChallenge()))
 }
 
+// @Summary Finishes a login with Spotify
+// @Description Spotify sends users here once they have logged in. The state has to match the cookie set by /login/, so a login cannot be finished by a browser that did not start it. Sets the JWT cookies, then sends the user to the redirect given to /login/, or responds with the user when there was none. When the user did not log in, they are sent to the redirect with Spotify's error as the error query parameter
+// @Tags Auth
+// @Param code query string false "Authorization code from Spotify"
+// @Param state query string true "State handed to Spotify by /login/"
+// @Param error query string false "Why Spotify did not log the user in"
+// @Success 200 {object} responses.User
+// @Success 303
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Failure 502 {string} string
+// @Router /login/callback [get]
 func(a *AuthService) LoginCallback(c *gin.Context) {
 
-	accessTokenResponse, err := a.SpotifyService.RetrieveInitialAccessToken(c.Query("code"))
+	cookie, err := a.CookiePolicy.Get(c, LOGIN_ATTEMPT_COOKIE)
+
+	if err != nil {
+		c.Error(invalidLoginAttemptError)
+		c.Abort()
+		return
+	}
+
+	// A login attempt can only be finished once
+	a.CookiePolicy.Clear(c, LOGIN_ATTEMPT_COOKIE)
+
+	attempt, err := verifyLoginAttempt(a.LoginStateSecret, cookie, c.Query("state"))
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	if loginError := c.Query("error"); loginError != "" {
+		if attempt.Redirect != "" {
+			c.Redirect(http.StatusSeeOther, withLoginError(attempt.Redirect, loginError))
+			return
+		}
+
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: fmt.Sprintf("spotify did not log the user in: %s", loginError)})
+		c.Abort()
+		return
+	}
+
+	accessTokenResponse, err := a.SpotifyService.RetrieveInitialAccessToken(c.Request.Context(), c.Query("code"), attempt.CodeVerifier)
 
 	if err != nil {
 		c.Error(err)
@@ -51,7 +161,7 @@ func(a *AuthService) LoginCallback(c *gin.Context) {
 		return
 	}
 
-	userProfileResponse, err := a.SpotifyService.RetrieveUserProfile(accessTokenResponse.Access_token)
+	userProfileResponse, err := a.SpotifyService.RetrieveUserProfile(c.Request.Context(), accessTokenResponse.Access_token)
 
 	if err != nil {
 		c.Error(err)
@@ -67,12 +177,23 @@ func(a *AuthService) LoginCallback(c *gin.Context) {
 		return
 	}
 
-	tokenString, err := a.JWTService.CreateAccessJWT(
-		userProfileResponse.Id,
-		userProfileResponse.Display_name,
-		accessTokenResponse.Access_token,
-		accessTokenResponse.Expires_in,
-		user.Role)
+	sessionID := uuid.NewString()
+	refreshTokenID := uuid.NewString()
+	sessionExpiresAt := time.Now().Add(jwt.REFRESH_JWT_TTL)
+
+	spotifyTokens, err := a.TokenBroker.SealTokens(sessionID, &tokenbroker.SpotifyTokens{
+		AccessToken:          accessTokenResponse.Access_token,
+		RefreshToken:         accessTokenResponse.Refresh_token,
+		AccessTokenExpiresAt: time.Now().Add(time.Duration(accessTokenResponse.Expires_in) * time.Second),
+	})
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	err = a.SessionsDAO.CreateSession(a.DB, sessionID, user.SpotifyID, refreshTokenID, sessionExpiresAt, spotifyTokens)
 
 	if err != nil {
 		c.Error(err)
@@ -80,7 +201,7 @@ func(a *AuthService) LoginCallback(c *gin.Context) {
 		return
 	}
 
-	refreshString, err := a.JWTService.CreateRefreshJWT(accessTokenResponse.Refresh_token)
+	tokenString, err := a.JWTService.CreateAccessJWT(sessionID, userProfileResponse.Id, userProfileResponse.Display_name)
 
 	if err != nil {
 		c.Error(err)
@@ -88,31 +209,50 @@ func(a *AuthService) LoginCallback(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("ACCESS_JWT", tokenString, 3600, "/", "localhost", false, false)
-	c.SetCookie("REFRESH_JWT", refreshString, 86400, "/", "localhost", false, true)
+	refreshString, err := a.JWTService.CreateRefreshJWT(sessionID, user.SpotifyID, refreshTokenID, sessionExpiresAt)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	a.CookiePolicy.Set(c, ACCESS_JWT_COOKIE, tokenString, time.Now().Add(jwt.ACCESS_JWT_TTL))
+	a.CookiePolicy.Set(c, REFRESH_JWT_COOKIE, refreshString, sessionExpiresAt)
+
+	if attempt.Redirect != "" {
+		c.Redirect(http.StatusSeeOther, attempt.Redirect)
+		return
+	}
+
+	user.ProfileImage, err = storage.ProfileImageURLs(c.Request.Context(), a.StorageService, user.ProfileImage)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
 
 	c.JSON(http.StatusOK, user)
 }
 
 // @Summary Refreshes the current users JWT
-// @Description Refreshes the current users JWT
-// @Tags Users
+// @Description Issues a new access JWT along with a new refresh JWT, and extends the session. Each refresh JWT can only be used once. Using one again revokes the session it belongs to, since it means someone else has a copy of it. The users Spotify tokens stay on the session, and are refreshed separately whenever they are needed
+// @Tags Auth
 // @Accept json
 // @Produce json
-// @Param Cookie header string false "refresh JWT provided by login endpoint REFRESH_JWT=..."
-// @Param spotifyID path string true "User Spotify ID"
-// @Success 200 {object} responses.User 
+// @Param Cookie header string false "refresh JWT provided by login endpoint REFRESH_JWT=..., or __Host-REFRESH_JWT=... when cookies are prefixed"
+// @Success 204
 // @Failure 400 {string} string 
-// @Failure 404 {string} string 
+// @Failure 401 {string} string 
 // @Failure 500 {string} string 
-// @Router /users/{spotifyID} [get]
-// @Security Bearer
+// @Router /login/jwt [get]
 func(a *AuthService) RefreshJWT(c *gin.Context) {
 
-	refresh_jwt, err := c.Cookie("REFRESH_JWT")
+	refresh_jwt, err := a.CookiePolicy.Get(c, REFRESH_JWT_COOKIE)
 
 	if err != nil {
-		c.Error(err)
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "missing refresh JWT cookie"})
 		c.Abort()
 		return
 	}
@@ -125,8 +265,72 @@ func(a *AuthService) RefreshJWT(c *gin.Context) {
 		return
 	}
 
-	spotifyRefreshToken := refresh_token.Claims.(*requests.RefreshJWTClaims).RefreshToken
-	accessTokenResponseBody, err := a.SpotifyService.RetreiveAccessTokenFromRefreshToken(spotifyRefreshToken)
+	refreshClaims := refresh_token.Claims.(*requests.RefreshJWTClaims)
+
+	// Refresh JWTs from before sessions were introduced have no session to rotate
+	if refreshClaims.SessionID == "" {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "session has expired, log in again"})
+		c.Abort()
+		return
+	}
+
+	newRefreshTokenID := uuid.NewString()
+	sessionExpiresAt := time.Now().Add(jwt.REFRESH_JWT_TTL)
+	session := &responses.Session{}
+	reused := false
+
+	transaction := func() error {
+
+		tx, err := a.DB.BeginTx(context.Background(), nil)
+
+		if err != nil {
+			return customerrors.WrapBasicError(err)
+		}
+
+		defer tx.Rollback()
+
+		session, err = a.SessionsDAO.RotateRefreshToken(tx, refreshClaims.SessionID, refreshClaims.ID, newRefreshTokenID, sessionExpiresAt)
+
+		if isNotFound(err) {
+			err = a.SessionsDAO.RevokeSession(tx, refreshClaims.SessionID, responses.REFRESH_TOKEN_REUSED)
+
+			if err != nil {
+				return err
+			}
+
+			err = tx.Commit()
+
+			if err != nil {
+				return customerrors.WrapBasicError(err)
+			}
+
+			reused = true
+
+			return &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "refresh token is no longer valid, log in again"}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if session.SpotifyID != refreshClaims.Subject {
+			return &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "refresh token was not issued for this session"}
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			return customerrors.WrapBasicError(err)
+		}
+
+		return nil
+	}
+
+	err = db.RunTransactionWithExponentialBackoff(transaction, 5)
+
+	if reused {
+		a.RealtimeService.DisconnectSessions(refreshClaims.Subject, refreshClaims.SessionID)
+	}
 
 	if err != nil {
 		c.Error(err)
@@ -134,11 +338,15 @@ func(a *AuthService) RefreshJWT(c *gin.Context) {
 		return
 	}
 
-	if accessTokenResponseBody.Refresh_token == "" {
-		accessTokenResponseBody.Refresh_token = spotifyRefreshToken
+	accessTokenJWT, err := a.JWTService.CreateAccessJWT(session.SessionID, session.SpotifyID, session.Username)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
-	userProfileResponse, err := a.SpotifyService.RetrieveUserProfile(accessTokenResponseBody.Access_token)
+	refreshString, err := a.JWTService.CreateRefreshJWT(session.SessionID, session.SpotifyID, newRefreshTokenID, sessionExpiresAt)
 
 	if err != nil {
 		c.Error(err)
@@ -146,7 +354,33 @@ func(a *AuthService) RefreshJWT(c *gin.Context) {
 		return
 	}
 
-	userDBResponse, err := a.UsersDAO.GetUser(a.DB, userProfileResponse.Id)
+	a.CookiePolicy.Set(c, ACCESS_JWT_COOKIE, accessTokenJWT, time.Now().Add(jwt.ACCESS_JWT_TTL))
+	a.CookiePolicy.Set(c, REFRESH_JWT_COOKIE, refreshString, sessionExpiresAt)
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Logs the current user out
+// @Description Revokes the session the access JWT belongs to, so neither its access JWTs nor its refresh JWT can be used again, and clears the JWT cookies
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /logout [post]
+// @Security Bearer
+func(a *AuthService) Logout(c *gin.Context) {
+
+	sessionID, found := c.Get("sessionID")
+
+	if !found {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+		c.Abort()
+		return
+	}
+
+	err := a.SessionsDAO.RevokeSession(a.DB, sessionID.(string), responses.LOGGED_OUT)
 
 	if err != nil {
 		c.Error(err)
@@ -154,13 +388,34 @@ func(a *AuthService) RefreshJWT(c *gin.Context) {
 		return
 	}
 
-	accessTokenJWT, err := a.JWTService.CreateAccessJWT(
-		userProfileResponse.Id,
-		userProfileResponse.Display_name,
-		accessTokenResponseBody.Access_token,
-		accessTokenResponseBody.Expires_in,
-		userDBResponse.Role,
-	)
+	a.RealtimeService.DisconnectSessions(c.GetString("spotifyID"), sessionID.(string))
+
+	a.clearJWTCookies(c)
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Logs the current user out everywhere
+// @Description Revokes every session of the current user, including the one making the request, and clears the JWT cookies
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /logout/everywhere [post]
+// @Security Bearer
+func(a *AuthService) LogoutEverywhere(c *gin.Context) {
+
+	spotifyID, found := c.Get("spotifyID")
+
+	if !found {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+		c.Abort()
+		return
+	}
+
+	err := a.SessionsDAO.RevokeUserSessions(a.DB, spotifyID.(string), responses.LOGGED_OUT_EVERYWHERE)
 
 	if err != nil {
 		c.Error(err)
@@ -168,7 +423,47 @@ func(a *AuthService) RefreshJWT(c *gin.Context) {
 		return
 	}
 
-	c.SetCookie("ACCESS_JWT", accessTokenJWT, 3600, "/", "localhost", false, false)
+	a.RealtimeService.DisconnectSessions(spotifyID.(string))
+
+	a.clearJWTCookies(c)
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Revokes every session of a user. Only accessible to admins
+// @Description Revokes every session of a user, which logs them out everywhere. They can log in again afterwards
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param spotifyID path string true "The spotify ID of the user"
+// @Success 204
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /users/admin/{spotifyID}/sessions [delete]
+// @Security Bearer
+func(a *AuthService) RevokeUserSessions(c *gin.Context) {
+
+	spotifyID := c.Param("spotifyID")
+
+	_, err := a.UsersDAO.GetUser(a.DB, spotifyID)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	err = a.SessionsDAO.RevokeUserSessions(a.DB, spotifyID, responses.REVOKED_BY_ADMIN)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	a.RealtimeService.DisconnectSessions(spotifyID)
 
 	c.Status(http.StatusNoContent)
 }
@@ -198,19 +493,50 @@ func(a *AuthService) ValidateUserJWT(c *gin.Context) {
 		return
 	}
 
-	spotifyID := token.Claims.(*requests.JWTClaims).SpotifyID
-	spotifyAccessToken := token.Claims.(*requests.JWTClaims).AccessToken
-	role := token.Claims.(*requests.JWTClaims).UserRole
-	username := token.Claims.(*requests.JWTClaims).Username
+	claims := token.Claims.(*requests.JWTClaims)
 
-	c.Set("spotifyID", spotifyID)
-	c.Set("userRole", role)
-	c.Set("spotifyUsername", username)
-	c.Set("spotifyAccessToken", spotifyAccessToken)
+	if claims.SessionID == "" {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "session has expired, log in again"})
+		c.Abort()
+		return
+	}
+
+	// The role is read from the session rather than the JWT, so a change of role applies straight away
+	session, err := a.SessionsDAO.GetActiveSession(a.DB, claims.SessionID)
+
+	if isNotFound(err) || err == nil && session.SpotifyID != claims.Subject {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "session has been revoked or has expired, log in again"})
+		c.Abort()
+		return
+	}
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	c.Set("sessionID", session.SessionID)
+	c.Set("spotifyID", session.SpotifyID)
+	c.Set("userRole", session.Role)
+	c.Set("spotifyUsername", session.Username)
+	// Connections to /stream are closed once the access JWT they were opened with expires
+	c.Set("accessTokenExpiresAt", claims.ExpiresAt.Time)
 
 	c.Next()
 }
 
+// @Summary Gets the public keys JWTs are signed with
+// @Description Gets every key JWTs can be signed with as a JSON Web Key Set, including keys scheduled to start signing later, so other services can verify JWTs themselves. JWTs name the key that signed them in their kid header
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} responses.JWKS
+// @Router /.well-known/jwks.json [get]
+func(a *AuthService) GetJWKS(c *gin.Context) {
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(JWKS_MAX_AGE.Seconds())))
+	c.JSON(http.StatusOK, a.JWTService.GetJWKS())
+}
 
 func(a *AuthService) ValidateAdminUser(c *gin.Context) {
 
@@ -232,3 +558,13 @@ func(a *AuthService) ValidateAdminUser(c *gin.Context) {
 	return
 
 }
+
+func(a *AuthService) clearJWTCookies(c *gin.Context) {
+	a.CookiePolicy.Clear(c, ACCESS_JWT_COOKIE)
+	a.CookiePolicy.Clear(c, REFRESH_JWT_COOKIE)
+}
+
+func isNotFound(err error) bool {
+    customError, ok := err.(*customerrors.CustomError)
+    return ok && customError.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/services/cookies"
+)
+
+const (
+	// How long the user has to get through Spotify's consent screen
+	LOGIN_ATTEMPT_TTL = 10 * time.Minute
+	LOGIN_ATTEMPT_PURPOSE = "login attempt"
+)
+
+// Always Lax, so the cookie comes along when Spotify sends the user back even when the policy is Strict
+var LOGIN_ATTEMPT_COOKIE = cookies.Cookie{Name: "LOGIN_ATTEMPT", Path: "/login", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+
+var invalidLoginAttemptError = &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "login could not be verified, try logging in again"}
+
+// Everything the callback needs to finish a login, kept in a signed cookie on the browser that started it. Only the
+// nonce goes through Spotify, as the state parameter, so a callback can only be finished by the browser that started
+// the login
+type loginAttempt struct {
+    Nonce string
+    CodeVerifier string
+    Redirect string
+    ExpiresAt time.Time
+}
+
+// The state parameter. It is signed as well, so it cannot be swapped for another nonce without the cookie too
+type loginState struct {
+    Nonce string
+    ExpiresAt time.Time
+}
+
+func newLoginAttempt(redirect string) (*loginAttempt, error) {
+
+    nonce, err := randomString()
+
+    if err != nil {
+        return nil, err
+    }
+
+    codeVerifier, err := randomString()
+
+    if err != nil {
+        return nil, err
+    }
+
+    return &loginAttempt{Nonce: nonce, CodeVerifier: codeVerifier, Redirect: redirect, ExpiresAt: time.Now().Add(LOGIN_ATTEMPT_TTL)}, nil
+}
+
+// The PKCE code challenge for the attempts code verifier, using the S256 method
+func(l *loginAttempt) codeChallenge() string {
+    hash := sha256.Sum256([]byte(l.CodeVerifier))
+    return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// Reads the attempt from its cookie and checks it against the state Spotify handed back
+func verifyLoginAttempt(secret []byte, cookie string, state string) (*loginAttempt, error) {
+
+    attempt := &loginAttempt{}
+    err := decodeSigned(secret, LOGIN_ATTEMPT_PURPOSE, cookie, attempt)
+
+    if err != nil {
+        return nil, err
+    }
+
+    stateClaims := &loginState{}
+    err = decodeSigned(secret, "state", state, stateClaims)
+
+    if err != nil {
+        return nil, err
+    }
+
+    if !hmac.Equal([]byte(attempt.Nonce), []byte(stateClaims.Nonce)) || time.Now().After(attempt.ExpiresAt) || time.Now().After(stateClaims.ExpiresAt) {
+        return nil, invalidLoginAttemptError
+    }
+
+    return attempt, nil
+}
+
+// purpose is signed along with the payload, so a value signed for one purpose cannot be passed off as another
+func encodeSigned(secret []byte, purpose string, value any) (string, error) {
+
+    payload, err := json.Marshal(value)
+
+    if err != nil {
+        return "", customerrors.WrapBasicError(err)
+    }
+
+    encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+    signature := base64.RawURLEncoding.EncodeToString(sign(secret, purpose, encodedPayload))
+
+    return fmt.Sprintf("%s.%s", encodedPayload, signature), nil
+}
+
+func decodeSigned(secret []byte, purpose string, encoded string, value any) error {
+
+    encodedPayload, encodedSignature, found := strings.Cut(encoded, ".")
+
+    if !found {
+        return invalidLoginAttemptError
+    }
+
+    signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+
+    if err != nil || !hmac.Equal(signature, sign(secret, purpose, encodedPayload)) {
+        return invalidLoginAttemptError
+    }
+
+    payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+    if err != nil {
+        return invalidLoginAttemptError
+    }
+
+    err = json.Unmarshal(payload, value)
+
+    if err != nil {
+        return invalidLoginAttemptError
+    }
+
+    return nil
+}
+
+func sign(secret []byte, purpose string, payload string) []byte {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(purpose + "." + payload))
+    return mac.Sum(nil)
+}
+
+// 32 random bytes, which also makes a code verifier of the 43 characters PKCE asks for
+func randomString() (string, error) {
+
+    bytes := make([]byte, 32)
+
+    _, err := rand.Read(bytes)
+
+    if err != nil {
+        return "", customerrors.WrapBasicError(err)
+    }
+
+    return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Parses LOGIN_REDIRECT_ALLOWLIST, a comma separated list of origins like https://tunes.app that users can be sent back
+// to once they have logged in
+func ParseRedirectAllowlist(allowlist string) ([]string, error) {
+
+    origins := []string{}
+
+    for _, entry := range strings.Split(allowlist, ",") {
+        entry = strings.TrimSpace(entry)
+
+        if entry == "" {
+            continue
+        }
+
+        origin, ok := originOf(entry)
+
+        if !ok {
+            return nil, fmt.Errorf("login redirect %q must be an http or https origin", entry)
+        }
+
+        origins = append(origins, origin)
+    }
+
+    return origins, nil
+}
+
+// A redirect is allowed when it is an absolute http or https URL on one of the allowed origins
+func isAllowedRedirect(allowlist []string, redirect string) bool {
+
+    origin, ok := originOf(redirect)
+
+    if !ok {
+        return false
+    }
+
+    for _, allowed := range allowlist {
+        if origin == allowed {
+            return true
+        }
+    }
+
+    return false
+}
+
+func originOf(rawURL string) (string, bool) {
+
+    parsed, err := url.Parse(rawURL)
+
+    if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+        return "", false
+    }
+
+    return strings.ToLower(parsed.Scheme + "://" + parsed.Host), true
+}
+
+// Appends the error Spotify sent back to the redirect, so the frontend can tell the user why they are not logged in
+func withLoginError(redirect string, loginError string) string {
+
+    parsed, err := url.Parse(redirect)
+
+    if err != nil {
+        return redirect
+    }
+
+    query := parsed.Query()
+    query.Set("error", loginError)
+    parsed.RawQuery = query.Encode()
+
+    return parsed.String()
+}
@@ -20,6 +20,18 @@ type UserCacheKey struct {
     SpotifyID string
 }
 
+type TrackCacheKey struct {
+    TrackID string
+}
+
+type AlbumCacheKey struct {
+    AlbumID string
+}
+
+type ArtistCacheKey struct {
+    ArtistID string
+}
+
 type CacheService struct {
     Redis *redis.Client
     CTX context.Context
@@ -89,6 +101,15 @@ func(c *CacheService) GenerateKey(t reflect.Type, v any) (string, error) {
         case reflect.TypeOf(responses.User{}): 
             user := v.(UserCacheKey)
             return user.SpotifyID, nil
+        case reflect.TypeOf(responses.Track{}):
+            track := v.(TrackCacheKey)
+            return fmt.Sprintf("catalog:track:%s", track.TrackID), nil
+        case reflect.TypeOf(responses.Album{}):
+            album := v.(AlbumCacheKey)
+            return fmt.Sprintf("catalog:album:%s", album.AlbumID), nil
+        case reflect.TypeOf(responses.Artist{}):
+            artist := v.(ArtistCacheKey)
+            return fmt.Sprintf("catalog:artist:%s", artist.ArtistID), nil
         case reflect.TypeOf(responses.UserIdentifer{}):
             return "", &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "caching user ids is not supported"}
         case reflect.TypeOf(responses.PostPreview{}):
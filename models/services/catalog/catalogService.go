@@ -0,0 +1,524 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/cache"
+	"github.com/Jack-Gitter/tunes/models/services/spotify"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DEFAULT_TTL = 7 * 24 * time.Hour
+	DEFAULT_CACHE_TTL = time.Hour
+	DEFAULT_REFRESH_INTERVAL = time.Hour
+	// Caps how much of the catalog one refresh gets through, so a large backlog is spread over several refreshes
+	MAX_REFRESH_BATCHES = 20
+)
+
+// Keeps a local copy of the tracks, albums and artists that are posted about. Each is read through Redis, then the
+// catalog tables, and only fetched from Spotify when it is missing or older than TTL. Run refreshes stale subjects in
+// the background, so changes like new album art show up on every post without the posts being rewritten
+type CatalogService struct {
+    DB *sql.DB
+    CatalogDAO daos.ICatalogDAO
+    SpotifyService spotify.ISpotifyService
+    CacheService cache.ICacheService
+    TTL time.Duration
+    CacheTTL time.Duration
+    RefreshInterval time.Duration
+
+    mu sync.Mutex
+    appAccessToken string
+    appAccessTokenExpiresAt time.Time
+}
+
+type ICatalogService interface {
+    GetTrack(ctx context.Context, trackID string, spotifyAccessToken string) (*responses.Track, error)
+    GetAlbum(ctx context.Context, albumID string, spotifyAccessToken string) (*responses.Album, error)
+    GetArtist(ctx context.Context, artistID string, spotifyAccessToken string) (*responses.Artist, error)
+    GetSubject(ctx context.Context, subjectType responses.SubjectType, subjectID string, spotifyAccessToken string) error
+    StoreTracks(songResponses []responses.SongResponse) error
+    Run(ctx context.Context)
+}
+
+// How many of each type of subject are refreshed with one call to Spotify
+var refreshBatchSizes = map[responses.SubjectType]int{
+    responses.TRACK: spotify.MAX_TRACKS_PER_REQUEST,
+    responses.ALBUM: spotify.MAX_ALBUMS_PER_REQUEST,
+    responses.ARTIST: spotify.MAX_ARTISTS_PER_REQUEST,
+}
+
+// Returns a 404 if Spotify does not know of the track. If Spotify cannot be reached, a stale copy is returned rather
+// than failing
+func(cs *CatalogService) GetTrack(ctx context.Context, trackID string, spotifyAccessToken string) (*responses.Track, error) {
+
+    key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Track{}), cache.TrackCacheKey{TrackID: trackID})
+
+    if err != nil {
+        return nil, err
+    }
+
+    return readThrough(cs, key, trackID,
+        func() (*responses.Track, error) { return cs.CatalogDAO.GetTrack(cs.DB, trackID) },
+        func(track *responses.Track) time.Time { return track.FetchedAt },
+        func() (*responses.Track, error) {
+            songResponse, err := cs.SpotifyService.GetSongDetailsFromSpotify(ctx, trackID, spotifyAccessToken)
+            if err != nil {
+                return nil, err
+            }
+            return trackFromSpotify(songResponse, time.Now().UTC()), nil
+        },
+        func(executor db.QueryExecutor, track *responses.Track) error { return cs.CatalogDAO.UpsertTrack(executor, track) },
+    )
+}
+
+// Returns a 404 if Spotify does not know of the album. If Spotify cannot be reached, a stale copy is returned rather
+// than failing
+func(cs *CatalogService) GetAlbum(ctx context.Context, albumID string, spotifyAccessToken string) (*responses.Album, error) {
+
+    key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Album{}), cache.AlbumCacheKey{AlbumID: albumID})
+
+    if err != nil {
+        return nil, err
+    }
+
+    return readThrough(cs, key, albumID,
+        func() (*responses.Album, error) { return cs.CatalogDAO.GetAlbum(cs.DB, albumID) },
+        func(album *responses.Album) time.Time { return album.FetchedAt },
+        func() (*responses.Album, error) {
+            albumResponse, err := cs.SpotifyService.GetAlbumDetailsFromSpotify(ctx, albumID, spotifyAccessToken)
+            if err != nil {
+                return nil, err
+            }
+            return albumFromSpotify(albumResponse, time.Now().UTC()), nil
+        },
+        func(executor db.QueryExecutor, album *responses.Album) error { return cs.CatalogDAO.UpsertAlbum(executor, album) },
+    )
+}
+
+// Returns a 404 if Spotify does not know of the artist. If Spotify cannot be reached, a stale copy is returned rather
+// than failing
+func(cs *CatalogService) GetArtist(ctx context.Context, artistID string, spotifyAccessToken string) (*responses.Artist, error) {
+
+    key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Artist{}), cache.ArtistCacheKey{ArtistID: artistID})
+
+    if err != nil {
+        return nil, err
+    }
+
+    return readThrough(cs, key, artistID,
+        func() (*responses.Artist, error) { return cs.CatalogDAO.GetArtist(cs.DB, artistID) },
+        func(artist *responses.Artist) time.Time { return artist.FetchedAt },
+        func() (*responses.Artist, error) {
+            artistResponse, err := cs.SpotifyService.GetArtistDetailsFromSpotify(ctx, artistID, spotifyAccessToken)
+            if err != nil {
+                return nil, err
+            }
+            return artistFromSpotify(artistResponse, time.Now().UTC()), nil
+        },
+        func(executor db.QueryExecutor, artist *responses.Artist) error { return cs.CatalogDAO.UpsertArtist(executor, artist) },
+    )
+}
+
+// Makes sure the subject of a post is in the catalog, whatever its type
+func(cs *CatalogService) GetSubject(ctx context.Context, subjectType responses.SubjectType, subjectID string, spotifyAccessToken string) error {
+
+    var err error
+
+    switch subjectType {
+    case responses.TRACK:
+        _, err = cs.GetTrack(ctx, subjectID, spotifyAccessToken)
+    case responses.ALBUM:
+        _, err = cs.GetAlbum(ctx, subjectID, spotifyAccessToken)
+    case responses.ARTIST:
+        _, err = cs.GetArtist(ctx, subjectID, spotifyAccessToken)
+    default:
+        err = &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "subjectType must be TRACK, ALBUM or ARTIST"}
+    }
+
+    return err
+}
+
+// Stores tracks that Spotify already returned in full as part of another response, e.g. a users recently played
+// tracks, so they are not fetched again one at a time
+func(cs *CatalogService) StoreTracks(songResponses []responses.SongResponse) error {
+
+    fetchedAt := time.Now().UTC()
+    tracks := []*responses.Track{}
+    cacheKeys := []string{}
+
+    for i := range songResponses {
+        track := trackFromSpotify(&songResponses[i], fetchedAt)
+        tracks = append(tracks, track)
+
+        key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Track{}), cache.TrackCacheKey{TrackID: track.TrackID})
+
+        if err != nil {
+            return err
+        }
+
+        cacheKeys = append(cacheKeys, key)
+    }
+
+    err := cs.inTransaction(func(tx *sql.Tx) error {
+        for _, track := range tracks {
+            err := cs.CatalogDAO.UpsertTrack(tx, track)
+
+            if err != nil {
+                return err
+            }
+        }
+
+        return nil
+    })
+
+    if err != nil {
+        return err
+    }
+
+    for _, key := range cacheKeys {
+        err = cs.CacheService.Delete(key)
+
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// Refreshes stale subjects until ctx is cancelled. Meant to be run in its own goroutine
+func(cs *CatalogService) Run(ctx context.Context) {
+
+    interval := cs.RefreshInterval
+
+    if interval <= 0 {
+        interval = DEFAULT_REFRESH_INTERVAL
+    }
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        for _, subjectType := range []responses.SubjectType{responses.TRACK, responses.ALBUM, responses.ARTIST} {
+            for range MAX_REFRESH_BATCHES {
+                refreshed, err := cs.refreshBatch(ctx, subjectType)
+
+                if err != nil {
+                    log.Printf("catalog: failed to refresh %s subjects: %s", subjectType, err.Error())
+                }
+
+                if err != nil || refreshed < refreshBatchSizes[subjectType] {
+                    break
+                }
+            }
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Serves key from Redis, then the catalog tables, then fetch. Whatever is fetched is stored and cached. If fetch fails
+// for any reason other than the subject not existing, the stale stored copy is served instead
+func readThrough[T any](cs *CatalogService, key string, id string, stored func() (*T, error), fetchedAt func(*T) time.Time, fetch func() (*T, error), upsert func(db.QueryExecutor, *T) error) (*T, error) {
+
+    cachedBytes, err := cs.CacheService.Get(key)
+
+    if err == nil {
+        cached := new(T)
+        err = gob.NewDecoder(bytes.NewReader(cachedBytes)).Decode(cached)
+
+        if err == nil {
+            return cached, nil
+        }
+    } else if !errors.Is(err, redis.Nil) {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    storedCopy, err := stored()
+
+    if err != nil && !isNotFound(err) {
+        return nil, err
+    }
+
+    if storedCopy != nil && time.Since(fetchedAt(storedCopy)) < cs.ttl() {
+        return storedCopy, cs.cache(key, *storedCopy)
+    }
+
+    fetched, err := fetch()
+
+    if err != nil {
+        if storedCopy != nil && !isNotFound(err) {
+            log.Printf("catalog: serving stale copy of %s, spotify failed: %s", id, err.Error())
+            return storedCopy, nil
+        }
+        return nil, err
+    }
+
+    err = cs.inTransaction(func(tx *sql.Tx) error { return upsert(tx, fetched) })
+
+    if err != nil {
+        return nil, err
+    }
+
+    return fetched, cs.cache(key, *fetched)
+}
+
+// Refetches the stalest subjects of the type in one call to Spotify. Returns how many were due
+func(cs *CatalogService) refreshBatch(ctx context.Context, subjectType responses.SubjectType) (int, error) {
+
+    subjectIDs, err := cs.CatalogDAO.GetStaleSubjectIDs(cs.DB, subjectType, time.Now().UTC().Add(-cs.ttl()), refreshBatchSizes[subjectType])
+
+    if err != nil || len(subjectIDs) < 1 {
+        return 0, err
+    }
+
+    accessToken, err := cs.appToken(ctx)
+
+    if err != nil {
+        return 0, err
+    }
+
+    fetchedAt := time.Now().UTC()
+    upserts := []func(tx *sql.Tx) error{}
+    cacheKeys := []string{}
+
+    switch subjectType {
+    case responses.TRACK:
+        songResponses, err := cs.SpotifyService.GetTracksFromSpotify(ctx, subjectIDs, accessToken)
+
+        if err != nil {
+            return 0, err
+        }
+
+        for i := range songResponses {
+            track := trackFromSpotify(&songResponses[i], fetchedAt)
+            upserts = append(upserts, func(tx *sql.Tx) error { return cs.CatalogDAO.UpsertTrack(tx, track) })
+
+            key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Track{}), cache.TrackCacheKey{TrackID: track.TrackID})
+
+            if err != nil {
+                return 0, err
+            }
+
+            cacheKeys = append(cacheKeys, key)
+        }
+    case responses.ALBUM:
+        albumResponses, err := cs.SpotifyService.GetAlbumsFromSpotify(ctx, subjectIDs, accessToken)
+
+        if err != nil {
+            return 0, err
+        }
+
+        for i := range albumResponses {
+            album := albumFromSpotify(&albumResponses[i], fetchedAt)
+            upserts = append(upserts, func(tx *sql.Tx) error { return cs.CatalogDAO.UpsertAlbum(tx, album) })
+
+            key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Album{}), cache.AlbumCacheKey{AlbumID: album.AlbumID})
+
+            if err != nil {
+                return 0, err
+            }
+
+            cacheKeys = append(cacheKeys, key)
+        }
+    case responses.ARTIST:
+        artistResponses, err := cs.SpotifyService.GetArtistsFromSpotify(ctx, subjectIDs, accessToken)
+
+        if err != nil {
+            return 0, err
+        }
+
+        for i := range artistResponses {
+            artist := artistFromSpotify(&artistResponses[i], fetchedAt)
+            upserts = append(upserts, func(tx *sql.Tx) error { return cs.CatalogDAO.UpsertArtist(tx, artist) })
+
+            key, err := cs.CacheService.GenerateKey(reflect.TypeOf(responses.Artist{}), cache.ArtistCacheKey{ArtistID: artist.ArtistID})
+
+            if err != nil {
+                return 0, err
+            }
+
+            cacheKeys = append(cacheKeys, key)
+        }
+    }
+
+    err = cs.inTransaction(func(tx *sql.Tx) error {
+        for _, upsert := range upserts {
+            err := upsert(tx)
+
+            if err != nil {
+                return err
+            }
+        }
+
+        return nil
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    // Whatever Spotify did not return keeps its last known details until it is next due
+    err = cs.CatalogDAO.MarkSubjectsFetched(cs.DB, subjectType, subjectIDs, fetchedAt)
+
+    if err != nil {
+        return 0, err
+    }
+
+    for _, key := range cacheKeys {
+        err = cs.CacheService.Delete(key)
+
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    return len(subjectIDs), nil
+}
+
+func(cs *CatalogService) inTransaction(write func(tx *sql.Tx) error) error {
+
+    transaction := func() error {
+
+        tx, err := cs.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        err = write(tx)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    return db.RunTransactionWithExponentialBackoff(transaction, 5)
+}
+
+// The app token is shared by every refresh until shortly before it expires
+func(cs *CatalogService) appToken(ctx context.Context) (string, error) {
+
+    cs.mu.Lock()
+    defer cs.mu.Unlock()
+
+    if cs.appAccessToken != "" && time.Now().Before(cs.appAccessTokenExpiresAt) {
+        return cs.appAccessToken, nil
+    }
+
+    tokenResponse, err := cs.SpotifyService.RetrieveAppAccessToken(ctx)
+
+    if err != nil {
+        return "", err
+    }
+
+    cs.appAccessToken = tokenResponse.Access_token
+    cs.appAccessTokenExpiresAt = time.Now().Add(time.Duration(tokenResponse.Expires_in)*time.Second - time.Minute)
+
+    return cs.appAccessToken, nil
+}
+
+func(cs *CatalogService) cache(key string, value any) error {
+
+    cacheTTL := cs.CacheTTL
+
+    if cacheTTL <= 0 {
+        cacheTTL = DEFAULT_CACHE_TTL
+    }
+
+    return cs.CacheService.Set(key, value, cacheTTL)
+}
+
+func(cs *CatalogService) ttl() time.Duration {
+    if cs.TTL <= 0 {
+        return DEFAULT_TTL
+    }
+    return cs.TTL
+}
+
+func trackFromSpotify(songResponse *responses.SongResponse, fetchedAt time.Time) *responses.Track {
+
+    return &responses.Track{
+        TrackID: songResponse.Id,
+        Name: songResponse.Name,
+        DurationMs: songResponse.Duration_ms,
+        Album: *albumFromSpotify(&songResponse.Album, fetchedAt),
+        Artists: artistsFromSpotify(songResponse.Artists),
+        FetchedAt: fetchedAt,
+    }
+}
+
+func albumFromSpotify(albumResponse *responses.AlbumResponse, fetchedAt time.Time) *responses.Album {
+
+    album := &responses.Album{
+        AlbumID: albumResponse.Id,
+        Name: albumResponse.Name,
+        ReleaseDate: albumResponse.Release_date,
+        ReleaseDatePrecision: albumResponse.Release_date_precision,
+        Artists: artistsFromSpotify(albumResponse.Artists),
+        FetchedAt: fetchedAt,
+    }
+
+    if len(albumResponse.Images) > 0 {
+        album.AlbumArtURI = albumResponse.Images[0].Url
+    }
+
+    return album
+}
+
+func artistFromSpotify(artistResponse *responses.ArtistResponse, fetchedAt time.Time) *responses.Artist {
+
+    artist := &responses.Artist{ArtistID: artistResponse.Id, Name: artistResponse.Name, FetchedAt: fetchedAt}
+
+    if len(artistResponse.Images) > 0 {
+        artist.ImageURI = artistResponse.Images[0].Url
+    }
+
+    return artist
+}
+
+// The artists of a track or album, which only carry their names
+func artistsFromSpotify(artistResponses []responses.ArtistResponse) []responses.Artist {
+
+    artists := []responses.Artist{}
+
+    for _, artist := range artistResponses {
+        artists = append(artists, responses.Artist{ArtistID: artist.Id, Name: artist.Name})
+    }
+
+    return artists
+}
+
+func isNotFound(err error) bool {
+    customError, ok := err.(*customerrors.CustomError)
+    return ok && customError.StatusCode == http.StatusNotFound
+}
@@ -3,17 +3,30 @@ package comments
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/emails"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
+	"github.com/Jack-Gitter/tunes/validation"
 	"github.com/gin-gonic/gin"
 )
+// Top level comments have a depth of 0, so this allows a reply to a reply to a reply, and so on, five times over
+const MAX_REPLY_DEPTH = 5
+
 type CommentsService struct {
     DB *sql.DB
     CommentsDAO daos.ICommentsDAO
+    OutboxDAO daos.IOutboxDAO
+    NotificationsDAO daos.INotificationsDAO
+    RealtimeService realtime.IRealtimeService
+    EmailDispatcher emails.IEmailDispatcher
 }
 
 type ICommentsService interface {
@@ -25,40 +38,164 @@ type ICommentsService interface {
     DislikeComment(c *gin.Context) 
     RemoveCommentVote(c *gin.Context) 
     UpdateComment(c *gin.Context) 
+    GetCommentVoters(c *gin.Context) 
+    GetCommentReplies(c *gin.Context) 
 }
 
 // @Summary Creates a comment for the current user
-// @Description Creates a comment for the current user
+// @Description Creates a comment for the current user. Setting ParentCommentID makes the comment a reply, and the parent has to be a comment on the same post
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param CreatePostDTO body requests.CreateCommentDTO true "Information required to create a commment"
-// @Param spotifyID path string true "spotifyID of poster"
-// @Param songID path string true "songID of post to make a comment on"
+// @Param postID path int true "ID of the post to make a comment on"
 // @Success 200 {object} responses.Comment
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /comments/{spotifyID}/{songID} [post]
+// @Router /comments/{postID} [post]
 // @Security Bearer
 func(cs *CommentsService) CreateComment(c *gin.Context) {
 
     commentorID, exists := c.Get("spotifyID")
-    posterID := c.Param("spotifyID")
-    songID := c.Param("songID")
+    commentorUsername, usernameExists := c.Get("spotifyUsername")
+
+    postIDParams := &requests.PostIDPathParams{}
+    c.ShouldBindUri(postIDParams)
 
     createCommentDTO := &requests.CreateCommentDTO{}
 
     c.ShouldBindBodyWithJSON(createCommentDTO)
 
-    if !exists {
+    if !exists || !usernameExists {
         c.Error(customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "JWT elmsss"})
         c.Abort()
         return
     }
 
-    comment, err := cs.CommentsDAO.CreateComment(cs.DB, commentorID.(string), posterID, songID, createCommentDTO.CommentText)
+    comment := &responses.Comment{}
+    pushes := []realtime.Push{}
+
+    transaction := func() error {
+
+        tx, err := cs.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        depth := 0
+        eventType := events.COMMENT_CREATED
+        parentCommentorID := ""
+
+        if createCommentDTO.ParentCommentID != nil {
+
+            parent, err := cs.CommentsDAO.GetCommentProperties(tx, fmt.Sprint(*createCommentDTO.ParentCommentID), commentorID.(string))
+
+            if err != nil {
+                return err
+            }
+
+            if parent.PostID != postIDParams.PostID {
+                return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "parent comment belongs to a different post"}
+            }
+
+            if parent.Deleted {
+                return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "cannot reply to a deleted comment"}
+            }
+
+            if parent.Depth >= MAX_REPLY_DEPTH {
+                return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("replies cannot be nested more than %d deep", MAX_REPLY_DEPTH)}
+            }
+
+            depth = parent.Depth + 1
+            eventType = events.COMMENT_REPLIED
+            parentCommentorID = parent.CommentorID
+        }
+
+        comment, err = cs.CommentsDAO.CreateComment(tx, commentorID.(string), postIDParams.PostID, createCommentDTO.CommentText, createCommentDTO.ParentCommentID, depth)
+
+        if err != nil {
+            return err
+        }
+
+        payload := events.CommentPayload{
+            CommentID: comment.CommentID,
+            CommentorSpotifyID: comment.CommentorID,
+            CommentorUsername: commentorUsername.(string),
+            PostID: comment.PostID,
+            PosterSpotifyID: comment.PostSpotifyID,
+            SubjectType: comment.SubjectType,
+            SongID: comment.SongID,
+            CommentText: comment.CommentText,
+            ParentCommentID: comment.ParentCommentID,
+            ParentCommentorSpotifyID: parentCommentorID,
+        }
+
+        event := events.New(eventType, payload)
+        err = cs.OutboxDAO.EnqueueEvent(tx, event)
+
+        if err != nil {
+            return err
+        }
+
+        // Replies notify whoever wrote the comment being replied to, and top level comments notify the poster
+        posterID := comment.PostSpotifyID
+        notificationRecipient := posterID
+        notificationType := responses.POST_COMMENTED
+        var notificationCommentID *int
+
+        if parentCommentorID != "" {
+            notificationRecipient = parentCommentorID
+            notificationType = responses.COMMENT_REPLIED
+            notificationCommentID = createCommentDTO.ParentCommentID
+        }
+
+        attemptPushes := []realtime.Push{}
+
+        if notificationRecipient != commentorID.(string) {
+            notificationID, err := cs.NotificationsDAO.Notify(tx, notificationRecipient, notificationType, commentorID.(string), &comment.PostID, notificationCommentID)
+
+            if err != nil {
+                return err
+            }
+
+            if notificationID != 0 {
+                attemptPushes = append(attemptPushes, realtime.NotificationPush(notificationID, notificationRecipient, notificationType, commentorID.(string)))
+            }
+
+            err = cs.EmailDispatcher.Dispatch(tx, event, []string{notificationRecipient})
+
+            if err != nil {
+                return err
+            }
+        }
+
+        recipients := []string{}
+
+        for _, recipient := range []string{posterID, parentCommentorID} {
+            if recipient != "" && recipient != commentorID.(string) {
+                recipients = append(recipients, recipient)
+            }
+        }
+
+        attemptPushes = append(attemptPushes, realtime.Push{Recipients: recipients, Event: event})
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        pushes = attemptPushes
+
+        return nil
+    }
+
+    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
 
     if err != nil {
         c.Error(err)
@@ -66,12 +203,14 @@ func(cs *CommentsService) CreateComment(c *gin.Context) {
         return
     }
 
+    cs.RealtimeService.Publish(pushes...)
+
     c.JSON(http.StatusOK, comment)
 
 }
 
 // @Summary Deletes a comment Must be admin
-// @Description Deletes a comment. Must be admin
+// @Description Deletes a comment. Must be admin. A comment with replies is replaced by a tombstone, so the replies are kept
 // @Tags Comments
 // @Accept json
 // @Produce json
@@ -88,7 +227,7 @@ func(cs *CommentsService) DeleteComment(c *gin.Context) {
 
     commentID := c.Param("commentID")
 
-    err := cs.CommentsDAO.DeleteComment(cs.DB, commentID)
+    err := cs.deleteComment(commentID, nil)
 
     if err != nil {
         c.Error(err)
@@ -100,7 +239,7 @@ func(cs *CommentsService) DeleteComment(c *gin.Context) {
 }
 
 // @Summary Deletes a comment for the current user
-// @Description Deletes a comment for the current user
+// @Description Deletes a comment for the current user. A comment with replies is replaced by a tombstone, so the replies are kept
 // @Tags Comments
 // @Accept json
 // @Produce json
@@ -108,6 +247,7 @@ func(cs *CommentsService) DeleteComment(c *gin.Context) {
 // @Success 204
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
+// @Failure 403 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
 // @Router /comments/current/{commentID} [delete]
@@ -115,9 +255,16 @@ func(cs *CommentsService) DeleteComment(c *gin.Context) {
 func(cs *CommentsService) DeleteCurrentUserComment(c *gin.Context) {
 
     commentID := c.Param("commentID")
+    spotifyID, exists := c.Get("spotifyID")
 
-    // need some extra validation here, first need to get the comment. then see if the spotifyid for the comment is the same as our spotifyid
-    err := cs.CommentsDAO.DeleteComment(cs.DB, commentID)
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    commentorID := spotifyID.(string)
+    err := cs.deleteComment(commentID, &commentorID)
 
     if err != nil {
         c.Error(err)
@@ -144,6 +291,13 @@ func(cs *CommentsService) DeleteCurrentUserComment(c *gin.Context) {
 func(cs *CommentsService) GetComment(c *gin.Context)  {
 
     commentID := c.Param("commentID") 
+    spotifyID, exists := c.Get("spotifyID")
+
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
 
     tx, err := cs.DB.BeginTx(context.Background(), nil)
 
@@ -163,7 +317,7 @@ func(cs *CommentsService) GetComment(c *gin.Context)  {
         return
     }
 
-    comment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID)
+    comment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
 
     if err != nil {
         c.Error(err)
@@ -171,17 +325,6 @@ func(cs *CommentsService) GetComment(c *gin.Context)  {
         return
     }
 
-    likes, dislikes, err := cs.CommentsDAO.GetCommentVotes(tx, commentID)
-
-    if err != nil {
-        c.Error(err)
-        c.Abort()
-        return
-    }
-
-    comment.Likes = len(likes)
-    comment.Dislikes = len(dislikes)
-
     err = tx.Commit()
 
     if err != nil {
@@ -196,22 +339,23 @@ func(cs *CommentsService) GetComment(c *gin.Context)  {
 }
 
 // @Summary Like a comment
-// @Description Like a comment
+// @Description Like a comment, and responds with the comment. Liking a comment that is already liked changes nothing
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param commentID path string true "Comment ID of comment to like"
-// @Success 204
+// @Success 200 {object} responses.Comment
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
-// @Failure 409 {string} string 
 // @Failure 500 {string} string 
 // @Router /comments/like/{commentID} [post]
 // @Security Bearer
 func(cs *CommentsService) LikeComment(c *gin.Context) {
     commentID := c.Param("commentID")
     spotifyID, exists := c.Get("spotifyID")
+    pushes := []realtime.Push{}
+    votedComment := &responses.Comment{}
 
     transaction := func() error {
 
@@ -227,30 +371,48 @@ func(cs *CommentsService) LikeComment(c *gin.Context) {
 
         defer tx.Rollback()
 
-        likes, _, err := cs.CommentsDAO.GetCommentVotes(tx, commentID)
+        changed, err := cs.CommentsDAO.LikeComment(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
-        for _, userIdentifier := range likes {
-            if userIdentifier.SpotifyID == spotifyID {
-                return &customerrors.CustomError{StatusCode: http.StatusConflict, Msg: "cannot like a message twice"}
-            }
-        }
-
-        err = cs.CommentsDAO.LikeComment(tx, commentID, spotifyID.(string))
+        comment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
+        // Liking a comment again changes nothing, so there is nobody to notify and the comment is returned as it is
+        if !changed {
+            votedComment = comment
+            return nil
+        }
+
+        // Tombstoned comments have no commentor left to notify
+        attemptPushes := []realtime.Push{}
+
+        if comment.CommentorID != "" && comment.CommentorID != spotifyID.(string) {
+            notificationID, err := cs.NotificationsDAO.Notify(tx, comment.CommentorID, responses.COMMENT_LIKED, spotifyID.(string), &comment.PostID, &comment.CommentID)
+
+            if err != nil {
+                return err
+            }
+
+            if notificationID != 0 {
+                attemptPushes = append(attemptPushes, realtime.NotificationPush(notificationID, comment.CommentorID, responses.COMMENT_LIKED, spotifyID.(string)))
+            }
+        }
+
         err = tx.Commit()
 
         if err != nil {
-            customerrors.WrapBasicError(err)
+            return customerrors.WrapBasicError(err)
         }
 
+        pushes = attemptPushes
+        votedComment = comment
+
         return nil
 
     }
@@ -263,20 +425,21 @@ func(cs *CommentsService) LikeComment(c *gin.Context) {
         return
     }
 
-    c.Status(http.StatusNoContent)
+    cs.RealtimeService.Publish(pushes...)
+
+    c.JSON(http.StatusOK, votedComment)
 
 }
 
 // @Summary Dislike a comment
-// @Description Dislike a comment
+// @Description Dislike a comment, and responds with the comment. Disliking a comment that is already disliked changes nothing
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Param commentID path string true "Comment ID of comment to dislike"
-// @Success 204
+// @Success 200 {object} responses.Comment
 // @Failure 400 {string} string 
 // @Failure 404 {string} string 
-// @Failure 409 {string} string 
 // @Failure 500 {string} string 
 // @Router /comments/dislike/{commentID} [post]
 // @Security Bearer
@@ -290,6 +453,8 @@ func(cs *CommentsService) DislikeComment(c *gin.Context) {
         return
     }
 
+    votedComment := &responses.Comment{}
+
     transaction := func() error {
 
         if !exists {
@@ -304,30 +469,32 @@ func(cs *CommentsService) DislikeComment(c *gin.Context) {
 
         defer tx.Rollback()
 
-        _, dislikes, err := cs.CommentsDAO.GetCommentVotes(tx, commentID)
+        changed, err := cs.CommentsDAO.DislikeComment(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
-        for _, userIdentifier := range dislikes {
-            if userIdentifier.SpotifyID == spotifyID {
-                return &customerrors.CustomError{StatusCode: http.StatusConflict, Msg: "cannot dislike a comment twice"}
-            }
-        }
-
-        err = cs.CommentsDAO.DislikeComment(tx, commentID, spotifyID.(string))
+        comment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
+        // Disliking a comment again changes nothing, so the comment is returned as it is
+        if !changed {
+            votedComment = comment
+            return nil
+        }
+
         err = tx.Commit()
 
         if err != nil {
-            customerrors.WrapBasicError(err)
+            return customerrors.WrapBasicError(err)
         }
 
+        votedComment = comment
+
         return nil
 
     }
@@ -340,7 +507,7 @@ func(cs *CommentsService) DislikeComment(c *gin.Context) {
         return
     }
 
-    c.Status(http.StatusNoContent)
+    c.JSON(http.StatusOK, votedComment)
 }
 
 // @Summary Delete a vote on a comment for the current user
@@ -395,9 +562,16 @@ func(cs *CommentsService) RemoveCommentVote(c *gin.Context) {
 func(cs *CommentsService) UpdateComment(c *gin.Context) {
 
     commentID := c.Param("commentID")
+    spotifyID, exists := c.Get("spotifyID")
     updateCommentDTO := &requests.UpdateCommentDTO{}
     c.ShouldBindBodyWithJSON(updateCommentDTO)
 
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
     comment := &responses.Comment{}
 
     transaction := func() error {
@@ -416,27 +590,29 @@ func(cs *CommentsService) UpdateComment(c *gin.Context) {
             return err
         }
 
-        comment, err = cs.CommentsDAO.UpdateComment(tx, commentID, updateCommentDTO)
+        existing, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
-        likes, dislikes, err := cs.CommentsDAO.GetCommentVotes(tx, commentID)
+        if existing.Deleted {
+            return &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "comment not found"}
+        }
+
+        comment, err = cs.CommentsDAO.UpdateComment(tx, commentID, updateCommentDTO)
 
         if err != nil {
             return err
         }
 
-        newcomment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID)
+        newcomment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
-        comment.CommentorUsername = newcomment.CommentorUsername
-        comment.Likes = len(likes)
-        comment.Dislikes = len(dislikes)
+        comment = newcomment
 
         err = tx.Commit()
 
@@ -459,3 +635,241 @@ func(cs *CommentsService) UpdateComment(c *gin.Context) {
     c.JSON(http.StatusOK, comment)
 
 }
+
+// @Summary Gets the users who voted on a comment
+// @Description Gets the users who voted on a comment along with how they voted
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param commentID path string true "Comment ID of the comment to get the voters of"
+// @Param vote query string false "Only return voters who voted this way. Either LIKE or DISLIKE"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.Voter]
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 404 {string} string 
+// @Failure 500 {string} string 
+// @Router /comments/votes/{commentID} [get]
+// @Security Bearer
+func(cs *CommentsService) GetCommentVoters(c *gin.Context) {
+
+    commentID := c.Param("commentID")
+    spotifyID, exists := c.Get("spotifyID")
+
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    vote, err := validation.ParseVoteQuery(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    page, err := cursor.ParsePageRequest(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    tx, err := cs.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    _, err = cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    voters, err := cs.CommentsDAO.GetCommentVoters(tx, commentID, vote, page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    paginatedVoters, err := cursor.BuildPage(voters, page, voterKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginatedVoters)
+
+}
+
+func voterKey(voter responses.Voter) (string, string) {
+    return voter.SpotifyID, voter.SpotifyID
+}
+
+// @Summary Gets the replies to a comment
+// @Description Gets the direct replies to a comment, oldest first. Each reply carries its own reply count, so deeper replies can be fetched the same way
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param commentID path string true "Comment ID of the comment to get the replies of"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.Comment]
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 404 {string} string 
+// @Failure 500 {string} string 
+// @Router /comments/replies/{commentID} [get]
+// @Security Bearer
+func(cs *CommentsService) GetCommentReplies(c *gin.Context) {
+
+    commentID := c.Param("commentID")
+    spotifyID, exists := c.Get("spotifyID")
+
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    page, err := cursor.ParsePageRequest(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    tx, err := cs.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    _, err = cs.CommentsDAO.GetCommentProperties(tx, commentID, spotifyID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    replies, err := cs.CommentsDAO.GetCommentReplies(tx, commentID, spotifyID.(string), page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    paginatedReplies, err := cursor.BuildPage(replies, page, replyKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginatedReplies)
+
+}
+
+// Deletes a comment, making sure it is owned by commentorID when one is given
+func(cs *CommentsService) deleteComment(commentID string, commentorID *string) error {
+
+    transaction := func() error {
+
+        tx, err := cs.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        comment, err := cs.CommentsDAO.GetCommentProperties(tx, commentID, "")
+
+        if err != nil {
+            return err
+        }
+
+        if comment.Deleted {
+            return &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "comment not found"}
+        }
+
+        if commentorID != nil && comment.CommentorID != *commentorID {
+            return &customerrors.CustomError{StatusCode: http.StatusForbidden, Msg: "cannot delete another users comment"}
+        }
+
+        err = cs.CommentsDAO.DeleteComment(tx, commentID)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    return db.RunTransactionWithExponentialBackoff(transaction, 5)
+}
+
+func replyKey(reply responses.Comment) (string, string) {
+    return cursor.TimeKey(reply.CreatedAt), fmt.Sprint(reply.CommentID)
+}
@@ -0,0 +1,177 @@
+package cookies
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HOST_PREFIX = "__Host-"
+	SECURE_PREFIX = "__Secure-"
+)
+
+// A cookie the API sets. The policy decides everything that depends on where the API is deployed, so a cookie only
+// says what it is for
+type Cookie struct {
+    Name string
+    // Appended to the policy path. Empty means the policy path itself
+    Path string
+    HttpOnly bool
+    // Overrides the policy, for cookies that have to come along on a particular kind of request. Zero means the policy
+    // SameSite
+    SameSite http.SameSite
+}
+
+// How cookies are set in this environment. With HostPrefix, cookies on the root path are named with the __Host- prefix,
+// which browsers only accept from a secure origin with no Domain, so they cannot be set or overwritten by a subdomain.
+// Cookies on other paths get the __Secure- prefix instead
+type CookiePolicy struct {
+    Domain string
+    Secure bool
+    SameSite http.SameSite
+    Path string
+    HostPrefix bool
+}
+
+type ICookiePolicy interface {
+    Set(c *gin.Context, cookie Cookie, value string, expiresAt time.Time)
+    Get(c *gin.Context, cookie Cookie) (string, error)
+    Clear(c *gin.Context, cookie Cookie)
+}
+
+// Reads the policy from COOKIE_DOMAIN, COOKIE_SECURE, COOKIE_SAMESITE, COOKIE_PATH and COOKIE_HOST_PREFIX. By default,
+// cookies are secure, host only, Lax and on the root path
+func NewCookiePolicyFromEnv() (*CookiePolicy, error) {
+
+    policy := &CookiePolicy{
+        Domain: os.Getenv("COOKIE_DOMAIN"),
+        Secure: true,
+        SameSite: http.SameSiteLaxMode,
+        Path: os.Getenv("COOKIE_PATH"),
+    }
+
+    if policy.Path == "" {
+        policy.Path = "/"
+    }
+
+    if !strings.HasPrefix(policy.Path, "/") {
+        return nil, errors.New("COOKIE_PATH must start with /")
+    }
+
+    secureString := os.Getenv("COOKIE_SECURE")
+
+    if secureString != "" {
+        secure, err := strconv.ParseBool(secureString)
+
+        if err != nil {
+            return nil, errors.New("COOKIE_SECURE must be true or false")
+        }
+
+        policy.Secure = secure
+    }
+
+    hostPrefixString := os.Getenv("COOKIE_HOST_PREFIX")
+
+    if hostPrefixString != "" {
+        hostPrefix, err := strconv.ParseBool(hostPrefixString)
+
+        if err != nil {
+            return nil, errors.New("COOKIE_HOST_PREFIX must be true or false")
+        }
+
+        policy.HostPrefix = hostPrefix
+    }
+
+    switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+        case "lax", "":
+            policy.SameSite = http.SameSiteLaxMode
+        case "strict":
+            policy.SameSite = http.SameSiteStrictMode
+        case "none":
+            policy.SameSite = http.SameSiteNoneMode
+        default:
+            return nil, fmt.Errorf("COOKIE_SAMESITE must be lax, strict or none")
+    }
+
+    // Browsers drop SameSite=None cookies that are not secure, and prefixed cookies that break the prefix rules
+    if policy.SameSite == http.SameSiteNoneMode && !policy.Secure {
+        return nil, errors.New("COOKIE_SECURE must be true when COOKIE_SAMESITE is none")
+    }
+
+    if policy.HostPrefix && (!policy.Secure || policy.Domain != "" || policy.Path != "/") {
+        return nil, errors.New("COOKIE_HOST_PREFIX needs COOKIE_SECURE to be true, no COOKIE_DOMAIN and a COOKIE_PATH of /")
+    }
+
+    return policy, nil
+}
+
+// The cookie lasts until expiresAt, which should be when its value stops being usable
+func(p *CookiePolicy) Set(c *gin.Context, cookie Cookie, value string, expiresAt time.Time) {
+
+    maxAge := int(time.Until(expiresAt).Seconds())
+
+    // A MaxAge of 0 would leave it up to the browser, so a cookie that is already expired is deleted instead
+    if maxAge < 1 {
+        maxAge = -1
+    }
+
+    http.SetCookie(c.Writer, p.build(cookie, value, maxAge))
+}
+
+func(p *CookiePolicy) Get(c *gin.Context, cookie Cookie) (string, error) {
+    return c.Cookie(p.Name(cookie))
+}
+
+func(p *CookiePolicy) Clear(c *gin.Context, cookie Cookie) {
+    http.SetCookie(c.Writer, p.build(cookie, "", -1))
+}
+
+// The name the cookie is set under, which includes its prefix
+func(p *CookiePolicy) Name(cookie Cookie) string {
+
+    if p.HostPrefix && p.path(cookie) == "/" {
+        return HOST_PREFIX + cookie.Name
+    }
+
+    if p.HostPrefix {
+        return SECURE_PREFIX + cookie.Name
+    }
+
+    return cookie.Name
+}
+
+func(p *CookiePolicy) build(cookie Cookie, value string, maxAge int) *http.Cookie {
+
+    sameSite := p.SameSite
+
+    if cookie.SameSite != 0 {
+        sameSite = cookie.SameSite
+    }
+
+    return &http.Cookie{
+        Name: p.Name(cookie),
+        Value: value,
+        Path: p.path(cookie),
+        Domain: p.Domain,
+        MaxAge: maxAge,
+        Secure: p.Secure,
+        HttpOnly: cookie.HttpOnly,
+        SameSite: sameSite,
+    }
+}
+
+func(p *CookiePolicy) path(cookie Cookie) string {
+
+    if cookie.Path == "" {
+        return p.Path
+    }
+
+    return strings.TrimSuffix(p.Path, "/") + cookie.Path
+}
@@ -0,0 +1,136 @@
+package cookies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestName(t *testing.T) {
+
+	rootCookie := Cookie{Name: "JWT"}
+	pathCookie := Cookie{Name: "REFRESH_JWT", Path: "/refresh"}
+
+	tests := []struct {
+		name   string
+		policy CookiePolicy
+		cookie Cookie
+		want   string
+	}{
+		{name: "no prefix", policy: CookiePolicy{Path: "/"}, cookie: rootCookie, want: "JWT"},
+		{name: "no prefix on a path", policy: CookiePolicy{Path: "/"}, cookie: pathCookie, want: "REFRESH_JWT"},
+		{name: "host prefix on the root path", policy: CookiePolicy{Path: "/", Secure: true, HostPrefix: true}, cookie: rootCookie, want: "__Host-JWT"},
+		{name: "secure prefix on a path", policy: CookiePolicy{Path: "/", Secure: true, HostPrefix: true}, cookie: pathCookie, want: "__Secure-REFRESH_JWT"},
+		{name: "cookie path of /", policy: CookiePolicy{Path: "/", Secure: true, HostPrefix: true}, cookie: Cookie{Name: "JWT", Path: "/"}, want: "__Host-JWT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.policy.Name(test.cookie); got != test.want {
+				t.Fatalf("Name = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewCookiePolicyFromEnv(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		want      CookiePolicy
+		wantError bool
+	}{
+		{name: "defaults", env: map[string]string{}, want: CookiePolicy{Secure: true, SameSite: http.SameSiteLaxMode, Path: "/"}},
+		{name: "strict", env: map[string]string{"COOKIE_SAMESITE": "Strict"}, want: CookiePolicy{Secure: true, SameSite: http.SameSiteStrictMode, Path: "/"}},
+		{name: "none", env: map[string]string{"COOKIE_SAMESITE": "none"}, want: CookiePolicy{Secure: true, SameSite: http.SameSiteNoneMode, Path: "/"}},
+		{name: "insecure for local development", env: map[string]string{"COOKIE_SECURE": "false"}, want: CookiePolicy{SameSite: http.SameSiteLaxMode, Path: "/"}},
+		{name: "domain and path", env: map[string]string{"COOKIE_DOMAIN": "tunes.app", "COOKIE_PATH": "/api"}, want: CookiePolicy{Domain: "tunes.app", Secure: true, SameSite: http.SameSiteLaxMode, Path: "/api"}},
+		{name: "host prefix", env: map[string]string{"COOKIE_HOST_PREFIX": "true"}, want: CookiePolicy{Secure: true, SameSite: http.SameSiteLaxMode, Path: "/", HostPrefix: true}},
+		{name: "path without a leading slash", env: map[string]string{"COOKIE_PATH": "api"}, wantError: true},
+		{name: "secure is not a bool", env: map[string]string{"COOKIE_SECURE": "yes please"}, wantError: true},
+		{name: "host prefix is not a bool", env: map[string]string{"COOKIE_HOST_PREFIX": "sometimes"}, wantError: true},
+		{name: "unknown samesite", env: map[string]string{"COOKIE_SAMESITE": "relaxed"}, wantError: true},
+		{name: "samesite none without secure", env: map[string]string{"COOKIE_SAMESITE": "none", "COOKIE_SECURE": "false"}, wantError: true},
+		{name: "host prefix without secure", env: map[string]string{"COOKIE_HOST_PREFIX": "true", "COOKIE_SECURE": "false"}, wantError: true},
+		{name: "host prefix with a domain", env: map[string]string{"COOKIE_HOST_PREFIX": "true", "COOKIE_DOMAIN": "tunes.app"}, wantError: true},
+		{name: "host prefix with a path", env: map[string]string{"COOKIE_HOST_PREFIX": "true", "COOKIE_PATH": "/api"}, wantError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			for _, key := range []string{"COOKIE_DOMAIN", "COOKIE_SECURE", "COOKIE_SAMESITE", "COOKIE_PATH", "COOKIE_HOST_PREFIX"} {
+				t.Setenv(key, test.env[key])
+			}
+
+			policy, err := NewCookiePolicyFromEnv()
+
+			if test.wantError {
+				if err == nil {
+					t.Fatalf("NewCookiePolicyFromEnv = %+v, want an error", policy)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *policy != test.want {
+				t.Fatalf("NewCookiePolicyFromEnv = %+v, want %+v", *policy, test.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	policy := &CookiePolicy{Secure: true, SameSite: http.SameSiteStrictMode, Path: "/", HostPrefix: true}
+
+	tests := []struct {
+		name         string
+		cookie       Cookie
+		expiresAt    time.Time
+		wantName     string
+		wantPath     string
+		wantSameSite http.SameSite
+		wantDeleted  bool
+	}{
+		{name: "root path", cookie: Cookie{Name: "JWT", HttpOnly: true}, expiresAt: time.Now().Add(time.Hour), wantName: "__Host-JWT", wantPath: "/", wantSameSite: http.SameSiteStrictMode},
+		{name: "overridden samesite on a path", cookie: Cookie{Name: "LOGIN_ATTEMPT", Path: "/login", SameSite: http.SameSiteLaxMode}, expiresAt: time.Now().Add(time.Hour), wantName: "__Secure-LOGIN_ATTEMPT", wantPath: "/login", wantSameSite: http.SameSiteLaxMode},
+		{name: "already expired", cookie: Cookie{Name: "JWT"}, expiresAt: time.Now().Add(-time.Hour), wantName: "__Host-JWT", wantPath: "/", wantSameSite: http.SameSiteStrictMode, wantDeleted: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			policy.Set(c, test.cookie, "value", test.expiresAt)
+
+			cookies := recorder.Result().Cookies()
+
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+
+			cookie := cookies[0]
+
+			if cookie.Name != test.wantName || cookie.Path != test.wantPath || cookie.SameSite != test.wantSameSite || !cookie.Secure || cookie.HttpOnly != test.cookie.HttpOnly {
+				t.Fatalf("got cookie %+v", cookie)
+			}
+
+			if deleted := cookie.MaxAge < 0; deleted != test.wantDeleted {
+				t.Fatalf("MaxAge = %d, want deleted %v", cookie.MaxAge, test.wantDeleted)
+			}
+		})
+	}
+}
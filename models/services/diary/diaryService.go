@@ -0,0 +1,301 @@
+package diary
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/requests"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/catalog"
+	"github.com/Jack-Gitter/tunes/models/services/spotify"
+	"github.com/Jack-Gitter/tunes/models/services/tokenbroker"
+	"github.com/gin-gonic/gin"
+)
+
+// Turns what the user has been listening to on Spotify into drafts, which are published as posts from /posts
+type DiaryService struct {
+    DB *sql.DB
+    DraftsDAO daos.IDraftsDAO
+    SpotifyService spotify.ISpotifyService
+    CatalogService catalog.ICatalogService
+    TokenBroker tokenbroker.ITokenBroker
+}
+
+type IDiaryService interface {
+    GetListenCandidates(c *gin.Context)
+    CreateDrafts(c *gin.Context)
+    GetDrafts(c *gin.Context)
+    DeleteDraft(c *gin.Context)
+}
+
+// @Summary Gets the tracks the current user recently listened to that they have not logged
+// @Description Gets the current users recently played tracks from Spotify, newest first, leaving out every listen the user has already posted about or drafted. Only the latest listen of each track is returned, and Relisten is set for tracks the user has posted about before
+// @Tags Diary
+// @Accept json
+// @Produce json
+// @Success 200 {object} []responses.ListenCandidate
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 500 {string} string
+// @Failure 502 {string} string
+// @Router /diary/recent [get]
+// @Security Bearer
+func(d *DiaryService) GetListenCandidates(c *gin.Context) {
+
+    spotifyID, spotifyIDExists := c.Get("spotifyID")
+    sessionID, sessionIDExists := c.Get("sessionID")
+
+    if !spotifyIDExists || !sessionIDExists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    spotifyAccessToken, err := d.TokenBroker.GetAccessToken(c.Request.Context(), sessionID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    plays, err := d.SpotifyService.GetRecentlyPlayed(c.Request.Context(), spotifyAccessToken)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    tracks := []responses.SongResponse{}
+    listens := []requests.ListenDTO{}
+
+    // Local files come back without a Spotify ID, and cannot be posted about
+    for _, play := range plays {
+        if play.Track.Id != "" {
+            tracks = append(tracks, play.Track)
+            listens = append(listens, requests.ListenDTO{TrackID: play.Track.Id, ListenedAt: play.Played_at})
+        }
+    }
+
+    err = d.CatalogService.StoreTracks(tracks)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    candidates, err := d.DraftsDAO.GetListenCandidates(d.DB, spotifyID.(string), latestListens(listens))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, candidates)
+}
+
+// @Summary Creates drafts for the current user
+// @Description Creates a draft for each listen, to be reviewed and published later. Listens are usually candidates from /diary/recent. Only the latest listen of each track is kept, and listens the user has already posted about or drafted are skipped, so only the drafts that were created are returned
+// @Tags Diary
+// @Accept json
+// @Produce json
+// @Param CreateDraftsDTO body requests.CreateDraftsDTO true "The listens to draft"
+// @Success 200 {object} []responses.PostDraft
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /diary/drafts [post]
+// @Security Bearer
+func(d *DiaryService) CreateDrafts(c *gin.Context) {
+
+    spotifyID, spotifyIDExists := c.Get("spotifyID")
+    sessionID, sessionIDExists := c.Get("sessionID")
+
+    if !spotifyIDExists || !sessionIDExists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    createDraftsDTO := &requests.CreateDraftsDTO{}
+    c.ShouldBindBodyWithJSON(createDraftsDTO)
+
+    listens := latestListens(createDraftsDTO.Listens)
+
+    spotifyAccessToken, err := d.TokenBroker.GetAccessToken(c.Request.Context(), sessionID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    // Candidates are already in the catalog, so this only reaches Spotify for tracks the client found elsewhere
+    for _, listen := range listens {
+        err = d.CatalogService.GetSubject(c.Request.Context(), responses.TRACK, listen.TrackID, spotifyAccessToken)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+    }
+
+    drafts := []responses.PostDraft{}
+
+    transaction := func() error {
+
+        tx, err := d.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        drafts, err = d.DraftsDAO.CreateDrafts(tx, spotifyID.(string), listens)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, drafts)
+}
+
+// @Summary Gets the current users drafts
+// @Description Gets the current users drafts, most recent listen first. A draft is published by creating a post with its DraftID
+// @Tags Diary
+// @Accept json
+// @Produce json
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.PostDraft]
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /diary/drafts [get]
+// @Security Bearer
+func(d *DiaryService) GetDrafts(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+    drafts, err := d.DraftsDAO.GetDrafts(d.DB, spotifyID.(string), page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    paginationResponse, err := cursor.BuildPage(drafts, page, draftKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginationResponse)
+}
+
+// @Summary Deletes a draft of the current user
+// @Description Deletes a draft of the current user without publishing it
+// @Tags Diary
+// @Accept json
+// @Produce json
+// @Param draftID path int true "ID of the draft to delete"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /diary/drafts/{draftID} [delete]
+// @Security Bearer
+func(d *DiaryService) DeleteDraft(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    draftIDParams := &requests.DraftIDPathParams{}
+    c.ShouldBindUri(draftIDParams)
+
+    err := d.DraftsDAO.DeleteDraft(d.DB, spotifyID.(string), draftIDParams.DraftID)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// Keeps only the latest listen of each track, since a draft covers every earlier listen of its track
+func latestListens(listens []requests.ListenDTO) []requests.ListenDTO {
+
+    latest := make(map[string]int)
+    deduped := []requests.ListenDTO{}
+
+    for _, listen := range listens {
+        i, seen := latest[listen.TrackID]
+
+        if !seen {
+            latest[listen.TrackID] = len(deduped)
+            deduped = append(deduped, listen)
+        } else if listen.ListenedAt.After(deduped[i].ListenedAt) {
+            deduped[i] = listen
+        }
+    }
+
+    return deduped
+}
+
+func draftKey(draft responses.PostDraft) (string, string) {
+    return cursor.TimeKey(draft.ListenedAt), fmt.Sprint(draft.DraftID)
+}
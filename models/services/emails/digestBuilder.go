@@ -0,0 +1,143 @@
+package emails
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+const (
+    DEFAULT_DIGEST_HOUR = 8
+    DIGEST_CHECK_INTERVAL = 5 * time.Minute
+    MAX_DIGEST_EVENTS = 100
+)
+
+// Batches the events held back for users who want daily or weekly digests into one email each. Daily digests go out
+// at Hour UTC, and weekly digests at Hour UTC on Mondays. Several instances can build digests at once, each user is
+// only ever claimed by one of them
+type DigestBuilder struct {
+    DB *sql.DB
+    DigestDAO daos.IDigestDAO
+    OutboxDAO daos.IOutboxDAO
+    Hour int
+}
+
+type IDigestBuilder interface {
+    Run(ctx context.Context)
+}
+
+// Checks for digests that are due until ctx is cancelled. Meant to be run in its own goroutine
+func(d *DigestBuilder) Run(ctx context.Context) {
+
+    ticker := time.NewTicker(DIGEST_CHECK_INTERVAL)
+    defer ticker.Stop()
+
+    for {
+        d.buildDigests(ctx, responses.DELIVERY_DAILY)
+        d.buildDigests(ctx, responses.DELIVERY_WEEKLY)
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Builds a digest for every user with events from before the end of the last period, one user at a time
+func(d *DigestBuilder) buildDigests(ctx context.Context, delivery responses.NotificationDelivery) {
+
+    periodEnd := d.periodEnd(delivery, time.Now().UTC())
+
+    for ctx.Err() == nil {
+
+        built, err := d.buildDigest(ctx, delivery, periodEnd)
+
+        if err != nil {
+            log.Printf("digests: failed to build %s digest: %s", delivery, err.Error())
+            return
+        }
+
+        if !built {
+            return
+        }
+    }
+}
+
+// Returns false once there are no users left with events waiting for this period
+func(d *DigestBuilder) buildDigest(ctx context.Context, delivery responses.NotificationDelivery, periodEnd time.Time) (bool, error) {
+
+    tx, err := d.DB.BeginTx(ctx, nil)
+
+    if err != nil {
+        return false, err
+    }
+
+    defer tx.Rollback()
+
+    recipientSpotifyID, err := d.DigestDAO.ClaimDigestRecipient(tx, delivery, periodEnd)
+
+    if err != nil {
+        return false, err
+    }
+
+    if recipientSpotifyID == "" {
+        return false, nil
+    }
+
+    pendingEvents, err := d.DigestDAO.TakeDigestEvents(tx, recipientSpotifyID, delivery, periodEnd)
+
+    if err != nil {
+        return false, err
+    }
+
+    payload := events.EmailDigestPayload{
+        RecipientSpotifyID: recipientSpotifyID,
+        Delivery: delivery,
+        PeriodEnd: periodEnd,
+        TotalEvents: len(pendingEvents),
+        Events: []json.RawMessage{},
+    }
+
+    for _, pendingEvent := range pendingEvents[:min(len(pendingEvents), MAX_DIGEST_EVENTS)] {
+        payload.Events = append(payload.Events, pendingEvent.Event)
+    }
+
+    err = d.OutboxDAO.EnqueueEvent(tx, events.New(events.EMAIL_DIGEST, payload))
+
+    if err != nil {
+        return false, err
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        return false, err
+    }
+
+    return true, nil
+}
+
+// The most recent digest time before now. Events from before it go out in the digest being built, and later ones wait
+// for the next
+func(d *DigestBuilder) periodEnd(delivery responses.NotificationDelivery, now time.Time) time.Time {
+
+    end := time.Date(now.Year(), now.Month(), now.Day(), d.Hour, 0, 0, 0, time.UTC)
+
+    if end.After(now) {
+        end = end.AddDate(0, 0, -1)
+    }
+
+    if delivery == responses.DELIVERY_WEEKLY {
+        daysSinceMonday := (int(end.Weekday()) + 6) % 7
+        end = end.AddDate(0, 0, -daysSinceMonday)
+    }
+
+    return end
+}
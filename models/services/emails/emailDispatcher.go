@@ -0,0 +1,76 @@
+package emails
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// Decides how each recipient of an event is emailed about it, going by their notification preferences. Instant emails
+// are written to the outbox straight away, and digest emails are held back for the digest builder
+type EmailDispatcher struct {
+    PreferencesDAO daos.IPreferencesDAO
+    DigestDAO daos.IDigestDAO
+    OutboxDAO daos.IOutboxDAO
+}
+
+type IEmailDispatcher interface {
+    Dispatch(executor db.QueryExecutor, event events.IEvent, recipients []string) error
+}
+
+// Events that users cannot be emailed about are ignored. Call this with the same transaction as the change the event
+// describes
+func(e *EmailDispatcher) Dispatch(executor db.QueryExecutor, event events.IEvent, recipients []string) error {
+
+    defaultDelivery, found := events.DEFAULT_EMAIL_DELIVERIES[event.Type()]
+
+    if !found || len(recipients) < 1 {
+        return nil
+    }
+
+    recipients = slices.Clone(recipients)
+    slices.Sort(recipients)
+    recipients = slices.Compact(recipients)
+
+    deliveries, err := e.PreferencesDAO.GetDeliveries(executor, recipients, string(event.Type()))
+
+    if err != nil {
+        return err
+    }
+
+    envelope, err := json.Marshal(event)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    for _, recipient := range recipients {
+
+        delivery, found := deliveries[recipient]
+
+        if !found {
+            delivery = defaultDelivery
+        }
+
+        switch delivery {
+        case responses.DELIVERY_INSTANT:
+            payload := events.EmailPayload{RecipientSpotifyID: recipient, Event: envelope}
+            err = e.OutboxDAO.EnqueueEvent(executor, events.New(events.EMAIL_INSTANT, payload))
+        case responses.DELIVERY_DAILY, responses.DELIVERY_WEEKLY:
+            err = e.DigestDAO.QueueDigestEvent(executor, recipient, delivery, string(event.Type()), envelope)
+        default:
+            err = nil
+        }
+
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,189 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const DATA_KEY_SIZE = 32
+
+// Envelope encryption for secrets kept at rest. Every call to Seal makes a new data key that encrypts the plaintexts,
+// and the data key is stored next to them wrapped by a key encryption key (KEK) from the key ring. KEKs never leave
+// the process, so a copy of the database alone cannot be decrypted.
+//
+// Rotating means adding a new KEK to the ring and making it the active one. Sealing always uses the active KEK, and
+// opening uses whichever KEK the data key was wrapped with, so old KEKs stay in the ring until nothing wrapped with them
+// is left
+type EncryptionService struct {
+    KEKs map[string][]byte
+    ActiveKeyID string
+}
+
+type IEncryptionService interface {
+    Seal(associatedData string, plaintexts ...string) (keyID string, wrappedDataKey []byte, ciphertexts [][]byte, err error)
+    Open(associatedData string, keyID string, wrappedDataKey []byte, ciphertexts ...[]byte) ([]string, error)
+    IsActiveKey(keyID string) bool
+}
+
+// Reads the key ring from TOKEN_ENCRYPTION_KEYS, a comma separated list of keyID:key pairs where each key is 32 bytes
+// encoded as base64, and the KEK to seal with from TOKEN_ENCRYPTION_ACTIVE_KEY_ID
+func NewEncryptionServiceFromEnv() (*EncryptionService, error) {
+
+    keks := make(map[string][]byte)
+
+    for _, entry := range strings.Split(os.Getenv("TOKEN_ENCRYPTION_KEYS"), ",") {
+        entry = strings.TrimSpace(entry)
+
+        if entry == "" {
+            continue
+        }
+
+        keyID, encodedKey, found := strings.Cut(entry, ":")
+
+        if !found || keyID == "" {
+            return nil, fmt.Errorf("TOKEN_ENCRYPTION_KEYS entries must look like keyID:base64key")
+        }
+
+        key, err := base64.StdEncoding.DecodeString(encodedKey)
+
+        if err != nil || len(key) != DATA_KEY_SIZE {
+            return nil, fmt.Errorf("encryption key %q must be %d bytes encoded as base64", keyID, DATA_KEY_SIZE)
+        }
+
+        if _, exists := keks[keyID]; exists {
+            return nil, fmt.Errorf("encryption key %q is listed more than once", keyID)
+        }
+
+        keks[keyID] = key
+    }
+
+    activeKeyID := os.Getenv("TOKEN_ENCRYPTION_ACTIVE_KEY_ID")
+
+    if _, exists := keks[activeKeyID]; !exists {
+        return nil, errors.New("TOKEN_ENCRYPTION_ACTIVE_KEY_ID must be one of the keys in TOKEN_ENCRYPTION_KEYS")
+    }
+
+    return &EncryptionService{KEKs: keks, ActiveKeyID: activeKeyID}, nil
+}
+
+// associatedData is not encrypted, but the ciphertexts can only be opened with the same associatedData. Binding it to
+// the row the ciphertexts are stored in stops them from being copied into another row
+func(e *EncryptionService) Seal(associatedData string, plaintexts ...string) (string, []byte, [][]byte, error) {
+
+    dataKey := make([]byte, DATA_KEY_SIZE)
+
+    _, err := rand.Read(dataKey)
+
+    if err != nil {
+        return "", nil, nil, err
+    }
+
+    ciphertexts := [][]byte{}
+
+    for _, plaintext := range plaintexts {
+        ciphertext, err := seal(dataKey, []byte(plaintext), []byte(associatedData))
+
+        if err != nil {
+            return "", nil, nil, err
+        }
+
+        ciphertexts = append(ciphertexts, ciphertext)
+    }
+
+    // The key ID is bound to the wrapped data key, so the data key cannot be passed off as wrapped by another KEK
+    wrappedDataKey, err := seal(e.KEKs[e.ActiveKeyID], dataKey, []byte(e.ActiveKeyID))
+
+    if err != nil {
+        return "", nil, nil, err
+    }
+
+    return e.ActiveKeyID, wrappedDataKey, ciphertexts, nil
+}
+
+func(e *EncryptionService) Open(associatedData string, keyID string, wrappedDataKey []byte, ciphertexts ...[]byte) ([]string, error) {
+
+    kek, exists := e.KEKs[keyID]
+
+    if !exists {
+        return nil, fmt.Errorf("encryption key %q is not in the key ring", keyID)
+    }
+
+    dataKey, err := open(kek, wrappedDataKey, []byte(keyID))
+
+    if err != nil {
+        return nil, err
+    }
+
+    plaintexts := []string{}
+
+    for _, ciphertext := range ciphertexts {
+        plaintext, err := open(dataKey, ciphertext, []byte(associatedData))
+
+        if err != nil {
+            return nil, err
+        }
+
+        plaintexts = append(plaintexts, string(plaintext))
+    }
+
+    return plaintexts, nil
+}
+
+// Values sealed with any other key should be sealed again, so the old key can eventually be dropped from the ring
+func(e *EncryptionService) IsActiveKey(keyID string) bool {
+    return keyID == e.ActiveKeyID
+}
+
+// AES-256-GCM with a random nonce, which is prepended to the ciphertext
+func seal(key []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
+
+    aead, err := newAEAD(key)
+
+    if err != nil {
+        return nil, err
+    }
+
+    nonce := make([]byte, aead.NonceSize())
+
+    _, err = rand.Read(nonce)
+
+    if err != nil {
+        return nil, err
+    }
+
+    return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(key []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
+
+    aead, err := newAEAD(key)
+
+    if err != nil {
+        return nil, err
+    }
+
+    if len(ciphertext) < aead.NonceSize() {
+        return nil, errors.New("ciphertext is too short")
+    }
+
+    nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+    return aead.Open(nil, nonce, sealed, associatedData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+
+    block, err := aes.NewCipher(key)
+
+    if err != nil {
+        return nil, err
+    }
+
+    return cipher.NewGCM(block)
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/rabbitmqservice"
+	"github.com/gin-gonic/gin"
+)
+
+const DATABASE_PING_TIMEOUT = 2 * time.Second
+
+type HealthService struct {
+    DB *sql.DB
+    RabbitMQService rabbitmqservice.IRabbitMQService
+}
+
+type IHealthService interface {
+    GetHealth(c *gin.Context)
+}
+
+// @Summary Reports the health of the API and its dependencies
+// @Description Reports the health of the API and its dependencies. Requests cannot be served without the database, so it being down makes the API unhealthy. Events are written to the outbox and published once the broker is back, so the broker being down only makes the API degraded
+// @Tags Health
+// @Produce json
+// @Success 200 {object} responses.Health
+// @Failure 503 {object} responses.Health
+// @Router /health [get]
+func(h *HealthService) GetHealth(c *gin.Context) {
+
+    health := responses.Health{
+        Status: responses.HEALTHY,
+        Database: responses.HEALTHY,
+        RabbitMQ: h.RabbitMQService.Health(),
+    }
+
+    ctx, cancel := context.WithTimeout(c.Request.Context(), DATABASE_PING_TIMEOUT)
+    defer cancel()
+
+    if err := h.DB.PingContext(ctx); err != nil {
+        health.Database = responses.UNHEALTHY
+        health.Status = responses.UNHEALTHY
+        c.JSON(http.StatusServiceUnavailable, health)
+        return
+    }
+
+    if health.RabbitMQ.State != responses.RABBITMQ_CONNECTED {
+        health.Status = responses.DEGRADED
+    }
+
+    c.JSON(http.StatusOK, health)
+}
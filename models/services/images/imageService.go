@@ -0,0 +1,110 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"golang.org/x/image/draw"
+)
+
+type ThumbnailSize string
+
+const (
+    SMALL ThumbnailSize = "small"
+    MEDIUM ThumbnailSize = "medium"
+    LARGE ThumbnailSize = "large"
+)
+
+var ThumbnailDimensions = map[ThumbnailSize]int{
+    SMALL: 64,
+    MEDIUM: 256,
+    LARGE: 512,
+}
+
+var allowedContentTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+const maxSourceDimension = 8192
+
+type ImageService struct {
+    MaxBytes int64
+}
+
+type IImageService interface {
+    GetMaxBytes() int64
+    CreateThumbnails(data []byte) (map[ThumbnailSize][]byte, error)
+}
+
+func(i *ImageService) GetMaxBytes() int64 {
+    return i.MaxBytes
+}
+
+func(i *ImageService) CreateThumbnails(data []byte) (map[ThumbnailSize][]byte, error) {
+
+    if int64(len(data)) > i.MaxBytes {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusRequestEntityTooLarge, Msg: "image is too large"}
+    }
+
+    if !isAllowedContentType(http.DetectContentType(data)) {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusUnsupportedMediaType, Msg: "image must be a jpeg, png or gif"}
+    }
+
+    config, _, err := image.DecodeConfig(bytes.NewReader(data))
+
+    if err != nil {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "image could not be decoded"}
+    }
+
+    if config.Width > maxSourceDimension || config.Height > maxSourceDimension {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusRequestEntityTooLarge, Msg: "image dimensions are too large"}
+    }
+
+    source, _, err := image.Decode(bytes.NewReader(data))
+
+    if err != nil {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "image could not be decoded"}
+    }
+
+    cropped := cropToSquare(source)
+    thumbnails := make(map[ThumbnailSize][]byte)
+
+    for size, dimension := range ThumbnailDimensions {
+
+        thumbnail := image.NewRGBA(image.Rect(0, 0, dimension, dimension))
+        draw.Draw(thumbnail, thumbnail.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+        draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), source, cropped, draw.Over, nil)
+
+        buffer := &bytes.Buffer{}
+        err := jpeg.Encode(buffer, thumbnail, &jpeg.Options{Quality: 85})
+
+        if err != nil {
+            return nil, customerrors.WrapBasicError(err)
+        }
+
+        thumbnails[size] = buffer.Bytes()
+    }
+
+    return thumbnails, nil
+}
+
+func cropToSquare(img image.Image) image.Rectangle {
+    bounds := img.Bounds()
+    side := min(bounds.Dx(), bounds.Dy())
+    x0 := bounds.Min.X + (bounds.Dx()-side)/2
+    y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+    return image.Rect(x0, y0, x0+side, y0+side)
+}
+
+func isAllowedContentType(contentType string) bool {
+    for _, allowed := range allowedContentTypes {
+        if contentType == allowed {
+            return true
+        }
+    }
+    return false
+}
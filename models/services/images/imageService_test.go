@@ -0,0 +1,135 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+)
+
+func encodePNG(t *testing.T, width int, height int) []byte {
+
+	buffer := &bytes.Buffer{}
+	source := image.NewRGBA(image.Rect(0, 0, width, height))
+	source.Set(0, 0, color.Black)
+
+	if err := png.Encode(buffer, source); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width int, height int) []byte {
+
+	buffer := &bytes.Buffer{}
+
+	if err := jpeg.Encode(buffer, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func encodeGIF(t *testing.T, width int, height int) []byte {
+
+	buffer := &bytes.Buffer{}
+	source := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+
+	if err := gif.Encode(buffer, source, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestCreateThumbnails(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		data       []byte
+		maxBytes   int64
+		wantStatus int
+	}{
+		{name: "png", data: encodePNG(t, 300, 200), maxBytes: 1 << 20},
+		{name: "jpeg", data: encodeJPEG(t, 100, 400), maxBytes: 1 << 20},
+		{name: "gif", data: encodeGIF(t, 32, 32), maxBytes: 1 << 20},
+		{name: "too many bytes", data: encodePNG(t, 300, 200), maxBytes: 10, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "not an image", data: []byte("<html><body>not an image</body></html>"), maxBytes: 1 << 20, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "empty", data: []byte{}, maxBytes: 1 << 20, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "png header with a broken body", data: append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...), maxBytes: 1 << 20, wantStatus: http.StatusBadRequest},
+		{name: "too many pixels", data: encodeGIF(t, maxSourceDimension+1, 1), maxBytes: 1 << 20, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			imageService := &ImageService{MaxBytes: test.maxBytes}
+
+			thumbnails, err := imageService.CreateThumbnails(test.data)
+
+			if test.wantStatus != 0 {
+				customError, ok := err.(*customerrors.CustomError)
+
+				if !ok || customError.StatusCode != test.wantStatus {
+					t.Fatalf("error = %v, want status %d", err, test.wantStatus)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(thumbnails) != len(ThumbnailDimensions) {
+				t.Fatalf("got %d thumbnails, want %d", len(thumbnails), len(ThumbnailDimensions))
+			}
+
+			for size, dimension := range ThumbnailDimensions {
+
+				if contentType := http.DetectContentType(thumbnails[size]); contentType != "image/jpeg" {
+					t.Fatalf("%s thumbnail is %s, want image/jpeg", size, contentType)
+				}
+
+				config, err := jpeg.DecodeConfig(bytes.NewReader(thumbnails[size]))
+
+				if err != nil {
+					t.Fatalf("%s thumbnail could not be decoded: %v", size, err)
+				}
+
+				if config.Width != dimension || config.Height != dimension {
+					t.Fatalf("%s thumbnail is %dx%d, want %dx%d", size, config.Width, config.Height, dimension, dimension)
+				}
+			}
+		})
+	}
+}
+
+func TestCropToSquare(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		bounds image.Rectangle
+		want   image.Rectangle
+	}{
+		{name: "square", bounds: image.Rect(0, 0, 10, 10), want: image.Rect(0, 0, 10, 10)},
+		{name: "landscape", bounds: image.Rect(0, 0, 30, 10), want: image.Rect(10, 0, 20, 10)},
+		{name: "portrait", bounds: image.Rect(0, 0, 10, 30), want: image.Rect(0, 10, 10, 20)},
+		{name: "offset", bounds: image.Rect(5, 5, 25, 15), want: image.Rect(10, 5, 20, 15)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cropToSquare(image.NewRGBA(test.bounds)); got != test.want {
+				t.Fatalf("cropToSquare = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,78 +1,261 @@
 package jwt
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-type JWTService struct {}
+const (
+	ACCESS_JWT_TTL  = time.Hour
+	REFRESH_JWT_TTL = 24 * time.Hour
+	DEFAULT_ISSUER = "tunes"
+	// Access and refresh JWTs are issued for different audiences, so one can never be used in place of the other
+	ACCESS_AUDIENCE = "tunes-api"
+	REFRESH_AUDIENCE = "tunes-refresh"
+	CLOCK_LEEWAY = 30 * time.Second
+)
+
+type SigningKey struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	ActiveFrom time.Time
+}
+
+// Signs JWTs with EdDSA. Every key in Keys is trusted and published in the JWKS, but only the most recent key whose
+// ActiveFrom has passed signs new JWTs. Scheduling a key ahead of time gives anyone caching the JWKS the chance to pick
+// it up before the first JWT signed with it turns up
+type JWTService struct {
+	Issuer string
+	Keys   []SigningKey
+}
 
 type IJWTService interface {
-    CreateAccessJWT(spotifyID string, username string, accessToken string, accessTokenExpiresAt int, role responses.Role) (string, error) 
-    CreateRefreshJWT(spotifyRefreshToken string) (string, error) 
-    ValidateAccessToken(accessTokenJWT string) (*jwt.Token, error) 
-    ValidateRefreshToken(refreshTokenJWT string) (*jwt.Token, error) 
+    CreateAccessJWT(sessionID string, spotifyID string, username string) (string, error)
+    CreateRefreshJWT(sessionID string, spotifyID string, refreshTokenID string, expiresAt time.Time) (string, error)
+    ValidateAccessToken(accessTokenJWT string) (*jwt.Token, error)
+    ValidateRefreshToken(refreshTokenJWT string) (*jwt.Token, error)
+    GetJWKS() *responses.JWKS
+}
+
+// Reads the signing keys from JWT_SIGNING_KEYS, a comma separated list of kid:key or kid:key:activeFrom entries. Each
+// key is a 32 byte Ed25519 seed encoded as base64, and activeFrom is a date like 2026-11-01. Keys without an activeFrom
+// are active straight away
+func NewJWTServiceFromEnv() (*JWTService, error) {
+
+	issuer := os.Getenv("JWT_ISSUER")
+
+	if issuer == "" {
+		issuer = DEFAULT_ISSUER
+	}
+
+	keys := []SigningKey{}
+	keyIDs := make(map[string]bool)
+
+	for _, entry := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+			return nil, errors.New("JWT_SIGNING_KEYS entries must look like kid:base64key or kid:base64key:activeFrom")
+		}
+
+		if keyIDs[fields[0]] {
+			return nil, fmt.Errorf("signing key %q is listed more than once", fields[0])
+		}
+
+		seed, err := base64.StdEncoding.DecodeString(fields[1])
+
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("signing key %q must be %d bytes encoded as base64", fields[0], ed25519.SeedSize)
+		}
+
+		activeFrom := time.Time{}
+
+		if len(fields) == 3 {
+			activeFrom, err = time.Parse(time.DateOnly, fields[2])
+
+			if err != nil {
+				return nil, fmt.Errorf("signing key %q must become active on a date like 2026-11-01", fields[0])
+			}
+		}
+
+		keyIDs[fields[0]] = true
+		keys = append(keys, SigningKey{ID: fields[0], PrivateKey: ed25519.NewKeyFromSeed(seed), ActiveFrom: activeFrom})
+	}
+
+	if len(keys) < 1 {
+		return nil, errors.New("JWT_SIGNING_KEYS must list at least one signing key")
+	}
+
+	jwtService := &JWTService{Issuer: issuer, Keys: keys}
+
+	_, err := jwtService.currentKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jwtService, nil
 }
 
-func(j *JWTService) CreateAccessJWT(spotifyID string, username string, accessToken string, accessTokenExpiresAt int, role responses.Role) (string, error) {
+func(j *JWTService) CreateAccessJWT(sessionID string, spotifyID string, username string) (string, error) {
 
 	claims := &requests.JWTClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "tunes",
-			Subject:   "bitch",
-			Audience:  []string{"another bitch"},
-			ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(time.Hour)},
-			NotBefore: &jwt.NumericDate{Time: time.Now()},
-			IssuedAt:  &jwt.NumericDate{Time: time.Now()},
-			ID:        "garbage for now",
-		},
-		SpotifyID:            spotifyID,
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessTokenExpiresAt,
-		UserRole:             role,
-		Username:             username,
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+		RegisteredClaims: j.registeredClaims(spotifyID, ACCESS_AUDIENCE, uuid.NewString(), time.Now().Add(ACCESS_JWT_TTL)),
+		SessionID: sessionID,
+		SpotifyID: spotifyID,
+		Username:  username,
+	}
 
-	return tokenString, customerrors.WrapBasicError(err)
+	return j.sign(claims)
 }
 
-func(j *JWTService) CreateRefreshJWT(spotifyRefreshToken string) (string, error) {
+// expiresAt should be the expiry of the session, so the JWT stops working when the session does
+func(j *JWTService) CreateRefreshJWT(sessionID string, spotifyID string, refreshTokenID string, expiresAt time.Time) (string, error) {
+
 	claims := &requests.RefreshJWTClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "tunes",
-			Subject:   "bitch",
-			Audience:  []string{"another bitch"},
-			ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(time.Hour * 24)},
-			NotBefore: &jwt.NumericDate{Time: time.Now()},
-			IssuedAt:  &jwt.NumericDate{Time: time.Now()},
-			ID:        "garbage for now",
-		},
-		RefreshToken: spotifyRefreshToken,
-	}
-
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	refreshString, err := refreshToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
-	return refreshString, customerrors.WrapBasicError(err)
+		RegisteredClaims: j.registeredClaims(spotifyID, REFRESH_AUDIENCE, refreshTokenID, expiresAt),
+		SessionID: sessionID,
+	}
+
+	return j.sign(claims)
 }
 
 func(j *JWTService) ValidateAccessToken(accessTokenJWT string) (*jwt.Token, error) {
-	token, err := jwt.ParseWithClaims(accessTokenJWT, &requests.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-	return token, customerrors.WrapBasicError(err)
+
+	claims := &requests.JWTClaims{}
+
+	token, err := j.parse(accessTokenJWT, claims, ACCESS_AUDIENCE)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SpotifyID != claims.Subject {
+		return nil, customerrors.WrapBasicError(jwt.ErrTokenInvalidSubject)
+	}
+
+	return token, nil
 }
 
 func(j *JWTService) ValidateRefreshToken(refreshTokenJWT string) (*jwt.Token, error) {
-	token, e := jwt.ParseWithClaims(refreshTokenJWT, &requests.RefreshJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-	return token, customerrors.WrapBasicError(e)
+	return j.parse(refreshTokenJWT, &requests.RefreshJWTClaims{}, REFRESH_AUDIENCE)
+}
+
+// Every trusted key, including the ones scheduled to become active later
+func(j *JWTService) GetJWKS() *responses.JWKS {
+
+	jwks := &responses.JWKS{Keys: []responses.JWK{}}
+
+	for _, key := range j.Keys {
+		jwks.Keys = append(jwks.Keys, responses.JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.PrivateKey.Public().(ed25519.PublicKey)),
+			KeyID:     key.ID,
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Use:       "sig",
+		})
+	}
+
+	return jwks
+}
+
+func(j *JWTService) registeredClaims(subject string, audience string, id string, expiresAt time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    j.Issuer,
+		Subject:   subject,
+		Audience:  []string{audience},
+		ExpiresAt: &jwt.NumericDate{Time: expiresAt},
+		NotBefore: &jwt.NumericDate{Time: time.Now()},
+		IssuedAt:  &jwt.NumericDate{Time: time.Now()},
+		ID:        id,
+	}
+}
+
+func(j *JWTService) sign(claims jwt.Claims) (string, error) {
+
+	key, err := j.currentKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
+
+	return tokenString, customerrors.WrapBasicError(err)
+}
+
+func(j *JWTService) parse(tokenString string, claims jwt.Claims, audience string) (*jwt.Token, error) {
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, j.publicKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(j.Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(CLOCK_LEEWAY),
+	)
+
+	if err != nil {
+		return nil, customerrors.WrapBasicError(err)
+	}
+
+	subject, err := claims.GetSubject()
+
+	if err != nil || subject == "" {
+		return nil, customerrors.WrapBasicError(jwt.ErrTokenInvalidSubject)
+	}
+
+	return token, nil
+}
+
+func(j *JWTService) publicKey(token *jwt.Token) (interface{}, error) {
+
+	keyID, _ := token.Header["kid"].(string)
+
+	for _, key := range j.Keys {
+		if key.ID == keyID {
+			return key.PrivateKey.Public(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("signing key %q is not trusted", keyID)
+}
+
+func(j *JWTService) currentKey() (*SigningKey, error) {
+
+	var current *SigningKey
+	now := time.Now()
+
+	for i, key := range j.Keys {
+		if !key.ActiveFrom.After(now) && (current == nil || !key.ActiveFrom.Before(current.ActiveFrom)) {
+			current = &j.Keys[i]
+		}
+	}
+
+	if current == nil {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "no JWT signing key is active yet"}
+	}
+
+	return current, nil
 }
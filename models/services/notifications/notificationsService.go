@@ -0,0 +1,252 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationsService struct {
+    DB *sql.DB
+    NotificationsDAO daos.INotificationsDAO
+}
+
+type INotificationsService interface {
+    GetNotifications(c *gin.Context)
+    GetUnreadNotificationCount(c *gin.Context)
+    MarkNotificationRead(c *gin.Context)
+    MarkAllNotificationsRead(c *gin.Context)
+}
+
+// @Summary Gets the current users notifications
+// @Description Gets the current users notifications, most recently updated first. Repeats of the same action on the same subject are folded into one unread notification, e.g. "alice and 4 others liked your review of Song", until it is read
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.Notification]
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /notifications [get]
+// @Security Bearer
+func(n *NotificationsService) GetNotifications(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+    tx, err := n.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    notifications, err := n.NotificationsDAO.GetNotifications(tx, spotifyID.(string), page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    for i := range notifications {
+        notifications[i].Message = notificationMessage(notifications[i])
+    }
+
+    paginationResponse, err := cursor.BuildPage(notifications, page, notificationKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginationResponse)
+}
+
+// @Summary Gets the number of unread notifications for the current user
+// @Description Gets the number of unread notifications for the current user
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.UnreadNotificationCount
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /notifications/unreadCount [get]
+// @Security Bearer
+func(n *NotificationsService) GetUnreadNotificationCount(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    count, err := n.NotificationsDAO.GetUnreadNotificationCount(n.DB, spotifyID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, responses.UnreadNotificationCount{Count: count})
+}
+
+// @Summary Marks a notification as read
+// @Description Marks one of the current users notifications as read. Actions after this start a new notification instead of being added to this one
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param notificationID path string true "ID of the notification to mark as read"
+// @Success 204
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /notifications/read/{notificationID} [post]
+// @Security Bearer
+func(n *NotificationsService) MarkNotificationRead(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    notificationID, err := strconv.ParseInt(c.Param("notificationID"), 10, 64)
+
+    if err != nil {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+        c.Abort()
+        return
+    }
+
+    err = n.NotificationsDAO.MarkNotificationRead(n.DB, spotifyID.(string), notificationID)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+// @Summary Marks all of the current users notifications as read
+// @Description Marks all of the current users notifications as read
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /notifications/read [post]
+// @Security Bearer
+func(n *NotificationsService) MarkAllNotificationsRead(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    err := n.NotificationsDAO.MarkAllNotificationsRead(n.DB, spotifyID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+func notificationMessage(notification responses.Notification) string {
+
+    actors := notification.LatestActor.Username
+
+    if actors == "" {
+        actors = "Someone"
+    }
+
+    if notification.ActorCount == 2 {
+        actors = fmt.Sprintf("%s and 1 other", actors)
+    } else if notification.ActorCount > 2 {
+        actors = fmt.Sprintf("%s and %d others", actors, notification.ActorCount-1)
+    }
+
+    review := "your review"
+
+    if notification.SubjectName != nil {
+        review = fmt.Sprintf("your review of %s", *notification.SubjectName)
+    }
+
+    switch notification.Type {
+    case responses.FOLLOWED:
+        return fmt.Sprintf("%s followed you", actors)
+    case responses.POST_LIKED:
+        return fmt.Sprintf("%s liked %s", actors, review)
+    case responses.POST_COMMENTED:
+        return fmt.Sprintf("%s commented on %s", actors, review)
+    case responses.COMMENT_REPLIED:
+        return fmt.Sprintf("%s replied to your comment", actors)
+    case responses.COMMENT_LIKED:
+        return fmt.Sprintf("%s liked your comment", actors)
+    }
+
+    return ""
+}
+
+func notificationKey(notification responses.Notification) (string, string) {
+    return cursor.TimeKey(notification.UpdatedAt), fmt.Sprint(notification.NotificationID)
+}
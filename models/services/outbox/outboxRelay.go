@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/rabbitmqservice"
+)
+
+const (
+    DEFAULT_POLL_INTERVAL = time.Second
+    DEFAULT_BATCH_SIZE = 100
+    BASE_RETRY_DELAY = time.Second
+    MAX_RETRY_DELAY = 10 * time.Minute
+    PUBLISH_TIMEOUT = 10 * time.Second
+    SENT_RETENTION = 7 * 24 * time.Hour
+)
+
+// Published at /debug/vars
+var (
+    pendingMessages = expvar.NewInt("outbox_pending_messages")
+    oldestPendingAgeSeconds = expvar.NewFloat("outbox_oldest_pending_age_seconds")
+    lastPublishLagSeconds = expvar.NewFloat("outbox_last_publish_lag_seconds")
+    publishedMessages = expvar.NewInt("outbox_published_messages_total")
+    failedPublishes = expvar.NewInt("outbox_failed_publishes_total")
+)
+
+// Publishes the messages written to the outbox table. Every message is published at least once, so consumers should
+// expect the occasional duplicate
+type OutboxRelay struct {
+    DB *sql.DB
+    OutboxDAO daos.IOutboxDAO
+    RabbitMQService rabbitmqservice.IRabbitMQService
+    PollInterval time.Duration
+    BatchSize int
+}
+
+type IOutboxRelay interface {
+    Run(ctx context.Context)
+}
+
+// Polls the outbox until ctx is cancelled. Meant to be run in its own goroutine
+func(o *OutboxRelay) Run(ctx context.Context) {
+
+    ticker := time.NewTicker(o.PollInterval)
+    defer ticker.Stop()
+
+    cleanup := time.NewTicker(time.Hour)
+    defer cleanup.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-cleanup.C:
+            err := o.OutboxDAO.DeleteSentMessages(o.DB, time.Now().UTC().Add(-SENT_RETENTION))
+            if err != nil {
+                log.Printf("outbox: failed to delete sent messages: %s", err.Error())
+            }
+        case <-ticker.C:
+            o.recordStats()
+
+            // Publishing would only fail while the broker is unreachable, and count against every message's
+            // retry backoff, so messages wait in the outbox until the connection is back
+            if o.RabbitMQService.Health().State != responses.RABBITMQ_CONNECTED {
+                continue
+            }
+
+            for {
+                published, err := o.relayBatch(ctx)
+                if err != nil {
+                    log.Printf("outbox: failed to relay messages: %s", err.Error())
+                }
+                if err != nil || published < o.BatchSize {
+                    break
+                }
+            }
+        }
+    }
+}
+
+// Claims a batch of due messages and publishes them one at a time. Messages that fail are scheduled for another
+// attempt with exponential backoff. Returns how many messages were claimed
+func(o *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+
+    tx, err := o.DB.BeginTx(ctx, nil)
+
+    if err != nil {
+        return 0, err
+    }
+
+    defer tx.Rollback()
+
+    messages, err := o.OutboxDAO.ClaimPendingMessages(tx, o.BatchSize)
+
+    if err != nil {
+        return 0, err
+    }
+
+    for _, message := range messages {
+
+        publishCtx, cancel := context.WithTimeout(ctx, PUBLISH_TIMEOUT)
+        err := o.RabbitMQService.Publish(publishCtx, message.Exchange, message.RoutingKey, message.Payload)
+        cancel()
+
+        if err != nil {
+            failedPublishes.Add(1)
+            err = o.OutboxDAO.MarkMessageFailed(tx, message.ID, time.Now().UTC().Add(retryDelay(message.Attempts)), err.Error())
+
+            if err != nil {
+                return 0, err
+            }
+
+            // The broker is most likely unavailable, so the rest of the batch is left for the next poll
+            return 0, tx.Commit()
+        }
+
+        publishedMessages.Add(1)
+        lastPublishLagSeconds.Set(time.Since(message.CreatedAt).Seconds())
+        err = o.OutboxDAO.MarkMessageSent(tx, message.ID)
+
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        return 0, err
+    }
+
+    return len(messages), nil
+}
+
+func(o *OutboxRelay) recordStats() {
+
+    stats, err := o.OutboxDAO.GetOutboxStats(o.DB)
+
+    if err != nil {
+        log.Printf("outbox: failed to read stats: %s", err.Error())
+        return
+    }
+
+    pendingMessages.Set(int64(stats.Pending))
+
+    if stats.OldestPendingAt == nil {
+        oldestPendingAgeSeconds.Set(0)
+        return
+    }
+
+    oldestPendingAgeSeconds.Set(time.Since(*stats.OldestPendingAt).Seconds())
+}
+
+// Doubles with every attempt up to MAX_RETRY_DELAY, with up to 20% jitter so failed messages don't retry in lockstep
+func retryDelay(attempts int) time.Duration {
+
+    delay := float64(BASE_RETRY_DELAY) * math.Pow(2, float64(attempts))
+    delay = math.Min(delay, float64(MAX_RETRY_DELAY))
+    jitter := delay * 0.2 * rand.Float64()
+
+    return time.Duration(delay + jitter)
+}
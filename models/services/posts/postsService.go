@@ -5,15 +5,19 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
-	"slices"
 	"time"
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
-	"github.com/Jack-Gitter/tunes/models/services/rabbitmqservice"
-	"github.com/Jack-Gitter/tunes/models/services/spotify"
+	"github.com/Jack-Gitter/tunes/models/services/catalog"
+	"github.com/Jack-Gitter/tunes/models/services/emails"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
+	"github.com/Jack-Gitter/tunes/models/services/tokenbroker"
+	"github.com/Jack-Gitter/tunes/validation"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,8 +26,14 @@ type PostsService struct {
     PostsDAO daos.IPostsDAO
     UsersDAO daos.IUsersDAO
     CommentsDAO daos.CommentsDAO
-    SpotifyService spotify.ISpotifyService
-    RabbitMQService rabbitmqservice.IRabbitMQService
+    FeedDAO daos.IFeedDAO
+    CatalogService catalog.ICatalogService
+    TokenBroker tokenbroker.ITokenBroker
+    OutboxDAO daos.IOutboxDAO
+    NotificationsDAO daos.INotificationsDAO
+    DraftsDAO daos.IDraftsDAO
+    RealtimeService realtime.IRealtimeService
+    EmailDispatcher emails.IEmailDispatcher
 }
 
 type IPostsService interface {
@@ -32,18 +42,20 @@ type IPostsService interface {
     DislikePost(c *gin.Context)
     GetAllPostsForUserByID(c *gin.Context) 
     GetAllPostsForCurrentUser(c *gin.Context) 
-    GetPostBySpotifyIDAndSongID(c *gin.Context)
+    GetPostByID(c *gin.Context)
     GetPostCurrentUserBySongID(c *gin.Context) 
-    DeletePostBySpotifyIDAndSongID(c *gin.Context)  
-    DeletePostForCurrentUserBySongID(c *gin.Context) 
+    DeletePostByID(c *gin.Context)  
+    DeletePostForCurrentUser(c *gin.Context) 
     UpdateCurrentUserPost(c *gin.Context) // yes
     RemovePostVote(c *gin.Context) 
     GetPostCommentsPaginated(c *gin.Context) 
+    GetPostVoters(c *gin.Context) 
     GetCurrentUserFeed(c *gin.Context) 
+    GetAlbumPostsPaginated(c *gin.Context)
 }
 
 // @Summary Creates a post for the current user
-// @Description Creates a post for the current user
+// @Description Creates a post for the current user reviewing a track, an album or an artist. The subject is looked up on Spotify and kept in the catalog. A user can post about the same subject more than once, and every post after the first is flagged as a re-listen. Setting DraftID publishes one of the users drafts instead, taking the subject and ListenedAt from it
 // @Tags Posts
 // @Accept json
 // @Produce json
@@ -59,10 +71,9 @@ type IPostsService interface {
 func(p *PostsService) CreatePostForCurrentUser(c *gin.Context) {
 
 	spotifyID, spotifyIDExists := c.Get("spotifyID")
-	spotifyUsername, spotifyUsernameExists := c.Get("spotifyUsername")
-	spotifyAccessToken, spotifyAccessTokenExists := c.Get("spotifyAccessToken")
+	sessionID, sessionIDExists := c.Get("sessionID")
 
-	if !spotifyIDExists || !spotifyAccessTokenExists || !spotifyUsernameExists {
+	if !spotifyIDExists || !sessionIDExists {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt"})
 		c.Abort()
 		return
@@ -71,7 +82,39 @@ func(p *PostsService) CreatePostForCurrentUser(c *gin.Context) {
 	createPostDTO := &requests.CreatePostDTO{}
 	c.ShouldBindBodyWithJSON(createPostDTO)
 
-	spotifySongResponse, err := p.SpotifyService.GetSongDetailsFromSpotify(*createPostDTO.SongID, spotifyAccessToken.(string))
+    subjectType := responses.TRACK
+
+    if createPostDTO.SubjectType != nil {
+        subjectType = *createPostDTO.SubjectType
+    }
+
+    subjectID := createPostDTO.SongID
+
+    if createPostDTO.SubjectID != nil {
+        subjectID = createPostDTO.SubjectID
+    }
+
+    createdAt := time.Now().UTC()
+    listenedAt := createdAt
+
+    if createPostDTO.ListenedAt != nil {
+        listenedAt = createPostDTO.ListenedAt.UTC()
+    }
+
+    if createPostDTO.DraftID != nil {
+        draft, err := p.DraftsDAO.GetDraft(p.DB, spotifyID.(string), *createPostDTO.DraftID)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        subjectID = &draft.TrackID
+        listenedAt = draft.ListenedAt
+    }
+
+	spotifyAccessToken, err := p.TokenBroker.GetAccessToken(c.Request.Context(), sessionID.(string))
 
 	if err != nil {
 		c.Error(err)
@@ -79,33 +122,91 @@ func(p *PostsService) CreatePostForCurrentUser(c *gin.Context) {
 		return
 	}
 
-	var albumImage string = ""
-	if len(spotifySongResponse.Album.Images) > 0 {
-		albumImage = spotifySongResponse.Album.Images[0].Url
+	err = p.CatalogService.GetSubject(c.Request.Context(), subjectType, *subjectID, spotifyAccessToken)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
-    if createPostDTO.Rating == nil {
-        rating := 0
-        createPostDTO.Rating = &rating
-    }
     if createPostDTO.Text == nil {
         text := ""
         createPostDTO.Text = &text
     }
 
-	resp, err := p.PostsDAO.CreatePost(
-        p.DB,
-		spotifyID.(string),
-		*createPostDTO.SongID,
-		spotifySongResponse.Name,
-		spotifySongResponse.Album.Id,
-		spotifySongResponse.Album.Name,
-		albumImage,
-		*createPostDTO.Rating,
-		*createPostDTO.Text,
-		time.Now().UTC(),
-		spotifyUsername.(string),
-	)
+    relisten := createPostDTO.Relisten != nil && *createPostDTO.Relisten
+
+    resp := &responses.PostPreview{}
+    pushes := []realtime.Push{}
+
+    transaction := func() error {
+
+        tx, err := p.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        resp, err = p.PostsDAO.CreatePost(
+            tx,
+            spotifyID.(string),
+            subjectType,
+            *subjectID,
+            createPostDTO.Rating,
+            *createPostDTO.Text,
+            listenedAt,
+            relisten,
+            createdAt,
+        )
+
+        if err != nil {
+            return err
+        }
+
+        // The draft is only gone once the post is in, and publishing it twice at once fails on the second delete
+        if createPostDTO.DraftID != nil {
+            err = p.DraftsDAO.DeleteDraft(tx, spotifyID.(string), *createPostDTO.DraftID)
+
+            if err != nil {
+                return err
+            }
+        }
+
+        followers, err := p.FeedDAO.FanOutPost(tx, spotifyID.(string), resp.PostID, createdAt)
+
+        if err != nil {
+            return err
+        }
+
+        event := events.New(events.POST_CREATED, events.NewPostPayload(resp))
+        err = p.OutboxDAO.EnqueueEvent(tx, event)
+
+        if err != nil {
+            return err
+        }
+
+        err = p.EmailDispatcher.Dispatch(tx, event, followers)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        pushes = []realtime.Push{{Recipients: followers, Event: event}}
+
+        return nil
+
+    }
+
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
 
 	if err != nil {
 		c.Error(err)
@@ -113,45 +214,47 @@ func(p *PostsService) CreatePostForCurrentUser(c *gin.Context) {
 		return
 	}
 
-    rabbitMQMessage := rabbitmqservice.RabbitMQPostMessage{
-        Type: rabbitmqservice.POST,
-        Poster: spotifyID.(string),
-    }
-
-    err = p.RabbitMQService.Enqueue(rabbitMQMessage)
-
-	resp.Likes = []responses.UserIdentifer{}
-	resp.Dislikes = []responses.UserIdentifer{}
+    p.RealtimeService.Publish(pushes...)
 
 	c.JSON(http.StatusOK, resp)
 
 }
 
 // @Summary Likes a post for the current user
-// @Description Likes a post for the current user
+// @Description Likes a post for the current user, and responds with the post. Liking a post that is already liked changes nothing
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param spotifyID path string true "Song ID of the post to like"
-// @Param songID path string true "Spotify ID of the user who posted the song"
-// @Success 204
+// @Param postID path int true "ID of the post to like"
+// @Success 200 {object} responses.PostPreview
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
-// @Failure 409 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/likes/{spotifyID}/{songID} [post]
+// @Router /posts/likes/{postID} [post]
 // @Security Bearer
 func(p *PostsService) LikePost(c *gin.Context) {
 	currentUserSpotifyID, found := c.Get("spotifyID")
-	spotifyID := c.Param("spotifyID")
-	songID := c.Param("songID")
+	currentUserSpotifyUsername, usernameFound := c.Get("spotifyUsername")
 
-	if !found {
+	if !found || !usernameFound {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt"})
 		c.Abort()
 		return
 	}
 
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
+
+    pushes := []realtime.Push{}
+    votedPost := &responses.PostPreview{}
+
     transaction := func() error {
 
         tx, err := p.DB.BeginTx(context.Background(), nil)
@@ -161,36 +264,63 @@ func(p *PostsService) LikePost(c *gin.Context) {
         }
 
         defer tx.Rollback()
-        
-        likes, _, err := p.PostsDAO.GetPostVotes(tx, songID, spotifyID)
+
+        changed, err := p.PostsDAO.LikePost(tx, currentUserSpotifyID.(string), postIDParams.PostID)
 
         if err != nil {
             return err
         }
 
-        for _, userIdentifier := range likes {
-            if userIdentifier.SpotifyID == spotifyID {
-                return &customerrors.CustomError{StatusCode: http.StatusConflict, Msg: "cannot like a post twice"}
-            }
+        // Liking a post again changes nothing, so there is nothing to publish and the post is returned as it is
+        if !changed {
+            votedPost, err = p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, currentUserSpotifyID.(string))
+            return err
         }
 
-        err = p.PostsDAO.LikePost(tx, currentUserSpotifyID.(string), spotifyID, songID)
+        post, event, err := p.publishVote(tx, events.POST_LIKED, currentUserSpotifyID.(string), currentUserSpotifyUsername.(string), postIDParams.PostID)
 
         if err != nil {
             return err
         }
 
+        attemptPushes := []realtime.Push{}
+        spotifyID := post.SpotifyID
+
+        if currentUserSpotifyID.(string) != spotifyID {
+            notificationID, err := p.NotificationsDAO.Notify(tx, spotifyID, responses.POST_LIKED, currentUserSpotifyID.(string), &post.PostID, nil)
+
+            if err != nil {
+                return err
+            }
+
+            attemptPushes = append(attemptPushes, realtime.Push{Recipients: []string{spotifyID}, Event: event})
+
+            // Emails follow the notification, so liking a post again after removing the like does not send another
+            if notificationID != 0 {
+                err = p.EmailDispatcher.Dispatch(tx, event, []string{spotifyID})
+
+                if err != nil {
+                    return err
+                }
+
+                attemptPushes = append(attemptPushes, realtime.NotificationPush(notificationID, spotifyID, responses.POST_LIKED, currentUserSpotifyID.(string)))
+            }
+        }
+
         err = tx.Commit()
 
         if err != nil {
             return customerrors.WrapBasicError(err)
         }
 
+        pushes = attemptPushes
+        votedPost = post
+
         return nil
 
     }
 
-    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
 
     if err != nil {
         c.Error(err)
@@ -198,36 +328,47 @@ func(p *PostsService) LikePost(c *gin.Context) {
         return
     }
 
-	c.Status(http.StatusNoContent)
+    p.RealtimeService.Publish(pushes...)
+
+	c.JSON(http.StatusOK, votedPost)
 }
 
 // @Summary Dislikes a post for the current user
-// @Description Dislikes a post for the current user
+// @Description Dislikes a post for the current user, and responds with the post. Disliking a post that is already disliked changes nothing
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param spotifyID path string true "Song ID of the post to dislike"
-// @Param songID path string true "Spotify ID of the user who posted the song"
-// @Success 204
+// @Param postID path int true "ID of the post to dislike"
+// @Success 200 {object} responses.PostPreview
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
-// @Failure 409 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/dislikes/{spotifyID}/{songID} [post]
+// @Router /posts/dislikes/{postID} [post]
 // @Security Bearer
 func(p *PostsService) DislikePost(c *gin.Context) {
 
 	currentUserSpotifyID, found := c.Get("spotifyID")
-	spotifyID := c.Param("spotifyID")
-	songID := c.Param("songID")
+	currentUserSpotifyUsername, usernameFound := c.Get("spotifyUsername")
 
-	if !found {
+	if !found || !usernameFound {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt"})
 		c.Abort()
 		return
 	}
+
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
     
+    pushes := []realtime.Push{}
+    votedPost := &responses.PostPreview{}
+
     transaction := func() error {
 
         tx, err := p.DB.BeginTx(context.Background(), nil)
@@ -237,20 +378,20 @@ func(p *PostsService) DislikePost(c *gin.Context) {
         }
 
         defer tx.Rollback()
-        
-        _, dislikes, err := p.PostsDAO.GetPostVotes(tx, songID, spotifyID)
+
+        changed, err := p.PostsDAO.DislikePost(tx, currentUserSpotifyID.(string), postIDParams.PostID)
 
         if err != nil {
             return err
         }
 
-        for _, userIdentifier := range dislikes {
-            if userIdentifier.SpotifyID == spotifyID {
-                return &customerrors.CustomError{StatusCode: http.StatusConflict, Msg: "cannot like a post twice"}
-            }
+        // Disliking a post again changes nothing, so there is nothing to publish and the post is returned as it is
+        if !changed {
+            votedPost, err = p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, currentUserSpotifyID.(string))
+            return err
         }
 
-        err = p.PostsDAO.DislikePost(tx, currentUserSpotifyID.(string), spotifyID, songID)
+        post, event, err := p.publishVote(tx, events.POST_DISLIKED, currentUserSpotifyID.(string), currentUserSpotifyUsername.(string), postIDParams.PostID)
 
         if err != nil {
             return err
@@ -262,11 +403,17 @@ func(p *PostsService) DislikePost(c *gin.Context) {
             return customerrors.WrapBasicError(err)
         }
 
+        if currentUserSpotifyID.(string) != post.SpotifyID {
+            pushes = []realtime.Push{{Recipients: []string{post.SpotifyID}, Event: event}}
+        }
+
+        votedPost = post
+
         return nil
 
     }
 
-    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
 
     if err != nil {
         c.Error(err)
@@ -274,7 +421,9 @@ func(p *PostsService) DislikePost(c *gin.Context) {
         return
     }
 
-	c.Status(http.StatusNoContent)
+    p.RealtimeService.Publish(pushes...)
+
+	c.JSON(http.StatusOK, votedPost)
 }
 
 // @Summary Get all of a users post previews
@@ -283,8 +432,9 @@ func(p *PostsService) DislikePost(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param spotifyID path string true "The user whos posts are recieved. Value is a spotify ID"
-// @Param createdAt query string false "Pagination Key. Format is UTC timestamp"
-// @Success 200 {object} responses.PostPreview
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.PostPreview]
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
@@ -293,25 +443,23 @@ func(p *PostsService) DislikePost(c *gin.Context) {
 // @Security Bearer
 func(p *PostsService) GetAllPostsForUserByID(c *gin.Context) {
 
+	currentUserSpotifyID, found := c.Get("spotifyID")
 	spotifyID := c.Param("spotifyID")
-	createdAt := c.Query("createdAt")
-
-	var t time.Time = time.Now().UTC()
-    var err error
 
-	if createdAt != "" {
-        t, err = time.Parse(time.RFC3339, createdAt)
+	if !found {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+		c.Abort()
+		return
+	}
 
-        if err != nil {
-            c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid time format"})
-            c.Abort()
-            return
-        }
+	page, err := cursor.ParsePageRequest(c)
 
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
-    paginationResponse := responses.PaginationResponse[[]responses.PostPreview, time.Time]{PaginationKey: time.Now().UTC()}
-
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
     if err != nil {
@@ -338,7 +486,7 @@ func(p *PostsService) GetAllPostsForUserByID(c *gin.Context) {
         return
     }
 
-    posts, err := p.PostsDAO.GetUserPostsProperties(tx, spotifyID, t)
+    posts, err := p.PostsDAO.GetUserPostsProperties(tx, spotifyID, currentUserSpotifyID.(string), page)
 
     if err != nil {
         c.Error(err)
@@ -346,20 +494,13 @@ func(p *PostsService) GetAllPostsForUserByID(c *gin.Context) {
         return
     }
 
-    for i := 0; i < len(posts); i++ {
-        likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, posts[i].SongID, spotifyID)
-        if err != nil {
-            c.Error(err)
-            c.Abort()
-            return
-        }
-        posts[i].Likes = likes
-        posts[i].Dislikes = dislikes
-    }
 
-    paginationResponse.DataResponse = posts
-    if len(posts) > 0 {
-        paginationResponse.PaginationKey = posts[len(posts)-1].CreatedAt
+    paginationResponse, err := cursor.BuildPage(posts, page, postKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
     }
 
     err = tx.Commit()
@@ -378,8 +519,9 @@ func(p *PostsService) GetAllPostsForUserByID(c *gin.Context) {
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param createdAt query string false "Pagination Key. Format is UTC timestamp"
-// @Success 200 {object} responses.PostPreview
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.PostPreview]
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 500 {string} string 
@@ -387,7 +529,6 @@ func(p *PostsService) GetAllPostsForUserByID(c *gin.Context) {
 // @Security Bearer
 func(p *PostsService) GetAllPostsForCurrentUser(c *gin.Context) {
 	spotifyID, spotifyIDExists := c.Get("spotifyID")
-	createdAt := c.Query("createdAt")
 
 	if !spotifyIDExists {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
@@ -395,22 +536,14 @@ func(p *PostsService) GetAllPostsForCurrentUser(c *gin.Context) {
 		return
 	}
 
-	var t time.Time = time.Now().UTC()
-    var err error
-
-	if createdAt != "" {
-        t, err = time.Parse(time.RFC3339, createdAt)
-
-        if err != nil {
-            c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid time format"})
-            c.Abort()
-            return
-        }
+	page, err := cursor.ParsePageRequest(c)
 
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
-    paginationResponse := responses.PaginationResponse[[]responses.PostPreview, time.Time]{PaginationKey: time.Now().UTC()}
-
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
     if err != nil {
@@ -436,7 +569,7 @@ func(p *PostsService) GetAllPostsForCurrentUser(c *gin.Context) {
         return
     }
 
-    posts, err := p.PostsDAO.GetUserPostsProperties(tx, spotifyID.(string), t)
+    posts, err := p.PostsDAO.GetUserPostsProperties(tx, spotifyID.(string), spotifyID.(string), page)
 
     if err != nil {
         c.Error(err)
@@ -444,20 +577,13 @@ func(p *PostsService) GetAllPostsForCurrentUser(c *gin.Context) {
         return
     }
 
-    for i := 0; i < len(posts); i++ {
-        likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, posts[i].SongID, spotifyID.(string))
-        if err != nil {
-            c.Error(err)
-            c.Abort()
-            return
-        }
-        posts[i].Likes = likes
-        posts[i].Dislikes = dislikes
-    }
 
-    paginationResponse.DataResponse = posts
-    if len(posts) > 0 {
-        paginationResponse.PaginationKey = posts[len(posts)-1].CreatedAt
+    paginationResponse, err := cursor.BuildPage(posts, page, postKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
     }
 
     err = tx.Commit()
@@ -470,24 +596,37 @@ func(p *PostsService) GetAllPostsForCurrentUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, paginationResponse)
 }
-// @Summary Get apath specific post
+// @Summary Get a specific post
 // @Description Get a specific post
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param spotifyID path string true "The user who posted the song"
-// @Param songID path string true "The songID of the posted song"
+// @Param postID path int true "The ID of the post"
 // @Success 200 {object} responses.PostPreview
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/{spotifyID}/{songID} [get]
+// @Router /posts/{postID} [get]
 // @Security Bearer
-func(p *PostsService) GetPostBySpotifyIDAndSongID(c *gin.Context) {
+func(p *PostsService) GetPostByID(c *gin.Context) {
 
-	spotifyID := c.Param("spotifyID")
-	songID := c.Param("songID")
+	currentUserSpotifyID, found := c.Get("spotifyID")
+
+	if !found {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+		c.Abort()
+		return
+	}
+
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
 
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
@@ -507,7 +646,7 @@ func(p *PostsService) GetPostBySpotifyIDAndSongID(c *gin.Context) {
         return
     }
 
-    post, err := p.PostsDAO.GetPostProperties(tx, songID, spotifyID)
+    post, err := p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, currentUserSpotifyID.(string))
 
     if err != nil {
         c.Error(err)
@@ -515,17 +654,6 @@ func(p *PostsService) GetPostBySpotifyIDAndSongID(c *gin.Context) {
         return
     }
 
-    likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, songID, spotifyID)
-
-    if err != nil {
-        c.Error(err)
-        c.Abort()
-        return
-    }
-
-    post.Likes = likes
-    post.Dislikes = dislikes
-
     err = tx.Commit()
 
     if err != nil {
@@ -538,13 +666,15 @@ func(p *PostsService) GetPostBySpotifyIDAndSongID(c *gin.Context) {
 	c.JSON(http.StatusOK, post)
 }
 
-// @Summary Get a specific post for the current user
-// @Description Get a specific post for the current user
+// @Summary Get the current users latest post about a subject
+// @Description Get the most recent post the current user made about a subject. Earlier posts about it are re-listened to and show up in the users post previews
 // @Tags Posts
 // @Accept json
 // @Produce json
 // @Param songID path string true "The songID of the posted song"
+// @Param subjectType query string false "The type of the posts subject, one of TRACK, ALBUM or ARTIST. Defaults to TRACK"
 // @Success 200 {object} responses.PostPreview
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
@@ -561,6 +691,14 @@ func(p *PostsService) GetPostCurrentUserBySongID(c *gin.Context) {
 		return
 	}
 
+	subjectType, err := validation.ParseSubjectTypeQuery(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
     if err != nil {
@@ -579,7 +717,7 @@ func(p *PostsService) GetPostCurrentUserBySongID(c *gin.Context) {
         return
     }
 
-    post, err := p.PostsDAO.GetPostProperties(tx, songID, currentUserSpotifyID.(string))
+    post, err := p.PostsDAO.GetLatestSubjectPost(tx, currentUserSpotifyID.(string), subjectType, songID, currentUserSpotifyID.(string))
 
     if err != nil {
         c.Error(err)
@@ -587,17 +725,6 @@ func(p *PostsService) GetPostCurrentUserBySongID(c *gin.Context) {
         return
     }
 
-    likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, songID, currentUserSpotifyID.(string))
-
-    if err != nil {
-        c.Error(err)
-        c.Abort()
-        return
-    }
-
-    post.Likes = likes
-    post.Dislikes = dislikes
-
     err = tx.Commit()
 
     if err != nil {
@@ -615,21 +742,34 @@ func(p *PostsService) GetPostCurrentUserBySongID(c *gin.Context) {
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param spotifyID path string true "The spotify ID of the user who posted the song"
-// @Param songID path string true "The songID of the posted song"
+// @Param postID path int true "The ID of the post"
 // @Success 204
 // @Failure 400 {string} string 
 // @Failure 403 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/admin/{spotifyID}/{songID} [delete]
+// @Router /posts/admin/{postID} [delete]
 // @Security Bearer
-func(p *PostsService) DeletePostBySpotifyIDAndSongID(c *gin.Context) {
+func(p *PostsService) DeletePostByID(c *gin.Context) {
 
-	spotifyID := c.Param("spotifyID")
-	songID := c.Param("songID")
+	requestorSpotifyID, found := c.Get("spotifyID")
 
-	err := p.PostsDAO.DeletePost(p.DB, songID, spotifyID)
+	if !found {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt"})
+		c.Abort()
+		return
+	}
+
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
+
+	err = p.deletePost(postIDParams.PostID, nil, requestorSpotifyID.(string))
 
 	if err != nil {
 		c.Error(err)
@@ -646,14 +786,15 @@ func(p *PostsService) DeletePostBySpotifyIDAndSongID(c *gin.Context) {
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param songID path string true "The songID of the posted song"
+// @Param postID path int true "The ID of the post"
 // @Success 204
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/current/{songID} [delete]
+// @Router /posts/current/{postID} [delete]
 // @Security Bearer
-func(p *PostsService) DeletePostForCurrentUserBySongID(c *gin.Context) {
+func(p *PostsService) DeletePostForCurrentUser(c *gin.Context) {
 
 	requestorSpotifyID, found := c.Get("spotifyID")
 
@@ -662,9 +803,18 @@ func(p *PostsService) DeletePostForCurrentUserBySongID(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	songID := c.Param("songID")
 
-	err := p.PostsDAO.DeletePost(p.DB, songID, requestorSpotifyID.(string))
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
+
+	posterSpotifyID := requestorSpotifyID.(string)
+	err = p.deletePost(postIDParams.PostID, &posterSpotifyID, requestorSpotifyID.(string))
 
 	if err != nil {
 		c.Error(err)
@@ -681,20 +831,29 @@ func(p *PostsService) DeletePostForCurrentUserBySongID(c *gin.Context) {
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param songID path string true "The songID of the posted song"
+// @Param postID path int true "The ID of the post"
 // @Param UpdatePostDTO body requests.UpdatePostRequestDTO true "The fields to update"
 // @Success 200 {object} responses.PostPreview
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/current/{songID} [patch]
+// @Router /posts/current/{postID} [patch]
 // @Security Bearer
 func(p *PostsService) UpdateCurrentUserPost(c *gin.Context) {
 
 	spotifyID, exists := c.Get("spotifyID")
 	spotifyUsername, uexists := c.Get("spotifyUsername")
-	songID := c.Param("songID")
+
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
+	}
+
 	updatePostReq := &requests.UpdatePostRequestDTO{}
 
 	c.ShouldBindBodyWithJSON(updatePostReq)
@@ -723,20 +882,23 @@ func(p *PostsService) UpdateCurrentUserPost(c *gin.Context) {
             return err
         }
 
-        post, err = p.PostsDAO.UpdatePost(tx, spotifyID.(string), songID, updatePostReq, spotifyUsername.(string))
+        post, err = p.PostsDAO.UpdatePost(tx, spotifyID.(string), postIDParams.PostID, updatePostReq, spotifyUsername.(string))
 
         if err != nil {
             return err
         }
 
-        likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, post.SongID, spotifyID.(string))
+        post, err = p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, spotifyID.(string))
 
         if err != nil {
             return err
         }
 
-        post.Likes = likes
-        post.Dislikes = dislikes
+        err = p.OutboxDAO.EnqueueEvent(tx, events.New(events.POST_UPDATED, events.NewPostPayload(post)))
+
+        if err != nil {
+            return err
+        }
 
         err = tx.Commit()
 
@@ -748,7 +910,7 @@ func(p *PostsService) UpdateCurrentUserPost(c *gin.Context) {
 
     }
 
-    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
 
     if err != nil {
         c.Error(err)
@@ -764,24 +926,33 @@ func(p *PostsService) UpdateCurrentUserPost(c *gin.Context) {
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param songID path string true "The songID of the posted song"
-// @Param posterSpotifyID path string true "The user who posted the post spotify ID"
+// @Param postID path int true "The ID of the post"
 // @Success 204
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/votes/current/{posterSpotifyID}/{songID} [delete]
+// @Router /posts/votes/current/{postID} [delete]
 // @Security Bearer
 func(p *PostsService) RemovePostVote(c *gin.Context) {
 	voterSpotifyID, found := c.Get("spotifyID")
-	posterSpotifyID := c.Param("posterSpotifyID")
-	songID := c.Param("songID")
 
 	if !found {
-		c.Error(customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "forgot to set JWT"})
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "forgot to set JWT"})
+		c.Abort()
+		return
+	}
+
+	postIDParams := &requests.PostIDPathParams{}
+	err := c.ShouldBindUri(postIDParams)
+
+	if err != nil {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+		c.Abort()
+		return
 	}
 
-	err := p.PostsDAO.RemovePostVote(p.DB, voterSpotifyID.(string), posterSpotifyID, songID)
+	err = p.PostsDAO.RemovePostVote(p.DB, voterSpotifyID.(string), postIDParams.PostID)
 
 	if err != nil {
 		c.Error(err)
@@ -794,42 +965,48 @@ func(p *PostsService) RemovePostVote(c *gin.Context) {
 }
 
 // @Summary Gets the comments of a post
-// @Description Gets the comments of a post
+// @Description Gets the top level comments of a post, newest first. Replies are not included, each comment carries a reply count and its replies are fetched from /comments/replies/{commentID}
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param songID path string true "The songID of the posted song"
-// @Param spotifyID path string true "The user who posted the post spotify ID"
-// @Param createdAt query string false "Pagination Key. In the form of UTC timestamp"
-// @Success 200 {object} responses.PaginationResponse[[]responses.Comment, time.Time]
+// @Param postID path int true "The ID of the post"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.Comment]
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/comments/{spotifyID}/{songID} [get]
+// @Router /posts/comments/{postID} [get]
 // @Security Bearer
 func(p *PostsService) GetPostCommentsPaginated(c *gin.Context) {
-    spotifyID := c.Param("spotifyID")
-    songID := c.Param("songID")
-    createdAt := c.Query("createdAt")
+    currentUserSpotifyID, found := c.Get("spotifyID")
 
-    var t time.Time = time.Now().UTC()
-    var err error
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
 
-	if createdAt != "" {
-		t, err = time.Parse(time.RFC3339, createdAt)
+    postIDParams := &requests.PostIDPathParams{}
+    err := c.ShouldBindUri(postIDParams)
 
-        if err != nil {
-            c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid time format"})
-            c.Abort()
-            return
-        }
+    if err != nil {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+        c.Abort()
+        return
+    }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
-    paginatedComments := responses.PaginationResponse[[]responses.Comment, time.Time]{PaginationKey: time.Now().UTC()}
-
     if err != nil {
         c.Error(customerrors.WrapBasicError(err))
         c.Abort()
@@ -846,7 +1023,7 @@ func(p *PostsService) GetPostCommentsPaginated(c *gin.Context) {
         return
     }
 
-    _, err = p.PostsDAO.GetPostProperties(tx, songID, spotifyID)
+    _, err = p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, currentUserSpotifyID.(string))
     
     if err != nil {
         c.Error(err)
@@ -854,28 +1031,23 @@ func(p *PostsService) GetPostCommentsPaginated(c *gin.Context) {
         return
     }
 
-    comments, err := p.PostsDAO.GetPostComments(tx, spotifyID, songID, t)
-
-    for i := 0; i < len(comments); i++ {
-        likes, dislikes, err := p.CommentsDAO.GetCommentVotes(tx, fmt.Sprint(comments[i].CommentID))
+    comments, err := p.PostsDAO.GetPostComments(tx, postIDParams.PostID, currentUserSpotifyID.(string), page)
 
-        if err != nil {
-            c.Error(err)
-            c.Abort()
-            return
-        }
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
 
-        comments[i].Likes = len(likes)
-        comments[i].Dislikes = len(dislikes)
 
-        if i == len(comments)-1 {
-            paginatedComments.PaginationKey = comments[i].CreatedAt
-        }
+    paginatedComments, err := cursor.BuildPage(comments, page, commentKey)
 
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
     }
 
-    paginatedComments.DataResponse = comments
-
     err = tx.Commit()
 
     if err != nil {
@@ -888,40 +1060,139 @@ func(p *PostsService) GetPostCommentsPaginated(c *gin.Context) {
 }
 
 
-// @Summary Gets the comments of a post
-// @Description Gets the comments of a post
+// @Summary Gets the users who voted on a post
+// @Description Gets the users who voted on a post along with how they voted. Post listings only carry vote counts, so this is where the voters themselves are found
 // @Tags Posts
 // @Accept json
 // @Produce json
-// @Param createdAt query string false "Pagination Key. In the form of UTC timestamp"
-// @Success 200 {object} responses.PaginationResponse[[]responses.PostPreview, time.Time]
+// @Param postID path int true "The ID of the post"
+// @Param vote query string false "Only return voters who voted this way. Either LIKE or DISLIKE"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.Voter]
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
-// @Router /posts/feed [get]
+// @Router /posts/votes/{postID} [get]
 // @Security Bearer
-func(p *PostsService) GetCurrentUserFeed(c *gin.Context) {
-    spotifyID, exists := c.Get("spotifyID")
-    createdAt := c.Query("createdAt")
+func(p *PostsService) GetPostVoters(c *gin.Context) {
+    currentUserSpotifyID, found := c.Get("spotifyID")
 
-	var t time.Time = time.Now().UTC()
-    var err error
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
 
-	if createdAt != "" {
-        t, err = time.Parse(time.RFC3339, createdAt)
+    postIDParams := &requests.PostIDPathParams{}
+    err := c.ShouldBindUri(postIDParams)
 
-        if err != nil {
-            c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid time format"})
-            c.Abort()
-            return
-        }
+    if err != nil {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad path parameter value"})
+        c.Abort()
+        return
+    }
+
+    vote, err := validation.ParseVoteQuery(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
+    tx, err := p.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    _, err = p.PostsDAO.GetPostProperties(tx, postIDParams.PostID, currentUserSpotifyID.(string))
+    
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    voters, err := p.PostsDAO.GetPostVoters(tx, postIDParams.PostID, vote, page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    paginatedVoters, err := cursor.BuildPage(voters, page, voterKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginatedVoters)
+}
+
+// @Summary Gets the current users feed
+// @Description Gets the posts of the users that the current user follows, newest first. Feeds are built when posts are created, so this is a single lookup
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.PostPreview]
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 404 {string} string 
+// @Failure 500 {string} string 
+// @Router /posts/feed [get]
+// @Security Bearer
+func(p *PostsService) GetCurrentUserFeed(c *gin.Context) {
+    spotifyID, exists := c.Get("spotifyID")
+
     if !exists {
         c.Error(customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "failed to set JWT"})
         c.Abort()
         return
     }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
     
     tx, err := p.DB.BeginTx(context.Background(), nil)
 
@@ -931,7 +1202,87 @@ func(p *PostsService) GetCurrentUserFeed(c *gin.Context) {
         return
     }
 
-    following, err := p.UsersDAO.GetAllUserFollowing(tx, spotifyID.(string))
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    posts, err := p.FeedDAO.GetFeed(tx, spotifyID.(string), page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    paginationResponse, err := cursor.BuildPage(posts, page, postKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, paginationResponse)
+
+}
+
+// @Summary Gets the reviews of an album
+// @Description Gets the reviews of an album along with the reviews of each of its tracks, newest first. SubjectType tells them apart
+// @Tags Posts
+// @Accept json
+// @Produce json
+// @Param albumID path string true "The Spotify ID of the album"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.PostPreview]
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 500 {string} string 
+// @Router /posts/albums/{albumID} [get]
+// @Security Bearer
+func(p *PostsService) GetAlbumPostsPaginated(c *gin.Context) {
+
+    currentUserSpotifyID, found := c.Get("spotifyID")
+    albumID := c.Param("albumID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+    posts, err := p.PostsDAO.GetAlbumPosts(p.DB, albumID, currentUserSpotifyID.(string), page)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    paginationResponse, err := cursor.BuildPage(posts, page, postKey)
 
     if err != nil {
         c.Error(err)
@@ -939,60 +1290,104 @@ func(p *PostsService) GetCurrentUserFeed(c *gin.Context) {
         return
     }
 
-    posts := []responses.PostPreview{}
+    c.JSON(http.StatusOK, paginationResponse)
+}
+
+// Deletes the post, which must have been made by posterSpotifyID when it is set. Posts made by someone else are
+// reported as not found
+func(p *PostsService) deletePost(postID int64, posterSpotifyID *string, requestorSpotifyID string) error {
 
-    for _, user_followed := range following {
+    transaction := func() error {
 
-        user_posts, err := p.PostsDAO.GetUserPostsProperties(tx, user_followed.SpotifyID, t)
+        tx, err := p.DB.BeginTx(context.Background(), nil)
 
         if err != nil {
-            c.Error(err)
-            c.Abort()
-            return
+            return customerrors.WrapBasicError(err)
         }
 
-        posts = append(posts, user_posts...)
+        defer tx.Rollback()
 
-    }
+        post, err := p.PostsDAO.GetPostProperties(tx, postID, requestorSpotifyID)
 
-    slices.SortFunc[[]responses.PostPreview](posts, func(p1, p2 responses.PostPreview) int {
-        if p1.CreatedAt.After(p2.CreatedAt) {
-            return -1
+        if err != nil {
+            return err
         }
-        return 1
-    })
 
-    feedLength := 25
+        if posterSpotifyID != nil && post.SpotifyID != *posterSpotifyID {
+            return customerrors.WrapBasicError(sql.ErrNoRows)
+        }
 
-    if len(posts) < feedLength {
-        feedLength = len(posts)
-    }
+        err = p.PostsDAO.DeletePost(tx, postID)
+
+        if err != nil {
+            return err
+        }
 
-    posts = posts[:feedLength]
+        payload := events.PostDeletedPayload{
+            PostID: post.PostID,
+            PosterSpotifyID: post.SpotifyID,
+            SubjectType: post.SubjectType,
+            SongID: post.SongID,
+            DeletedBySpotifyID: requestorSpotifyID,
+        }
 
-    for i := 0; i < len(posts); i++ {
-        likes, dislikes, err := p.PostsDAO.GetPostVotes(tx, posts[i].SongID, posts[i].SpotifyID)
+        err = p.OutboxDAO.EnqueueEvent(tx, events.New(events.POST_DELETED, payload))
 
         if err != nil {
-            c.Error(err)
-            c.Abort()
-            return
+            return err
         }
 
-        posts[i].Likes = likes
-        posts[i].Dislikes = dislikes
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
 
+        return nil
     }
 
-    paginationResponse := responses.PaginationResponse[[]responses.PostPreview, time.Time]{DataResponse: posts}
-    paginationKey := time.Now().UTC()
+    return db.RunTransactionWithExponentialBackoff(transaction, 5)
+}
+
+// Publishes post.liked or post.disliked, and returns the post and the event so it can be pushed to the poster. Only
+// called once the vote has been recorded, so switching a vote publishes an event but repeating one does not
+func(p *PostsService) publishVote(tx *sql.Tx, eventType events.EventType, voterSpotifyID string, voterUsername string, postID int64) (*responses.PostPreview, events.IEvent, error) {
+
+    post, err := p.PostsDAO.GetPostProperties(tx, postID, voterSpotifyID)
+
+    if err != nil {
+        return nil, nil, err
+    }
 
-    if feedLength > 0 {
-        paginationKey = posts[feedLength-1].CreatedAt
+    payload := events.PostVotePayload{
+        VoterSpotifyID: voterSpotifyID,
+        VoterUsername: voterUsername,
+        PostID: post.PostID,
+        PosterSpotifyID: post.SpotifyID,
+        SubjectType: post.SubjectType,
+        SongID: post.SongID,
+        SubjectName: post.SubjectName,
+        SongName: post.SongName,
     }
 
-    paginationResponse.PaginationKey = paginationKey
+    event := events.New(eventType, payload)
+    err = p.OutboxDAO.EnqueueEvent(tx, event)
 
-    c.JSON(http.StatusOK, paginationResponse)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return post, event, nil
+}
+
+func postKey(post responses.PostPreview) (string, string) {
+    return cursor.TimeKey(post.CreatedAt), fmt.Sprint(post.PostID)
+}
+
+func commentKey(comment responses.Comment) (string, string) {
+    return cursor.TimeKey(comment.CreatedAt), fmt.Sprint(comment.CommentID)
+}
 
+func voterKey(voter responses.Voter) (string, string) {
+    return voter.SpotifyID, voter.SpotifyID
 }
@@ -1,76 +1,447 @@
 package rabbitmqservice
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
 	"os"
-	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"sync"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/rabbitmq/amqp091-go"
 )
 
-type QueueMessageType string 
+const EMAIL_QUEUE = "emails.v1"
 
 const (
-	POST QueueMessageType = "POST"
+    DEFAULT_CHANNEL_POOL_SIZE = 4
+    MIN_RECONNECT_DELAY = 500 * time.Millisecond
+    MAX_RECONNECT_DELAY = 30 * time.Second
 )
-type RabbitMQPostMessage struct {
-    Type QueueMessageType
-    Poster string
+
+// The events each queue is bound to on the events exchange
+var queueBindings = map[string][]string{
+    EMAIL_QUEUE: {"email.instant.v1", "email.digest.v1"},
+}
+
+// Bindings that have since been replaced. Queues outlive the app, so old bindings have to be removed
+// explicitly or the queue keeps receiving both
+var retiredQueueBindings = map[string][]string{}
+
+// Queues that have since been replaced, along with everything they were bound to. They are unbound as soon as the app
+// connects, so they stop receiving messages, and deleted once their consumers have emptied them. emailQueue was not
+// durable, so it lost whatever was in it whenever the broker restarted
+var retiredQueues = map[string][]string{
+    "emailQueue": {"email.instant.v1", "email.digest.v1", "post.created.v1"},
 }
 
+var ErrNotConnected = errors.New("not connected to rabbitmq")
+
+// Keeps a connection to the broker open, reconnecting with backoff whenever it is lost. Publishing goes through a pool
+// of confirm mode channels, since a channel cannot be published to from more than one goroutine at a time
 type RabbitMQService struct {
-    Conn *amqp091.Connection
-    Chan *amqp091.Channel
-    QName string
+    PoolSize int
+
+    mu sync.RWMutex
+    conn *amqp091.Connection
+    pool chan *confirmChannel
+    health responses.RabbitMQHealth
+    done chan struct{}
+    closeOnce sync.Once
 }
 
 type IRabbitMQService interface {
-    Connect() 
-    Enqueue(v any) error
+    Connect()
+    Close()
+    Health() responses.RabbitMQHealth
+    Publish(ctx context.Context, exchange string, routingKey string, body []byte) error
 }
-func(rmq *RabbitMQService) Enqueue(v any) error {
-    bytes, err := json.Marshal(v)
-    if err != nil {
-        return customerrors.WrapBasicError(err)
+
+// A channel in confirm mode, along with where it hands back mandatory messages that could not be routed
+type confirmChannel struct {
+    ch *amqp091.Channel
+    returns chan amqp091.Return
+}
+
+// Starts connecting in the background and returns straight away, so the app can start while the broker is
+// unreachable. Publishing fails with ErrNotConnected until the first connection is made
+func(rmq *RabbitMQService) Connect() {
+
+    if rmq.PoolSize < 1 {
+        rmq.PoolSize = DEFAULT_CHANNEL_POOL_SIZE
     }
-    return rmq.Chan.Publish(
-        "",
-        rmq.QName,
-        false,
+
+    rmq.done = make(chan struct{})
+    rmq.setState(responses.RABBITMQ_CONNECTING, nil)
+
+    go rmq.maintainConnection(os.Getenv("RABBIT_MQ_CONNECTION_STRING"))
+}
+
+// Stops reconnecting and closes the connection, along with every channel in the pool
+func(rmq *RabbitMQService) Close() {
+    rmq.closeOnce.Do(func() {
+        close(rmq.done)
+
+        rmq.mu.Lock()
+        defer rmq.mu.Unlock()
+
+        if rmq.conn != nil {
+            rmq.conn.Close()
+        }
+
+        rmq.health.State = responses.RABBITMQ_CLOSED
+    })
+}
+
+func(rmq *RabbitMQService) Health() responses.RabbitMQHealth {
+    rmq.mu.RLock()
+    defer rmq.mu.RUnlock()
+    return rmq.health
+}
+
+// Publishes a JSON message and waits for the broker to confirm it. Messages should not be published directly from
+// request handlers, they are written to the outbox and published from there.
+//
+// Messages for the queues declared here are published as mandatory, and fail if the broker cannot route them to a
+// queue, so they stay in the outbox rather than being dropped. Any other event goes to whoever has subscribed to it,
+// and one nobody has subscribed to is not a failure
+func(rmq *RabbitMQService) Publish(ctx context.Context, exchange string, routingKey string, body []byte) error {
+
+    rmq.mu.RLock()
+    conn := rmq.conn
+    pool := rmq.pool
+    connected := rmq.health.State == responses.RABBITMQ_CONNECTED
+    rmq.mu.RUnlock()
+
+    if !connected {
+        return ErrNotConnected
+    }
+
+    var ch *confirmChannel
+
+    select {
+    case ch = <-pool:
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+
+    defer rmq.release(conn, pool, ch)
+
+    // A message returned after an earlier publish gave up waiting would otherwise be taken for this one
+    drainReturns(ch.returns)
+
+    confirmation, err := ch.ch.PublishWithDeferredConfirmWithContext(
+        ctx,
+        exchange,
+        routingKey,
+        isMandatory(exchange, routingKey),
         false,
         amqp091.Publishing{
             ContentType: "application/json",
-            Body: bytes,
+            DeliveryMode: amqp091.Persistent,
+            Body: body,
         },
     )
+
+    if err != nil {
+        return err
+    }
+
+    acked, err := confirmation.WaitContext(ctx)
+
+    if err != nil {
+        return err
+    }
+
+    if !acked {
+        return errors.New("message was nacked by the broker")
+    }
+
+    // The broker returns an unroutable message before confirming it, and returns are handed over in the order they
+    // arrive, so a return for this message is already waiting by the time the confirm is
+    select {
+    case returned := <-ch.returns:
+        return fmt.Errorf("message was returned by the broker: %s", returned.ReplyText)
+    default:
+    }
+
+    return nil
 }
 
-func(rmq *RabbitMQService) Connect() {
-    connectionString := os.Getenv("RABBIT_MQ_CONNECTION_STRING")
+// Hands a channel back to the pool it came from. A channel closed by a channel level error is replaced, as long as
+// its connection is still open. Channels from a connection that has since been replaced are dropped along with it
+func(rmq *RabbitMQService) release(conn *amqp091.Connection, pool chan *confirmChannel, ch *confirmChannel) {
+
+    if ch.ch.IsClosed() {
+
+        if conn.IsClosed() {
+            return
+        }
+
+        replacement, err := openConfirmChannel(conn)
+
+        if err != nil {
+            log.Printf("rabbitmq: failed to replace closed channel: %s", err.Error())
+            return
+        }
+
+        ch = replacement
+    }
+
+    pool <- ch
+}
+
+func(rmq *RabbitMQService) maintainConnection(url string) {
+
+    attempt := 0
+
+    for {
+        conn, pool, err := rmq.dial(url)
+
+        if err != nil {
+            log.Printf("rabbitmq: failed to connect: %s", err.Error())
+            rmq.setState(responses.RABBITMQ_DISCONNECTED, err)
+
+            select {
+            case <-rmq.done:
+                return
+            case <-time.After(reconnectDelay(attempt)):
+                attempt++
+                continue
+            }
+        }
+
+        attempt = 0
+        closed := conn.NotifyClose(make(chan *amqp091.Error, 1))
+
+        rmq.mu.Lock()
+        rmq.conn = conn
+        rmq.pool = pool
+        rmq.health.State = responses.RABBITMQ_CONNECTED
+        rmq.health.LastError = ""
+        now := time.Now().UTC()
+        rmq.health.ConnectedAt = &now
+        rmq.mu.Unlock()
+
+        select {
+        case <-rmq.done:
+            conn.Close()
+            return
+        case amqpErr := <-closed:
+            if amqpErr == nil {
+                amqpErr = amqp091.ErrClosed
+            }
+            log.Printf("rabbitmq: connection lost: %s", amqpErr.Error())
+            rmq.setState(responses.RABBITMQ_DISCONNECTED, amqpErr)
+            rmq.mu.Lock()
+            rmq.health.Reconnects++
+            rmq.mu.Unlock()
+        }
+    }
+}
+
+// Opens a connection, declares the topology and fills a new channel pool
+func(rmq *RabbitMQService) dial(url string) (*amqp091.Connection, chan *confirmChannel, error) {
+
+    conn, err := amqp091.Dial(url)
+
+    if err != nil {
+        return nil, nil, err
+    }
 
-    conn, err := amqp091.Dial(connectionString)
+    err = declareTopology(conn)
 
     if err != nil {
-        panic(err.Error())
+        conn.Close()
+        return nil, nil, err
     }
 
-    rmq.Conn = conn
+    pool := make(chan *confirmChannel, rmq.PoolSize)
+
+    for range rmq.PoolSize {
+        ch, err := openConfirmChannel(conn)
+
+        if err != nil {
+            conn.Close()
+            return nil, nil, err
+        }
+
+        pool <- ch
+    }
+
+    return conn, pool, nil
+}
+
+// Declared on every connect, so the exchange and queues are recreated if the broker lost them
+func declareTopology(conn *amqp091.Connection) error {
 
     ch, err := conn.Channel()
 
     if err != nil {
-        panic(err.Error())
+        return err
     }
 
-    rmq.Chan = ch
-    rmq.QName = "emailQueue"
+    defer ch.Close()
 
-    _, err = ch.QueueDeclare(
-      rmq.QName, 
-      false,   
-      false,   
-      false,  
-      false, 
-      nil,  
+    err = ch.ExchangeDeclare(
+      events.EXCHANGE,
+      amqp091.ExchangeTopic,
+      true,
+      false,
+      false,
+      false,
+      nil,
     )
+
+    if err != nil {
+        return err
+    }
+
+    for queue, routingKeys := range queueBindings {
+
+        _, err = ch.QueueDeclare(
+          queue,
+          true,
+          false,
+          false,
+          false,
+          nil,
+        )
+
+        if err != nil {
+            return err
+        }
+
+        for _, routingKey := range routingKeys {
+            err = ch.QueueBind(queue, routingKey, events.EXCHANGE, false, nil)
+
+            if err != nil {
+                return err
+            }
+        }
+
+        for _, routingKey := range retiredQueueBindings[queue] {
+            err = ch.QueueUnbind(queue, routingKey, events.EXCHANGE, nil)
+
+            if err != nil {
+                return err
+            }
+        }
+    }
+
+    for queue, routingKeys := range retiredQueues {
+        retireQueue(conn, queue, routingKeys)
+    }
+
+    return nil
+}
+
+// Unbinds the queue and deletes it if it is empty. The broker closes the channel when the queue does not exist or still
+// has messages in it, so each queue gets a channel of its own, and a queue that cannot be deleted yet is left for the
+// next connect
+func retireQueue(conn *amqp091.Connection, queue string, routingKeys []string) {
+
+    ch, err := conn.Channel()
+
+    if err != nil {
+        log.Printf("rabbitmq: failed to open a channel to retire %s: %s", queue, err.Error())
+        return
+    }
+
+    defer ch.Close()
+
+    _, err = ch.QueueDeclarePassive(queue, false, false, false, false, nil)
+
+    if err != nil {
+        // Already deleted
+        return
+    }
+
+    for _, routingKey := range routingKeys {
+        err = ch.QueueUnbind(queue, routingKey, events.EXCHANGE, nil)
+
+        if err != nil {
+            log.Printf("rabbitmq: failed to unbind retired queue %s: %s", queue, err.Error())
+            return
+        }
+    }
+
+    _, err = ch.QueueDelete(queue, false, true, false)
+
+    if err != nil {
+        log.Printf("rabbitmq: leaving retired queue %s until its consumers have emptied it: %s", queue, err.Error())
+    }
+}
+
+// Whether the message is meant for one of the queues declared here
+func isMandatory(exchange string, routingKey string) bool {
+
+    if exchange != events.EXCHANGE {
+        return false
+    }
+
+    for _, routingKeys := range queueBindings {
+        for _, boundKey := range routingKeys {
+            if boundKey == routingKey {
+                return true
+            }
+        }
+    }
+
+    return false
 }
 
+func drainReturns(returns chan amqp091.Return) {
+    for {
+        select {
+        case <-returns:
+        default:
+            return
+        }
+    }
+}
+
+func openConfirmChannel(conn *amqp091.Connection) (*confirmChannel, error) {
+
+    ch, err := conn.Channel()
+
+    if err != nil {
+        return nil, err
+    }
+
+    err = ch.Confirm(false)
+
+    if err != nil {
+        ch.Close()
+        return nil, err
+    }
+
+    // Only one message is in flight on a channel at a time, so there is never more than one return to wait for
+    returns := ch.NotifyReturn(make(chan amqp091.Return, 1))
+
+    return &confirmChannel{ch: ch, returns: returns}, nil
+}
+
+func(rmq *RabbitMQService) setState(state responses.RabbitMQConnectionState, err error) {
+    rmq.mu.Lock()
+    defer rmq.mu.Unlock()
+
+    rmq.health.State = state
+
+    if err != nil {
+        rmq.health.LastError = err.Error()
+    }
+}
+
+// Exponential backoff with 20% jitter, so instances that lost the broker together do not all reconnect at once
+func reconnectDelay(attempt int) time.Duration {
+    delay := float64(MIN_RECONNECT_DELAY) * math.Pow(2, float64(attempt))
+    delay = math.Min(delay, float64(MAX_RECONNECT_DELAY))
+    jitter := delay * 0.2 * (rand.Float64()*2 - 1)
+    return time.Duration(delay + jitter)
+}
@@ -0,0 +1,432 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+    REDIS_CHANNEL_PREFIX = "realtime:"
+    REDIS_DISCONNECT_CHANNEL_PREFIX = "realtime-disconnect:"
+    DEFAULT_SEND_BUFFER_SIZE = 64
+    DEFAULT_HEARTBEAT_INTERVAL = 30 * time.Second
+    WRITE_TIMEOUT = 10 * time.Second
+    PUBLISH_TIMEOUT = 5 * time.Second
+)
+
+// An event to push to every connection of each of the recipients
+type Push struct {
+    Recipients []string
+    Event events.IEvent
+}
+
+// Pushes events to connected clients over WebSockets, or server sent events for clients that cannot use them. Events
+// are published to a Redis channel per user, and every instance delivers the ones for the users connected to it, so a
+// user gets their events no matter which instance they are connected to. Revoking a session is published the same way,
+// so its connections are closed wherever they are
+type RealtimeService struct {
+    Redis *redis.Client
+    DB *sql.DB
+    SessionsDAO daos.ISessionsDAO
+    SendBufferSize int
+    HeartbeatInterval time.Duration
+
+    mu sync.RWMutex
+    connections map[string]map[*connection]struct{}
+}
+
+type IRealtimeService interface {
+    Publish(pushes ...Push)
+    DisconnectSessions(spotifyID string, sessionIDs ...string)
+    Run(ctx context.Context)
+    Stream(c *gin.Context)
+    CreateStreamTicket(c *gin.Context)
+    ValidateStreamTicket(c *gin.Context)
+}
+
+type redisMessage struct {
+    EventType events.EventType
+    Event json.RawMessage
+}
+
+type connection struct {
+    sessionID string
+    send chan redisMessage
+    closed chan struct{}
+    closeOnce sync.Once
+    // Sent to WebSocket clients in the close frame. Only read once closed is
+    closeCode int
+    closeText string
+}
+
+// Closes the connection for falling behind or going away, which the client should recover from by reconnecting
+func(conn *connection) close() {
+    conn.closeWith(websocket.CloseTryAgainLater, "connection closed, reconnect and refetch")
+}
+
+// Only the first reason a connection is closed for is sent to the client
+func(conn *connection) closeWith(code int, text string) {
+    conn.closeOnce.Do(func() {
+        conn.closeCode = code
+        conn.closeText = text
+        close(conn.closed)
+    })
+}
+
+// Publishes the pushes to Redis. Pushing is best effort, clients are expected to refetch whatever they missed when
+// they reconnect, so failures are logged rather than returned. Call this after the transaction behind the events has
+// committed
+func(r *RealtimeService) Publish(pushes ...Push) {
+
+    ctx, cancel := context.WithTimeout(context.Background(), PUBLISH_TIMEOUT)
+    defer cancel()
+
+    pipe := r.Redis.Pipeline()
+    queued := 0
+
+    for _, push := range pushes {
+
+        event, err := json.Marshal(push.Event)
+
+        if err != nil {
+            log.Printf("realtime: failed to marshal %s event: %s", push.Event.Type(), err.Error())
+            continue
+        }
+
+        message, err := json.Marshal(redisMessage{EventType: push.Event.Type(), Event: event})
+
+        if err != nil {
+            log.Printf("realtime: failed to marshal %s event: %s", push.Event.Type(), err.Error())
+            continue
+        }
+
+        recipients := slices.Clone(push.Recipients)
+        slices.Sort(recipients)
+
+        for _, recipient := range slices.Compact(recipients) {
+            pipe.Publish(ctx, REDIS_CHANNEL_PREFIX+recipient, message)
+            queued++
+        }
+    }
+
+    if queued == 0 {
+        return
+    }
+
+    _, err := pipe.Exec(ctx)
+
+    if err != nil {
+        log.Printf("realtime: failed to publish events: %s", err.Error())
+    }
+}
+
+// Closes the connections of the sessions on every instance. Without any sessionIDs, every connection of the user is
+// closed. Call this after the sessions have been revoked, so the client cannot get a ticket to reconnect with
+func(r *RealtimeService) DisconnectSessions(spotifyID string, sessionIDs ...string) {
+
+    ctx, cancel := context.WithTimeout(context.Background(), PUBLISH_TIMEOUT)
+    defer cancel()
+
+    message, err := json.Marshal(sessionIDs)
+
+    if err != nil {
+        log.Printf("realtime: failed to marshal sessions to disconnect: %s", err.Error())
+        return
+    }
+
+    err = r.Redis.Publish(ctx, REDIS_DISCONNECT_CHANNEL_PREFIX+spotifyID, message).Err()
+
+    if err != nil {
+        log.Printf("realtime: failed to publish sessions to disconnect: %s", err.Error())
+    }
+}
+
+// Delivers the events published by every instance to the connections on this one, until ctx is cancelled. Meant to be
+// run in its own goroutine
+func(r *RealtimeService) Run(ctx context.Context) {
+
+    pubsub := r.Redis.PSubscribe(ctx, REDIS_CHANNEL_PREFIX+"*", REDIS_DISCONNECT_CHANNEL_PREFIX+"*")
+    defer pubsub.Close()
+
+    messages := pubsub.Channel()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case msg, ok := <-messages:
+            if !ok {
+                return
+            }
+
+            if spotifyID, found := strings.CutPrefix(msg.Channel, REDIS_DISCONNECT_CHANNEL_PREFIX); found {
+                sessionIDs := []string{}
+                err := json.Unmarshal([]byte(msg.Payload), &sessionIDs)
+
+                if err != nil {
+                    log.Printf("realtime: dropping malformed disconnect: %s", err.Error())
+                    continue
+                }
+
+                r.disconnect(spotifyID, sessionIDs)
+                continue
+            }
+
+            message := redisMessage{}
+            err := json.Unmarshal([]byte(msg.Payload), &message)
+
+            if err != nil {
+                log.Printf("realtime: dropping malformed message: %s", err.Error())
+                continue
+            }
+
+            r.deliver(strings.TrimPrefix(msg.Channel, REDIS_CHANNEL_PREFIX), message)
+        }
+    }
+}
+
+// @Summary Streams events for the current user
+// @Description Streams new posts from followed users, comments and replies on the current users posts and comments, votes on their posts and notifications as they happen. Upgrades to a WebSocket when asked to, otherwise falls back to server sent events. Every message is an event envelope, and server sent events are named after the event type. Clients that fall too far behind are disconnected and should reconnect and refetch. Connections are authenticated with a ticket from /stream/tickets, and are closed when the access JWT the ticket was issued with expires or the session is revoked
+// @Tags Realtime
+// @Produce json
+// @Produce text/event-stream
+// @Param ticket query string true "A ticket from /stream/tickets"
+// @Success 101
+// @Success 200 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /stream [get]
+func(r *RealtimeService) Stream(c *gin.Context) {
+
+    spotifyID, spotifyIDFound := c.Get("spotifyID")
+    sessionID, sessionIDFound := c.Get("sessionID")
+    accessTokenExpiresAt, expiresAtFound := c.Get("accessTokenExpiresAt")
+
+    if !spotifyIDFound || !sessionIDFound || !expiresAtFound {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad ticket lookup"})
+        c.Abort()
+        return
+    }
+
+    if websocket.IsWebSocketUpgrade(c.Request) {
+        r.serveWebSocket(c, spotifyID.(string), sessionID.(string), accessTokenExpiresAt.(time.Time))
+        return
+    }
+
+    r.serveServerSentEvents(c, spotifyID.(string), sessionID.(string), accessTokenExpiresAt.(time.Time))
+}
+
+func(r *RealtimeService) serveWebSocket(c *gin.Context, spotifyID string, sessionID string, expiresAt time.Time) {
+
+    upgrader := websocket.Upgrader{
+        CheckOrigin: func(req *http.Request) bool {
+            origin := req.Header.Get("Origin")
+            return origin == "" || origin == os.Getenv("FRONTEND_URI")
+        },
+    }
+
+    ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+
+    if err != nil {
+        // The upgrader has already responded
+        return
+    }
+
+    defer ws.Close()
+
+    conn := r.register(spotifyID, sessionID)
+    defer r.unregister(spotifyID, conn)
+
+    expiry := time.AfterFunc(time.Until(expiresAt), func() {
+        conn.closeWith(websocket.ClosePolicyViolation, "access token expired, get a new ticket and reconnect")
+    })
+    defer expiry.Stop()
+
+    heartbeat := r.heartbeatInterval()
+
+    // Clients only ever send pongs and close frames, but reading is what processes them
+    go func() {
+        defer conn.close()
+
+        ws.SetReadLimit(512)
+        ws.SetReadDeadline(time.Now().Add(2 * heartbeat))
+        ws.SetPongHandler(func(string) error {
+            return ws.SetReadDeadline(time.Now().Add(2 * heartbeat))
+        })
+
+        for {
+            if _, _, err := ws.NextReader(); err != nil {
+                return
+            }
+        }
+    }()
+
+    ticker := time.NewTicker(heartbeat)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case message := <-conn.send:
+            ws.SetWriteDeadline(time.Now().Add(WRITE_TIMEOUT))
+
+            if err := ws.WriteMessage(websocket.TextMessage, message.Event); err != nil {
+                return
+            }
+        case <-ticker.C:
+            if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(WRITE_TIMEOUT)); err != nil {
+                return
+            }
+        case <-conn.closed:
+            closeMessage := websocket.FormatCloseMessage(conn.closeCode, conn.closeText)
+            ws.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(WRITE_TIMEOUT))
+            return
+        }
+    }
+}
+
+// Server sent events have no way to say why the stream ended, so clients should get a new ticket whenever it does
+func(r *RealtimeService) serveServerSentEvents(c *gin.Context, spotifyID string, sessionID string, expiresAt time.Time) {
+
+    c.Header("Content-Type", "text/event-stream")
+    c.Header("Cache-Control", "no-cache")
+    c.Header("Connection", "keep-alive")
+    c.Header("X-Accel-Buffering", "no")
+    c.Status(http.StatusOK)
+    c.Writer.Flush()
+
+    conn := r.register(spotifyID, sessionID)
+    defer r.unregister(spotifyID, conn)
+
+    expiry := time.NewTimer(time.Until(expiresAt))
+    defer expiry.Stop()
+
+    ticker := time.NewTicker(r.heartbeatInterval())
+    defer ticker.Stop()
+
+    for {
+        select {
+        case message := <-conn.send:
+            if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", message.EventType, message.Event); err != nil {
+                return
+            }
+            c.Writer.Flush()
+        case <-ticker.C:
+            if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+                return
+            }
+            c.Writer.Flush()
+        case <-expiry.C:
+            return
+        case <-conn.closed:
+            return
+        case <-c.Request.Context().Done():
+            return
+        }
+    }
+}
+
+// Hands the message to each of the users connections. A connection whose buffer is full is closed rather than
+// waited on, so one slow client cannot hold up everyone else
+func(r *RealtimeService) deliver(spotifyID string, message redisMessage) {
+
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for conn := range r.connections[spotifyID] {
+        select {
+        case conn.send <- message:
+        default:
+            log.Printf("realtime: closing connection for %s, it has fallen too far behind", spotifyID)
+            conn.close()
+        }
+    }
+}
+
+// Closes the connections of the sessions on this instance, or every connection of the user when there are no sessionIDs
+func(r *RealtimeService) disconnect(spotifyID string, sessionIDs []string) {
+
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for conn := range r.connections[spotifyID] {
+        if len(sessionIDs) == 0 || slices.Contains(sessionIDs, conn.sessionID) {
+            conn.closeWith(websocket.ClosePolicyViolation, "session has been revoked, log in again")
+        }
+    }
+}
+
+func(r *RealtimeService) register(spotifyID string, sessionID string) *connection {
+
+    bufferSize := r.SendBufferSize
+
+    if bufferSize < 1 {
+        bufferSize = DEFAULT_SEND_BUFFER_SIZE
+    }
+
+    conn := &connection{sessionID: sessionID, send: make(chan redisMessage, bufferSize), closed: make(chan struct{})}
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.connections == nil {
+        r.connections = make(map[string]map[*connection]struct{})
+    }
+
+    if r.connections[spotifyID] == nil {
+        r.connections[spotifyID] = make(map[*connection]struct{})
+    }
+
+    r.connections[spotifyID][conn] = struct{}{}
+
+    return conn
+}
+
+func(r *RealtimeService) unregister(spotifyID string, conn *connection) {
+
+    conn.close()
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    delete(r.connections[spotifyID], conn)
+
+    if len(r.connections[spotifyID]) == 0 {
+        delete(r.connections, spotifyID)
+    }
+}
+
+func(r *RealtimeService) heartbeatInterval() time.Duration {
+    if r.HeartbeatInterval <= 0 {
+        return DEFAULT_HEARTBEAT_INTERVAL
+    }
+    return r.HeartbeatInterval
+}
+
+func NotificationPush(notificationID int64, recipientSpotifyID string, notificationType responses.NotificationType, actorSpotifyID string) Push {
+
+    payload := events.NotificationPayload{
+        NotificationID: notificationID,
+        RecipientSpotifyID: recipientSpotifyID,
+        NotificationType: notificationType,
+        ActorSpotifyID: actorSpotifyID,
+    }
+
+    return Push{Recipients: []string{recipientSpotifyID}, Event: events.New(events.NOTIFICATION_CREATED, payload)}
+}
@@ -0,0 +1,141 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+    REDIS_TICKET_PREFIX = "realtime-ticket:"
+    // Long enough to open the connection straight after asking for the ticket, and no longer
+    STREAM_TICKET_TTL = 30 * time.Second
+)
+
+var invalidStreamTicketError = &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "stream ticket is missing, used or expired, get a new one"}
+
+// What a ticket stands in for. The connection it opens is closed at AccessTokenExpiresAt, the same time the access JWT
+// the ticket was issued for stops working
+type streamTicket struct {
+    SpotifyID string
+    SessionID string
+    AccessTokenExpiresAt time.Time
+}
+
+// @Summary Issues a ticket for connecting to /stream
+// @Description EventSource and WebSockets in the browser cannot set the Authorization header, so /stream is authenticated with a ticket passed as the ticket query parameter instead. A ticket can only be used once and only for a short while, so it is useless once it has turned up in a log. The connection it opens is closed when the access JWT the ticket was issued with expires
+// @Tags Realtime
+// @Produce json
+// @Success 201 {object} responses.StreamTicket
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /stream/tickets [post]
+// @Security Bearer
+func(r *RealtimeService) CreateStreamTicket(c *gin.Context) {
+
+    spotifyID, spotifyIDFound := c.Get("spotifyID")
+    sessionID, sessionIDFound := c.Get("sessionID")
+    accessTokenExpiresAt, expiresAtFound := c.Get("accessTokenExpiresAt")
+
+    if !spotifyIDFound || !sessionIDFound || !expiresAtFound {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    bytes := make([]byte, 32)
+
+    _, err := rand.Read(bytes)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    ticket := base64.RawURLEncoding.EncodeToString(bytes)
+
+    value, err := json.Marshal(streamTicket{SpotifyID: spotifyID.(string), SessionID: sessionID.(string), AccessTokenExpiresAt: accessTokenExpiresAt.(time.Time)})
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    err = r.Redis.Set(c.Request.Context(), REDIS_TICKET_PREFIX+ticket, value, STREAM_TICKET_TTL).Err()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusCreated, responses.StreamTicket{Ticket: ticket, ExpiresAt: time.Now().Add(STREAM_TICKET_TTL)})
+}
+
+// Swaps the ticket query parameter for the user and session it was issued to. The ticket is deleted as it is read, so
+// it cannot be used twice, and the session has to still be active
+func(r *RealtimeService) ValidateStreamTicket(c *gin.Context) {
+
+    ticket := c.Query("ticket")
+
+    if ticket == "" {
+        c.Error(invalidStreamTicketError)
+        c.Abort()
+        return
+    }
+
+    value, err := r.Redis.GetDel(c.Request.Context(), REDIS_TICKET_PREFIX+ticket).Bytes()
+
+    if errors.Is(err, redis.Nil) {
+        c.Error(invalidStreamTicketError)
+        c.Abort()
+        return
+    }
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    claims := &streamTicket{}
+    err = json.Unmarshal(value, claims)
+
+    if err != nil || time.Now().After(claims.AccessTokenExpiresAt) {
+        c.Error(invalidStreamTicketError)
+        c.Abort()
+        return
+    }
+
+    session, err := r.SessionsDAO.GetActiveSession(r.DB, claims.SessionID)
+
+    if err == nil && session.SpotifyID != claims.SpotifyID {
+        err = invalidStreamTicketError
+    }
+
+    if err != nil {
+        if customError, ok := err.(*customerrors.CustomError); ok && customError.StatusCode == http.StatusNotFound {
+            err = invalidStreamTicketError
+        }
+
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.Set("spotifyID", claims.SpotifyID)
+    c.Set("sessionID", claims.SessionID)
+    c.Set("accessTokenExpiresAt", claims.AccessTokenExpiresAt)
+
+    c.Next()
+}
@@ -0,0 +1,204 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Jack-Gitter/tunes/cursor"
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const MAX_QUERY_LENGTH = 256
+
+type SearchService struct {
+    DB *sql.DB
+    SearchDAO daos.ISearchDAO
+    StorageService storage.IStorageService
+}
+
+type ISearchService interface {
+    Search(c *gin.Context)
+}
+
+// @Summary Searches posts, comments and users
+// @Description Full text search over song names, album names, reviews, comments, usernames and bios. Results are split into a section per type and ordered by rank, with matches highlighted in each snippet. Without a type every section is searched and the first page of each is returned. To page through a section, pass its type along with one of its cursors
+// @Tags Search
+// @Accept json
+// @Produce json
+// @Param q query string true "The search terms. Quoted phrases, OR and -excluded words are supported"
+// @Param type query string false "Only search one section. One of posts, comments or users"
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page. Requires type"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.SearchResults
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 500 {string} string 
+// @Router /search [get]
+// @Security Bearer
+func(s *SearchService) Search(c *gin.Context) {
+
+    spotifyID, exists := c.Get("spotifyID")
+    query := strings.TrimSpace(c.Query("q"))
+    searchType := responses.SearchType(c.Query("type"))
+
+    if !exists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    if query == "" || len(query) > MAX_QUERY_LENGTH {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("q must be between 1 and %d characters", MAX_QUERY_LENGTH)})
+        c.Abort()
+        return
+    }
+
+    if searchType != "" && !responses.IsValidSearchType(searchType) {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "type must be one of posts, comments or users"})
+        c.Abort()
+        return
+    }
+
+    page, err := cursor.ParsePageRequest(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    if page.Cursor != nil && searchType == "" {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "type is required when using a cursor"})
+        c.Abort()
+        return
+    }
+
+    tx, err := s.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    results := responses.SearchResults{}
+
+    if searchType == "" || searchType == responses.POST_SEARCH {
+
+        posts, err := s.SearchDAO.SearchPosts(tx, query, spotifyID.(string), page)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        paginatedPosts, err := cursor.BuildPage(posts, page, postResultKey)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        results.Posts = &paginatedPosts
+    }
+
+    if searchType == "" || searchType == responses.COMMENT_SEARCH {
+
+        comments, err := s.SearchDAO.SearchComments(tx, query, spotifyID.(string), page)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        paginatedComments, err := cursor.BuildPage(comments, page, commentResultKey)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        results.Comments = &paginatedComments
+    }
+
+    if searchType == "" || searchType == responses.USER_SEARCH {
+
+        users, err := s.SearchDAO.SearchUsers(tx, query, page)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        paginatedUsers, err := cursor.BuildPage(users, page, userResultKey)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        for i := range paginatedUsers.DataResponse {
+            user := &paginatedUsers.DataResponse[i]
+            user.ProfileImage, err = storage.ProfileImageURLs(c.Request.Context(), s.StorageService, user.ProfileImage)
+
+            if err != nil {
+                c.Error(err)
+                c.Abort()
+                return
+            }
+        }
+
+        results.Users = &paginatedUsers
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        c.Error(customerrors.WrapBasicError(err))
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, results)
+}
+
+func rankKey(rank float64) string {
+    return strconv.FormatFloat(rank, 'g', -1, 64)
+}
+
+func postResultKey(result responses.PostSearchResult) (string, string) {
+    return rankKey(result.Rank), fmt.Sprint(result.PostID)
+}
+
+func commentResultKey(result responses.CommentSearchResult) (string, string) {
+    return rankKey(result.Rank), fmt.Sprint(result.CommentID)
+}
+
+func userResultKey(result responses.UserSearchResult) (string, string) {
+    return rankKey(result.Rank), result.SpotifyID
+}
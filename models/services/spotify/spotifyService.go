@@ -1,149 +1,586 @@
 package spotify
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 )
 
-type SpotifyService struct { }
+const (
+	// The most tracks, albums and artists Spotify returns from one call to GetTracksFromSpotify, GetAlbumsFromSpotify
+	// and GetArtistsFromSpotify
+	MAX_TRACKS_PER_REQUEST  = 50
+	MAX_ALBUMS_PER_REQUEST  = 20
+	MAX_ARTISTS_PER_REQUEST = 50
+	// Spotify only remembers this many of a users most recent plays
+	MAX_RECENTLY_PLAYED       = 50
+	DEFAULT_ACCOUNTS_BASE_URL = "https://accounts.spotify.com"
+	DEFAULT_API_BASE_URL      = "https://api.spotify.com/v1"
+	DEFAULT_TIMEOUT           = 10 * time.Second
+	DEFAULT_MAX_RETRIES       = 3
+	MIN_RETRY_DELAY           = 200 * time.Millisecond
+	MAX_RETRY_DELAY           = 5 * time.Second
+	// A Retry-After longer than this is not waited out, the request fails straight away instead
+	MAX_RETRY_AFTER    = 10 * time.Second
+	MAX_RESPONSE_BYTES = 1 << 20
+)
+
+// Shared by every SpotifyService that is not given a client of its own, so connections are reused
+var defaultClient = &http.Client{Timeout: DEFAULT_TIMEOUT}
+
+// Talks to the Spotify accounts service and Web API over one shared client. Failed calls are mapped to CustomErrors,
+// rate limited calls are retried once Retry-After has passed and other transient failures are retried with jittered
+// backoff. The base URLs can be pointed somewhere else, e.g. at an httptest server
+type SpotifyService struct {
+	Client          *http.Client
+	AccountsBaseURL string
+	APIBaseURL      string
+	MaxRetries      int
+}
 
 type ISpotifyService interface {
-    RetrieveInitialAccessToken(authorizationCode string) (*responses.AccessTokenResponnse, error) 
-    RetrieveUserProfile(accessToken string) (*responses.ProfileResponse, error) 
-    RetreiveAccessTokenFromRefreshToken(spotifyRefreshToken string) (*responses.RefreshTokenResponse, error) 
-    GetSongDetailsFromSpotify(songID string, spotifyAccessToken string) (*responses.SongResponse, error)
+	AuthorizeURL(state string, codeChallenge string) string
+	RetrieveInitialAccessToken(ctx context.Context, authorizationCode string, codeVerifier string) (*responses.AccessTokenResponnse, error)
+	RetrieveUserProfile(ctx context.Context, accessToken string) (*responses.ProfileResponse, error)
+	RetreiveAccessTokenFromRefreshToken(ctx context.Context, spotifyRefreshToken string) (*responses.RefreshTokenResponse, error)
+	GetSongDetailsFromSpotify(ctx context.Context, songID string, spotifyAccessToken string) (*responses.SongResponse, error)
+	GetTracksFromSpotify(ctx context.Context, songIDs []string, spotifyAccessToken string) ([]responses.SongResponse, error)
+	GetAlbumDetailsFromSpotify(ctx context.Context, albumID string, spotifyAccessToken string) (*responses.AlbumResponse, error)
+	GetAlbumsFromSpotify(ctx context.Context, albumIDs []string, spotifyAccessToken string) ([]responses.AlbumResponse, error)
+	GetArtistDetailsFromSpotify(ctx context.Context, artistID string, spotifyAccessToken string) (*responses.ArtistResponse, error)
+	GetArtistsFromSpotify(ctx context.Context, artistIDs []string, spotifyAccessToken string) ([]responses.ArtistResponse, error)
+	RetrieveAppAccessToken(ctx context.Context) (*responses.AppAccessTokenResponse, error)
+	GetRecentlyPlayed(ctx context.Context, spotifyAccessToken string) ([]responses.PlayHistoryResponse, error)
+}
+
+// Describes one call. Requests are rebuilt for every attempt, since a body cannot be read twice
+type spotifyRequest struct {
+	method     string
+	url        string
+	form       url.Values
+	bearer     string
+	idempotent bool
+	notFound   string
 }
 
-func(s *SpotifyService) RetrieveInitialAccessToken(authorizationCode string) (*responses.AccessTokenResponnse, error) {
+// The accounts service describes errors with error and error_description, the Web API with an error object
+type spotifyErrorResponse struct {
+	Error             json.RawMessage
+	Error_description string
+}
 
-	queryParamsMap := url.Values{}
-	queryParamsMap.Add("grant_type", "authorization_code")
-	queryParamsMap.Add("code", authorizationCode)
-	queryParamsMap.Add("redirect_uri", os.Getenv("REDIRECT_URI"))
-	queryParams := queryParamsMap.Encode()
+type spotifyAPIError struct {
+	Status  int
+	Message string
+}
 
-	basicAuthToken := fmt.Sprintf("%s:%s", os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"))
-	encodedBasicAuthToken := base64.StdEncoding.EncodeToString([]byte(basicAuthToken))
+// Where users are sent to log in with Spotify. codeChallenge is the S256 PKCE challenge for the code verifier that
+// will be handed to RetrieveInitialAccessToken
+func (s *SpotifyService) AuthorizeURL(state string, codeChallenge string) string {
 
-	accessTokenRequest, err := http.NewRequest(http.MethodPost, "https://accounts.spotify.com/api/token", bytes.NewBuffer([]byte(queryParams)))
+	// SCOPES is kept URL encoded in the environment
+	scope, err := url.QueryUnescape(os.Getenv("SCOPES"))
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		scope = os.Getenv("SCOPES")
 	}
 
-	accessTokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	accessTokenRequest.Header.Set("Authorization", fmt.Sprintf("Basic %s", encodedBasicAuthToken))
+	query := url.Values{}
+	query.Add("response_type", "code")
+	query.Add("client_id", os.Getenv("CLIENT_ID"))
+	query.Add("scope", scope)
+	query.Add("redirect_uri", os.Getenv("REDIRECT_URI"))
+	query.Add("state", state)
+	query.Add("code_challenge_method", "S256")
+	query.Add("code_challenge", codeChallenge)
+
+	return s.accountsURL("/authorize?" + query.Encode())
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(accessTokenRequest)
+func (s *SpotifyService) RetrieveInitialAccessToken(ctx context.Context, authorizationCode string, codeVerifier string) (*responses.AccessTokenResponnse, error) {
 
-	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+	if authorizationCode == "" {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "missing authorization code"}
 	}
 
-	defer resp.Body.Close()
+	form := url.Values{}
+	form.Add("grant_type", "authorization_code")
+	form.Add("code", authorizationCode)
+	form.Add("redirect_uri", os.Getenv("REDIRECT_URI"))
+	form.Add("code_verifier", codeVerifier)
+
 	accessTokenResponseBody := &responses.AccessTokenResponnse{}
-	json.NewDecoder(resp.Body).Decode(accessTokenResponseBody)
+
+	// An authorization code can only be exchanged once, so this is only retried when Spotify turned it away unread
+	err := s.do(ctx, spotifyRequest{method: http.MethodPost, url: s.accountsURL("/api/token"), form: form}, accessTokenResponseBody)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if accessTokenResponseBody.Access_token == "" || accessTokenResponseBody.Refresh_token == "" {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "spotify did not return the tokens"}
+	}
 
 	return accessTokenResponseBody, nil
 }
 
-func(s *SpotifyService) RetrieveUserProfile(accessToken string) (*responses.ProfileResponse, error) {
+func (s *SpotifyService) RetrieveUserProfile(ctx context.Context, accessToken string) (*responses.ProfileResponse, error) {
 
-	nReq, err := http.NewRequest(http.MethodGet, "https://api.spotify.com/v1/me", &bytes.Buffer{})
+	profileResponse := &responses.ProfileResponse{}
+
+	err := s.do(ctx, spotifyRequest{method: http.MethodGet, url: s.apiURL("/me"), bearer: accessToken, idempotent: true}, profileResponse)
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		return nil, err
+	}
+
+	if profileResponse.Id == "" {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "spotify did not return a user ID"}
 	}
 
-	nReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	return profileResponse, nil
+}
+
+func (s *SpotifyService) RetreiveAccessTokenFromRefreshToken(ctx context.Context, spotifyRefreshToken string) (*responses.RefreshTokenResponse, error) {
 
-	client := &http.Client{}
-	nResp, err := client.Do(nReq)
+	form := url.Values{}
+	form.Add("grant_type", "refresh_token")
+	form.Add("refresh_token", spotifyRefreshToken)
+
+	accessTokenResponseBody := &responses.RefreshTokenResponse{}
+
+	err := s.do(ctx, spotifyRequest{method: http.MethodPost, url: s.accountsURL("/api/token"), form: form, idempotent: true}, accessTokenResponseBody)
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		return nil, err
+	}
+
+	if accessTokenResponseBody.Access_token == "" {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "spotify did not return an access token"}
 	}
 
-	defer nResp.Body.Close()
-	respJson2 := &responses.ProfileResponse{}
+	return accessTokenResponseBody, nil
+}
+
+func (s *SpotifyService) GetSongDetailsFromSpotify(ctx context.Context, songID string, spotifyAccessToken string) (*responses.SongResponse, error) {
+
+	request := spotifyRequest{
+		method:     http.MethodGet,
+		url:        s.apiURL(fmt.Sprintf("/tracks/%s", url.PathEscape(songID))),
+		bearer:     spotifyAccessToken,
+		idempotent: true,
+		notFound:   "Song with spotify ID not found",
+	}
 
-	json.NewDecoder(nResp.Body).Decode(respJson2)
+	spotifySongResponse := &responses.SongResponse{}
 
-	return respJson2, nil
+	err := s.do(ctx, request, spotifySongResponse)
 
+	if err != nil {
+		return nil, err
+	}
+
+	return spotifySongResponse, nil
 }
 
-func(s *SpotifyService) RetreiveAccessTokenFromRefreshToken(spotifyRefreshToken string) (*responses.RefreshTokenResponse, error) {
+// Tracks that Spotify does not know of are left out, so fewer tracks than IDs may come back
+func (s *SpotifyService) GetTracksFromSpotify(ctx context.Context, songIDs []string, spotifyAccessToken string) ([]responses.SongResponse, error) {
 
-	queryParamsMap := url.Values{}
-	queryParamsMap.Add("grant_type", "refresh_token")
-	queryParamsMap.Add("refresh_token", spotifyRefreshToken)
-	queryParams := queryParamsMap.Encode()
+	if len(songIDs) > MAX_TRACKS_PER_REQUEST {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: fmt.Sprintf("cannot get more than %d tracks at once", MAX_TRACKS_PER_REQUEST)}
+	}
 
-	basicAuthToken := fmt.Sprintf("%s:%s", os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"))
-	encodedBasicAuthToken := base64.StdEncoding.EncodeToString([]byte(basicAuthToken))
+	query := url.Values{}
+	query.Add("ids", strings.Join(songIDs, ","))
 
-	accessTokenRefreshRequest, _ := http.NewRequest(http.MethodPost, "https://accounts.spotify.com/api/token", bytes.NewBuffer([]byte(queryParams)))
-	accessTokenRefreshRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	accessTokenRefreshRequest.Header.Set("Authorization", fmt.Sprintf("Basic %s", encodedBasicAuthToken))
+	tracksResponse := &responses.TracksResponse{}
 
-	client := &http.Client{}
-	resp, err := client.Do(accessTokenRefreshRequest)
+	err := s.do(ctx, spotifyRequest{method: http.MethodGet, url: s.apiURL("/tracks?" + query.Encode()), bearer: spotifyAccessToken, idempotent: true}, tracksResponse)
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		return nil, err
 	}
 
-	defer resp.Body.Close()
+	tracks := []responses.SongResponse{}
 
-	accessTokenResponseBody := &responses.RefreshTokenResponse{}
-	json.NewDecoder(resp.Body).Decode(accessTokenResponseBody)
+	for _, track := range tracksResponse.Tracks {
+		if track != nil {
+			tracks = append(tracks, *track)
+		}
+	}
 
-	return accessTokenResponseBody, nil
+	return tracks, nil
+}
+
+func (s *SpotifyService) GetAlbumDetailsFromSpotify(ctx context.Context, albumID string, spotifyAccessToken string) (*responses.AlbumResponse, error) {
+
+	request := spotifyRequest{
+		method:     http.MethodGet,
+		url:        s.apiURL(fmt.Sprintf("/albums/%s", url.PathEscape(albumID))),
+		bearer:     spotifyAccessToken,
+		idempotent: true,
+		notFound:   "Album with spotify ID not found",
+	}
 
+	albumResponse := &responses.AlbumResponse{}
+
+	err := s.do(ctx, request, albumResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return albumResponse, nil
 }
 
-func(s *SpotifyService) GetSongDetailsFromSpotify(songID string, spotifyAccessToken string) (*responses.SongResponse, error) {
+// Albums that Spotify does not know of are left out, so fewer albums than IDs may come back
+func (s *SpotifyService) GetAlbumsFromSpotify(ctx context.Context, albumIDs []string, spotifyAccessToken string) ([]responses.AlbumResponse, error) {
+
+	if len(albumIDs) > MAX_ALBUMS_PER_REQUEST {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: fmt.Sprintf("cannot get more than %d albums at once", MAX_ALBUMS_PER_REQUEST)}
+	}
+
+	query := url.Values{}
+	query.Add("ids", strings.Join(albumIDs, ","))
+
+	albumsResponse := &responses.AlbumsResponse{}
 
-	url := fmt.Sprintf("https://api.spotify.com/v1/tracks/%s", songID)
-	songRequest, err := http.NewRequest(http.MethodGet, url, nil)
+	err := s.do(ctx, spotifyRequest{method: http.MethodGet, url: s.apiURL("/albums?" + query.Encode()), bearer: spotifyAccessToken, idempotent: true}, albumsResponse)
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		return nil, err
+	}
+
+	albums := []responses.AlbumResponse{}
+
+	for _, album := range albumsResponse.Albums {
+		if album != nil {
+			albums = append(albums, *album)
+		}
+	}
+
+	return albums, nil
+}
+
+func (s *SpotifyService) GetArtistDetailsFromSpotify(ctx context.Context, artistID string, spotifyAccessToken string) (*responses.ArtistResponse, error) {
+
+	request := spotifyRequest{
+		method:     http.MethodGet,
+		url:        s.apiURL(fmt.Sprintf("/artists/%s", url.PathEscape(artistID))),
+		bearer:     spotifyAccessToken,
+		idempotent: true,
+		notFound:   "Artist with spotify ID not found",
 	}
 
-	songRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", spotifyAccessToken))
+	artistResponse := &responses.ArtistResponse{}
 
-	client := &http.Client{}
-	resp, err := client.Do(songRequest)
+	err := s.do(ctx, request, artistResponse)
 
 	if err != nil {
-		return nil, customerrors.WrapBasicError(err)
+		return nil, err
 	}
 
-	defer resp.Body.Close()
+	return artistResponse, nil
+}
+
+// Artists that Spotify does not know of are left out, so fewer artists than IDs may come back
+func (s *SpotifyService) GetArtistsFromSpotify(ctx context.Context, artistIDs []string, spotifyAccessToken string) ([]responses.ArtistResponse, error) {
+
+	if len(artistIDs) > MAX_ARTISTS_PER_REQUEST {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: fmt.Sprintf("cannot get more than %d artists at once", MAX_ARTISTS_PER_REQUEST)}
+	}
+
+	query := url.Values{}
+	query.Add("ids", strings.Join(artistIDs, ","))
+
+	artistsResponse := &responses.ArtistsResponse{}
 
-	if resp.StatusCode != 200 {
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return nil, &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "Song with spotify ID not found"}
-		} else {
-            return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "spotify failed not 200"}
+	err := s.do(ctx, spotifyRequest{method: http.MethodGet, url: s.apiURL("/artists?" + query.Encode()), bearer: spotifyAccessToken, idempotent: true}, artistsResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	artists := []responses.ArtistResponse{}
+
+	for _, artist := range artistsResponse.Artists {
+		if artist != nil {
+			artists = append(artists, *artist)
 		}
 	}
 
-	spotifySongResponse := &responses.SongResponse{}
-	bodyString, _ := io.ReadAll(resp.Body)
-	json.Unmarshal(bodyString, spotifySongResponse)
+	return artists, nil
+}
 
-	return spotifySongResponse, nil
+// Gets a token for the app itself, for reading the catalog when there is no user around to read it as
+func (s *SpotifyService) RetrieveAppAccessToken(ctx context.Context) (*responses.AppAccessTokenResponse, error) {
+
+	form := url.Values{}
+	form.Add("grant_type", "client_credentials")
 
+	appAccessTokenResponse := &responses.AppAccessTokenResponse{}
+
+	err := s.do(ctx, spotifyRequest{method: http.MethodPost, url: s.accountsURL("/api/token"), form: form, idempotent: true}, appAccessTokenResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if appAccessTokenResponse.Access_token == "" {
+		return nil, &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "spotify did not return an access token"}
+	}
+
+	return appAccessTokenResponse, nil
+}
+
+// Gets the tracks the user most recently played, newest first. The access token needs the user-read-recently-played
+// scope, and Spotify answers with a 403 when it does not have it
+func (s *SpotifyService) GetRecentlyPlayed(ctx context.Context, spotifyAccessToken string) ([]responses.PlayHistoryResponse, error) {
+
+	query := url.Values{}
+	query.Add("limit", strconv.Itoa(MAX_RECENTLY_PLAYED))
+
+	recentlyPlayedResponse := &responses.RecentlyPlayedResponse{}
+
+	err := s.do(ctx, spotifyRequest{method: http.MethodGet, url: s.apiURL("/me/player/recently-played?" + query.Encode()), bearer: spotifyAccessToken, idempotent: true}, recentlyPlayedResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return recentlyPlayedResponse.Items, nil
+}
+
+// Sends the request and decodes a successful response into out. Rate limited requests are retried after Retry-After,
+// as long as it is short enough. Idempotent requests are also retried on network errors and 5xx responses
+func (s *SpotifyService) do(ctx context.Context, request spotifyRequest, out any) error {
+
+	for attempt := 0; ; attempt++ {
+
+		resp, err := s.send(ctx, request)
+
+		if err != nil {
+			if ctx.Err() != nil || !request.idempotent || attempt >= s.maxRetries() {
+				return transportError(err)
+			}
+
+			if err := sleep(ctx, retryDelay(attempt)); err != nil {
+				return transportError(err)
+			}
+
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+
+			err := json.NewDecoder(io.LimitReader(resp.Body, MAX_RESPONSE_BYTES)).Decode(out)
+
+			if err != nil {
+				return &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: "malformed response from spotify"}
+			}
+
+			return nil
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_BYTES))
+		resp.Body.Close()
+
+		delay, retryable := time.Duration(0), false
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			delay, retryable = retryAfter(resp.Header.Get("Retry-After"), attempt)
+		} else if resp.StatusCode >= 500 && request.idempotent {
+			delay, retryable = retryDelay(attempt), true
+		}
+
+		if !retryable || attempt >= s.maxRetries() {
+			return statusError(request, resp.StatusCode, body)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return transportError(err)
+		}
+	}
+}
+
+func (s *SpotifyService) send(ctx context.Context, request spotifyRequest) (*http.Response, error) {
+
+	var body io.Reader
+
+	if request.form != nil {
+		body = strings.NewReader(request.form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.method, request.url, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.form != nil {
+		basicAuthToken := fmt.Sprintf("%s:%s", os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(basicAuthToken))))
+	}
+
+	if request.bearer != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", request.bearer))
+	}
+
+	return s.client().Do(req)
+}
+
+// Maps a failed response onto the error the caller of tunes should see. Problems with what the user gave us, like an
+// expired code or token or a song that does not exist, are passed on. Anything else is Spotify's fault, or ours, e.g.
+// bad client credentials, so is reported as a bad gateway
+func statusError(request spotifyRequest, statusCode int, body []byte) error {
+
+	reason := spotifyErrorReason(body)
+	tokenRequest := request.form != nil
+
+	switch {
+	case tokenRequest && statusCode == http.StatusBadRequest:
+		return &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: fmt.Sprintf("spotify rejected the authorization: %s", reason)}
+	case statusCode == http.StatusUnauthorized:
+		return &customerrors.CustomError{StatusCode: http.StatusUnauthorized, Msg: "spotify access token is invalid or expired"}
+	case statusCode == http.StatusForbidden:
+		return &customerrors.CustomError{StatusCode: http.StatusForbidden, Msg: fmt.Sprintf("spotify refused the request: %s", reason)}
+	case request.notFound != "" && (statusCode == http.StatusNotFound || statusCode == http.StatusBadRequest):
+		return &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: request.notFound}
+	case statusCode == http.StatusTooManyRequests:
+		return &customerrors.CustomError{StatusCode: http.StatusServiceUnavailable, Msg: "spotify rate limit exceeded, try again later"}
+	}
+
+	return &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: fmt.Sprintf("spotify responded with %d: %s", statusCode, reason)}
+}
+
+func transportError(err error) error {
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return &customerrors.CustomError{StatusCode: http.StatusGatewayTimeout, Msg: "timed out waiting for spotify"}
+	}
+
+	var netErr interface{ Timeout() bool }
+
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &customerrors.CustomError{StatusCode: http.StatusGatewayTimeout, Msg: "timed out waiting for spotify"}
+	}
+
+	return &customerrors.CustomError{StatusCode: http.StatusBadGateway, Msg: fmt.Sprintf("could not reach spotify: %s", err.Error())}
+}
+
+func spotifyErrorReason(body []byte) string {
+
+	errorResponse := spotifyErrorResponse{}
+
+	if json.Unmarshal(body, &errorResponse) != nil {
+		return "unknown error"
+	}
+
+	if errorResponse.Error_description != "" {
+		return errorResponse.Error_description
+	}
+
+	apiError := spotifyAPIError{}
+
+	if json.Unmarshal(errorResponse.Error, &apiError) == nil && apiError.Message != "" {
+		return apiError.Message
+	}
+
+	reason := ""
+
+	if json.Unmarshal(errorResponse.Error, &reason) == nil && reason != "" {
+		return reason
+	}
+
+	return "unknown error"
+}
+
+// Retry-After is usually a number of seconds, but can be an HTTP date. Falls back to the usual backoff when it is
+// missing, and gives up when it is longer than MAX_RETRY_AFTER
+func retryAfter(header string, attempt int) (time.Duration, bool) {
+
+	if header == "" {
+		return retryDelay(attempt), true
+	}
+
+	delay := time.Duration(0)
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	} else {
+		return retryDelay(attempt), true
+	}
+
+	if delay > MAX_RETRY_AFTER {
+		return 0, false
+	}
+
+	return max(delay, 0), true
+}
+
+// Exponential backoff with full jitter, so requests that failed together do not all retry at once
+func retryDelay(attempt int) time.Duration {
+	delay := float64(MIN_RETRY_DELAY) * math.Pow(2, float64(attempt))
+	delay = math.Min(delay, float64(MAX_RETRY_DELAY))
+	return time.Duration(delay/2 + rand.Float64()*delay/2)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *SpotifyService) client() *http.Client {
+	if s.Client == nil {
+		return defaultClient
+	}
+	return s.Client
+}
+
+func (s *SpotifyService) maxRetries() int {
+	if s.MaxRetries < 0 {
+		return 0
+	}
+	return s.MaxRetries
+}
+
+func (s *SpotifyService) accountsURL(path string) string {
+	if s.AccountsBaseURL == "" {
+		return DEFAULT_ACCOUNTS_BASE_URL + path
+	}
+	return strings.TrimSuffix(s.AccountsBaseURL, "/") + path
+}
+
+func (s *SpotifyService) apiURL(path string) string {
+	if s.APIBaseURL == "" {
+		return DEFAULT_API_BASE_URL + path
+	}
+	return strings.TrimSuffix(s.APIBaseURL, "/") + path
 }
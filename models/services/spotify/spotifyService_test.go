@@ -0,0 +1,156 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+)
+
+func TestRetryAfter(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		header    string
+		retryable bool
+		minDelay  time.Duration
+		maxDelay  time.Duration
+	}{
+		{name: "seconds", header: "2", retryable: true, minDelay: 2 * time.Second, maxDelay: 2 * time.Second},
+		{name: "zero seconds", header: "0", retryable: true, minDelay: 0, maxDelay: 0},
+		{name: "negative seconds", header: "-5", retryable: true, minDelay: 0, maxDelay: 0},
+		{name: "too long", header: "60", retryable: false},
+		{name: "http date", header: time.Now().Add(3 * time.Second).UTC().Format(http.TimeFormat), retryable: true, minDelay: time.Second, maxDelay: 3 * time.Second},
+		{name: "http date in the past", header: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), retryable: true, minDelay: 0, maxDelay: 0},
+		{name: "http date too far away", header: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), retryable: false},
+		{name: "missing", header: "", retryable: true, minDelay: MIN_RETRY_DELAY / 2, maxDelay: MIN_RETRY_DELAY},
+		{name: "invalid", header: "soon", retryable: true, minDelay: MIN_RETRY_DELAY / 2, maxDelay: MIN_RETRY_DELAY},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			delay, retryable := retryAfter(test.header, 0)
+
+			if retryable != test.retryable {
+				t.Fatalf("retryable = %v, want %v", retryable, test.retryable)
+			}
+
+			if retryable && (delay < test.minDelay || delay > test.maxDelay) {
+				t.Fatalf("delay = %v, want between %v and %v", delay, test.minDelay, test.maxDelay)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := retryDelay(attempt)
+
+		if delay < MIN_RETRY_DELAY/2 || delay > MAX_RETRY_DELAY {
+			t.Fatalf("attempt %d: delay = %v, want between %v and %v", attempt, delay, MIN_RETRY_DELAY/2, MAX_RETRY_DELAY)
+		}
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+
+	tests := []struct {
+		name string
+		// The status of each response in turn. Once they run out, the last one is repeated
+		statuses   []int
+		retryAfter string
+		maxRetries int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, maxRetries: 3, wantCalls: 1},
+		{name: "rate limited then success", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, retryAfter: "0", maxRetries: 3, wantCalls: 2},
+		{name: "rate limited for too long", statuses: []int{http.StatusTooManyRequests}, retryAfter: "60", maxRetries: 3, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		{name: "rate limited past max retries", statuses: []int{http.StatusTooManyRequests}, retryAfter: "0", maxRetries: 2, wantStatus: http.StatusServiceUnavailable, wantCalls: 3},
+		{name: "server errors then success", statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, maxRetries: 3, wantCalls: 3},
+		{name: "server errors past max retries", statuses: []int{http.StatusInternalServerError}, maxRetries: 1, wantStatus: http.StatusBadGateway, wantCalls: 2},
+		{name: "no retries", statuses: []int{http.StatusInternalServerError}, maxRetries: 0, wantStatus: http.StatusBadGateway, wantCalls: 1},
+		{name: "client errors are not retried", statuses: []int{http.StatusUnauthorized}, maxRetries: 3, wantStatus: http.StatusUnauthorized, wantCalls: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			calls := atomic.Int32{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				call := int(calls.Add(1)) - 1
+				status := test.statuses[min(call, len(test.statuses)-1)]
+
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+
+				w.WriteHeader(status)
+
+				if status == http.StatusOK {
+					w.Write([]byte(`{"id": "spotify-user", "display_name": "Spotify User"}`))
+				}
+			}))
+			defer server.Close()
+
+			spotifyService := &SpotifyService{Client: server.Client(), APIBaseURL: server.URL, MaxRetries: test.maxRetries}
+
+			profile, err := spotifyService.RetrieveUserProfile(context.Background(), "access-token")
+
+			if calls.Load() != test.wantCalls {
+				t.Fatalf("spotify was called %d times, want %d", calls.Load(), test.wantCalls)
+			}
+
+			if test.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if profile.Id != "spotify-user" {
+					t.Fatalf("profile ID = %q, want %q", profile.Id, "spotify-user")
+				}
+
+				return
+			}
+
+			customError, ok := err.(*customerrors.CustomError)
+
+			if !ok {
+				t.Fatalf("error = %v, want a CustomError", err)
+			}
+
+			if customError.StatusCode != test.wantStatus {
+				t.Fatalf("status = %d, want %d", customError.StatusCode, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDoStopsRetryingWhenContextIsDone(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	spotifyService := &SpotifyService{Client: server.Client(), APIBaseURL: server.URL, MaxRetries: 100}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := spotifyService.RetrieveUserProfile(ctx, "access-token")
+
+	customError, ok := err.(*customerrors.CustomError)
+
+	if !ok || customError.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("error = %v, want a gateway timeout", err)
+	}
+}
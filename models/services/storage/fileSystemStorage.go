@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/gin-gonic/gin"
+)
+
+// Stores objects on local disk and serves them over the /storage route. When the storage is public, objects
+// can be read without a signature, like a public-read bucket. Otherwise, only presigned URLs are served
+type FileSystemStorage struct {
+    Root string
+    BaseURL string
+    SigningSecret []byte
+    Public bool
+}
+
+func NewFileSystemStorage() (*FileSystemStorage, error) {
+
+    root := os.Getenv("STORAGE_FS_ROOT")
+    baseURL := os.Getenv("STORAGE_FS_BASE_URL")
+    signingSecret := os.Getenv("STORAGE_FS_SIGNING_SECRET")
+    public := os.Getenv("STORAGE_FS_PUBLIC") != "false"
+
+    if root == "" {
+        root = "./storage"
+    }
+
+    if baseURL == "" {
+        baseURL = fmt.Sprintf("http://localhost:%s/storage", os.Getenv("PORT"))
+    }
+
+    if signingSecret == "" && !public {
+        return nil, errors.New("STORAGE_FS_SIGNING_SECRET must be set when filesystem storage is not public")
+    }
+
+    root, err := filepath.Abs(root)
+
+    if err != nil {
+        return nil, err
+    }
+
+    err = os.MkdirAll(root, 0o755)
+
+    if err != nil {
+        return nil, err
+    }
+
+    return &FileSystemStorage{
+        Root: root,
+        BaseURL: strings.TrimSuffix(baseURL, "/"),
+        SigningSecret: []byte(signingSecret),
+        Public: public,
+    }, nil
+}
+
+func(f *FileSystemStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+
+    path, err := f.pathForKey(key)
+
+    if err != nil {
+        return err
+    }
+
+    err = os.MkdirAll(filepath.Dir(path), 0o755)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    defer os.Remove(tmp.Name())
+
+    _, err = io.Copy(tmp, body)
+
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    err = os.Rename(tmp.Name(), path)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(f *FileSystemStorage) Get(ctx context.Context, key string) (*Object, error) {
+
+    path, err := f.pathForKey(key)
+
+    if err != nil {
+        return nil, err
+    }
+
+    file, err := os.Open(path)
+
+    if err != nil {
+        if errors.Is(err, fs.ErrNotExist) {
+            return nil, &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "object not found"}
+        }
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    info, err := file.Stat()
+
+    if err != nil {
+        file.Close()
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    contentType := mime.TypeByExtension(filepath.Ext(path))
+
+    if contentType == "" {
+        contentType = "application/octet-stream"
+    }
+
+    return &Object{Body: file, ContentType: contentType, Size: info.Size()}, nil
+}
+
+func(f *FileSystemStorage) Delete(ctx context.Context, key string) error {
+
+    path, err := f.pathForKey(key)
+
+    if err != nil {
+        return err
+    }
+
+    err = os.Remove(path)
+
+    if err != nil && !errors.Is(err, fs.ErrNotExist) {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(f *FileSystemStorage) URL(key string) string {
+    return fmt.Sprintf("%s/%s", f.BaseURL, key)
+}
+
+func(f *FileSystemStorage) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+
+    _, err := f.pathForKey(key)
+
+    if err != nil {
+        return "", err
+    }
+
+    expires := time.Now().Add(expiry).Unix()
+
+    query := url.Values{}
+    query.Set("expires", strconv.FormatInt(expires, 10))
+    query.Set("signature", f.sign(key, expires))
+
+    return fmt.Sprintf("%s?%s", f.URL(key), query.Encode()), nil
+}
+
+func(f *FileSystemStorage) ServeObject(c *gin.Context) {
+
+    key := strings.TrimPrefix(c.Param("key"), "/")
+    signature := c.Query("signature")
+
+    if !f.Public || signature != "" {
+        expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+
+        if err != nil || !hmac.Equal([]byte(signature), []byte(f.sign(key, expires))) {
+            c.Error(&customerrors.CustomError{StatusCode: http.StatusForbidden, Msg: "invalid object signature"})
+            c.Abort()
+            return
+        }
+
+        if time.Now().Unix() > expires {
+            c.Error(&customerrors.CustomError{StatusCode: http.StatusForbidden, Msg: "object URL has expired"})
+            c.Abort()
+            return
+        }
+    }
+
+    object, err := f.Get(c.Request.Context(), key)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    defer object.Body.Close()
+
+    c.DataFromReader(http.StatusOK, object.Size, object.ContentType, object.Body, nil)
+}
+
+func(f *FileSystemStorage) sign(key string, expires int64) string {
+    mac := hmac.New(sha256.New, f.SigningSecret)
+    mac.Write([]byte(fmt.Sprintf("%s\n%d", key, expires)))
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+func(f *FileSystemStorage) pathForKey(key string) (string, error) {
+
+    path := filepath.Join(f.Root, filepath.FromSlash(key))
+
+    if key == "" || !strings.HasPrefix(path, f.Root + string(os.PathSeparator)) {
+        return "", &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "invalid object key"}
+    }
+
+    return path, nil
+}
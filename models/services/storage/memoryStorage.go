@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+)
+
+type memoryObject struct {
+    data []byte
+    contentType string
+}
+
+// Keeps objects in process memory. Meant for tests, nothing survives a restart
+type MemoryStorage struct {
+    mu sync.RWMutex
+    objects map[string]memoryObject
+}
+
+func NewMemoryStorage() *MemoryStorage {
+    return &MemoryStorage{objects: make(map[string]memoryObject)}
+}
+
+func(m *MemoryStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+
+    data, err := io.ReadAll(body)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.objects[key] = memoryObject{data: data, contentType: contentType}
+
+    return nil
+}
+
+func(m *MemoryStorage) Get(ctx context.Context, key string) (*Object, error) {
+
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    object, ok := m.objects[key]
+
+    if !ok {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "object not found"}
+    }
+
+    return &Object{
+        Body: io.NopCloser(bytes.NewReader(object.data)),
+        ContentType: object.contentType,
+        Size: int64(len(object.data)),
+    }, nil
+}
+
+func(m *MemoryStorage) Delete(ctx context.Context, key string) error {
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    delete(m.objects, key)
+
+    return nil
+}
+
+func(m *MemoryStorage) URL(key string) string {
+    return fmt.Sprintf("memory://%s", key)
+}
+
+func(m *MemoryStorage) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+    return fmt.Sprintf("%s?expires=%d", m.URL(key), time.Now().Add(expiry).Unix()), nil
+}
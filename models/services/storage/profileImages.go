@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// How long the URLs handed out for profile images work for
+const PROFILE_IMAGE_URL_EXPIRY = time.Hour
+
+// Profile images are stored as the object keys of their thumbnails, and only turned into presigned URLs as they are
+// handed out, so they can be read from a private bucket. Returns nil when there is no image
+func ProfileImageURLs(ctx context.Context, storageService IStorageService, keys *responses.ProfileImage) (*responses.ProfileImage, error) {
+
+    if keys == nil {
+        return nil, nil
+    }
+
+    urls := &responses.ProfileImage{}
+    sizes := map[*string]string{&urls.Small: keys.Small, &urls.Medium: keys.Medium, &urls.Large: keys.Large}
+
+    for url, key := range sizes {
+
+        if key == "" {
+            continue
+        }
+
+        presignedURL, err := storageService.PresignedURL(ctx, key, PROFILE_IMAGE_URL_EXPIRY)
+
+        if err != nil {
+            return nil, err
+        }
+
+        *url = presignedURL
+    }
+
+    return urls, nil
+}
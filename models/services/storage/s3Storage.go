@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type S3Storage struct {
+    client *s3.Client
+    presignClient *s3.PresignClient
+    bucket string
+    objectURLPrefix string
+}
+
+func NewS3Storage() (*S3Storage, error) {
+
+    bucket := os.Getenv("S3_BUCKET")
+    region := os.Getenv("S3_REGION")
+    endpoint := os.Getenv("S3_ENDPOINT")
+    accessKeyID := os.Getenv("S3_ACCESS_KEY_ID")
+    secretAccessKey := os.Getenv("S3_SECRET_ACCESS_KEY")
+    publicURL := os.Getenv("S3_PUBLIC_URL")
+
+    if bucket == "" {
+        bucket = "tunes-profile-pictures"
+    }
+
+    if region == "" {
+        region = "us-east-1"
+    }
+
+    loadOptions := []func(*config.LoadOptions) error{config.WithRegion(region)}
+
+    if accessKeyID != "" && secretAccessKey != "" {
+        loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")))
+    }
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), loadOptions...)
+
+	if err != nil {
+        return nil, err
+    }
+
+    client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+        if endpoint != "" {
+            o.BaseEndpoint = aws.String(endpoint)
+            o.UsePathStyle = true
+        }
+    })
+
+    if publicURL == "" && endpoint != "" {
+        publicURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(endpoint, "/"), bucket)
+    } else if publicURL == "" {
+        publicURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+    }
+
+    return &S3Storage{
+        client: client,
+        presignClient: s3.NewPresignClient(client),
+        bucket: bucket,
+        objectURLPrefix: strings.TrimSuffix(publicURL, "/"),
+    }, nil
+}
+
+func(s *S3Storage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+    putObjectInput := &s3.PutObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key: aws.String(key),
+        Body: body,
+        ContentType: aws.String(contentType),
+    }
+
+    _, err := s.client.PutObject(ctx, putObjectInput)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(s *S3Storage) Get(ctx context.Context, key string) (*Object, error) {
+    getObjectInput := &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key: aws.String(key),
+    }
+
+    getObjectOutput, err := s.client.GetObject(ctx, getObjectInput)
+
+    if err != nil {
+        var noSuchKey *types.NoSuchKey
+        if errors.As(err, &noSuchKey) {
+            return nil, &customerrors.CustomError{StatusCode: http.StatusNotFound, Msg: "object not found"}
+        }
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    object := &Object{
+        Body: getObjectOutput.Body,
+        ContentType: aws.ToString(getObjectOutput.ContentType),
+        Size: aws.ToInt64(getObjectOutput.ContentLength),
+    }
+
+    return object, nil
+}
+
+func(s *S3Storage) Delete(ctx context.Context, key string) error {
+    deleteObjectInput := &s3.DeleteObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key: aws.String(key),
+    }
+
+    _, err := s.client.DeleteObject(ctx, deleteObjectInput)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+func(s *S3Storage) URL(key string) string {
+    return fmt.Sprintf("%s/%s", s.objectURLPrefix, key)
+}
+
+func(s *S3Storage) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+    getObjectInput := &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key: aws.String(key),
+    }
+
+    presignedRequest, err := s.presignClient.PresignGetObject(ctx, getObjectInput, s3.WithPresignExpires(expiry))
+
+    if err != nil {
+        return "", customerrors.WrapBasicError(err)
+    }
+
+    return presignedRequest.URL, nil
+}
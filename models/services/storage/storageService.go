@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Backend string
+
+const (
+    S3 Backend = "s3"
+    FILESYSTEM Backend = "filesystem"
+    MEMORY Backend = "memory"
+)
+
+type Object struct {
+    Body io.ReadCloser
+    ContentType string
+    Size int64
+}
+
+type IStorageService interface {
+    Put(ctx context.Context, key string, body io.Reader, contentType string) error
+    Get(ctx context.Context, key string) (*Object, error)
+    Delete(ctx context.Context, key string) error
+    URL(key string) string
+    PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Storage backends that serve their own objects over HTTP, rather than from a bucket
+type IServableStorageService interface {
+    IStorageService
+    ServeObject(c *gin.Context)
+}
+
+func NewStorageServiceFromEnv() (IStorageService, error) {
+
+    backend := Backend(os.Getenv("STORAGE_BACKEND"))
+
+    switch backend {
+        case S3:
+            return NewS3Storage()
+        case FILESYSTEM, "":
+            return NewFileSystemStorage()
+        case MEMORY:
+            return NewMemoryStorage(), nil
+        default:
+            return nil, fmt.Errorf("unknown storage backend %q", backend)
+    }
+}
@@ -0,0 +1,223 @@
+package subjects
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sort"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/catalog"
+	"github.com/Jack-Gitter/tunes/models/services/tokenbroker"
+	"github.com/gin-gonic/gin"
+)
+
+// how many reviews of each kind a song or album page shows
+const SUBJECT_PAGE_REVIEWS = 10
+
+type SubjectsService struct {
+    DB *sql.DB
+    PostsDAO daos.IPostsDAO
+    SubjectStatsDAO daos.ISubjectStatsDAO
+    CatalogService catalog.ICatalogService
+    TokenBroker tokenbroker.ITokenBroker
+    RatingScale responses.RatingScale
+}
+
+type ISubjectsService interface {
+    GetSongPage(c *gin.Context)
+    GetAlbumPage(c *gin.Context)
+}
+
+// @Summary Gets the page of a song
+// @Description Gets a song from the catalog along with its rating statistics, its most liked reviews and the reviews of users the current user follows
+// @Tags Subjects
+// @Accept json
+// @Produce json
+// @Param songID path string true "The Spotify ID of the song"
+// @Success 200 {object} responses.SongPage
+// @Failure 401 {string} string 
+// @Failure 404 {string} string 
+// @Failure 500 {string} string 
+// @Router /songs/{songID} [get]
+// @Security Bearer
+func(s *SubjectsService) GetSongPage(c *gin.Context) {
+
+    spotifyID, spotifyIDExists := c.Get("spotifyID")
+    sessionID, sessionIDExists := c.Get("sessionID")
+    songID := c.Param("songID")
+
+    if !spotifyIDExists || !sessionIDExists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    spotifyAccessToken, err := s.TokenBroker.GetAccessToken(c.Request.Context(), sessionID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    track, err := s.CatalogService.GetTrack(c.Request.Context(), songID, spotifyAccessToken)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    page := &responses.SongPage{Track: *track}
+
+    err = s.readPage(responses.TRACK, songID, spotifyID.(string), &page.Stats, &page.MostLikedReviews, &page.FollowingReviews, nil)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, page)
+}
+
+// @Summary Gets the page of an album
+// @Description Gets an album from the catalog along with the rating statistics of the album and of its tracks, and its most liked reviews and the reviews of users the current user follows. The reviews include those of the albums tracks
+// @Tags Subjects
+// @Accept json
+// @Produce json
+// @Param albumID path string true "The Spotify ID of the album"
+// @Success 200 {object} responses.AlbumPage
+// @Failure 401 {string} string 
+// @Failure 404 {string} string 
+// @Failure 500 {string} string 
+// @Router /albums/{albumID} [get]
+// @Security Bearer
+func(s *SubjectsService) GetAlbumPage(c *gin.Context) {
+
+    spotifyID, spotifyIDExists := c.Get("spotifyID")
+    sessionID, sessionIDExists := c.Get("sessionID")
+    albumID := c.Param("albumID")
+
+    if !spotifyIDExists || !sessionIDExists {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    spotifyAccessToken, err := s.TokenBroker.GetAccessToken(c.Request.Context(), sessionID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    album, err := s.CatalogService.GetAlbum(c.Request.Context(), albumID, spotifyAccessToken)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    page := &responses.AlbumPage{Album: *album}
+
+    err = s.readPage(responses.ALBUM, albumID, spotifyID.(string), &page.Stats, &page.MostLikedReviews, &page.FollowingReviews, &page.TrackStats)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, page)
+}
+
+// Reads everything on the page of a subject from one snapshot, so the statistics agree with the reviews. trackStats is
+// only filled for albums
+func(s *SubjectsService) readPage(subjectType responses.SubjectType, subjectID string, viewerSpotifyID string, stats *responses.RatingStats, mostLiked *[]responses.PostPreview, following *[]responses.PostPreview, trackStats *responses.RatingStats) error {
+
+    tx, err := s.DB.BeginTx(context.Background(), nil)
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    defer tx.Rollback()
+
+    err = db.SetTransactionIsolationLevel(tx, sql.LevelRepeatableRead)
+
+    if err != nil {
+        return err
+    }
+
+    subjectStats, err := s.SubjectStatsDAO.GetRatingStats(tx, subjectType, subjectID)
+
+    if err != nil {
+        return err
+    }
+
+    subjectStats.Histogram = fillHistogram(subjectStats.Histogram, s.RatingScale)
+    *stats = *subjectStats
+
+    if trackStats != nil {
+        albumTrackStats, err := s.SubjectStatsDAO.GetAlbumTrackRatingStats(tx, subjectID)
+
+        if err != nil {
+            return err
+        }
+
+        albumTrackStats.Histogram = fillHistogram(albumTrackStats.Histogram, s.RatingScale)
+        *trackStats = *albumTrackStats
+    }
+
+    *mostLiked, err = s.PostsDAO.GetMostLikedSubjectPosts(tx, subjectType, subjectID, viewerSpotifyID, SUBJECT_PAGE_REVIEWS)
+
+    if err != nil {
+        return err
+    }
+
+    *following, err = s.PostsDAO.GetFollowedSubjectPosts(tx, subjectType, subjectID, viewerSpotifyID, SUBJECT_PAGE_REVIEWS)
+
+    if err != nil {
+        return err
+    }
+
+    err = tx.Commit()
+
+    if err != nil {
+        return customerrors.WrapBasicError(err)
+    }
+
+    return nil
+}
+
+// Adds an empty bucket for every rating on the scale that has not been given. Ratings given under an earlier scale keep
+// their buckets
+func fillHistogram(histogram []responses.RatingBucket, scale responses.RatingScale) []responses.RatingBucket {
+
+    counts := map[responses.Rating]int{}
+
+    for _, bucket := range histogram {
+        counts[bucket.Rating] = bucket.Count
+    }
+
+    for _, rating := range scale.Ratings() {
+        counts[rating] += 0
+    }
+
+    filled := []responses.RatingBucket{}
+
+    for rating, count := range counts {
+        filled = append(filled, responses.RatingBucket{Rating: rating, Count: count})
+    }
+
+    sort.Slice(filled, func(i, j int) bool { return filled[i].Rating < filled[j].Rating })
+
+    return filled
+}
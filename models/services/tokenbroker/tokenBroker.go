@@ -0,0 +1,166 @@
+package tokenbroker
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/Jack-Gitter/tunes/db"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/Jack-Gitter/tunes/models/services/encryption"
+	"github.com/Jack-Gitter/tunes/models/services/spotify"
+)
+
+// Access tokens this close to expiring are refreshed first, so they do not expire on the way to Spotify
+const REFRESH_MARGIN = time.Minute
+
+// Keeps the Spotify tokens of each session encrypted in the database, and hands out access tokens that are good to
+// use, refreshing them with Spotify when they have expired
+type TokenBroker struct {
+    DB *sql.DB
+    SessionsDAO daos.ISessionsDAO
+    SpotifyService spotify.ISpotifyService
+    EncryptionService encryption.IEncryptionService
+}
+
+type SpotifyTokens struct {
+    AccessToken string
+    RefreshToken string
+    AccessTokenExpiresAt time.Time
+}
+
+type ITokenBroker interface {
+    SealTokens(sessionID string, tokens *SpotifyTokens) (*responses.EncryptedSpotifyTokens, error)
+    GetAccessToken(ctx context.Context, sessionID string) (string, error)
+}
+
+// Encrypts tokens for storing on the session sessionID. They cannot be opened for any other session
+func(t *TokenBroker) SealTokens(sessionID string, tokens *SpotifyTokens) (*responses.EncryptedSpotifyTokens, error) {
+
+    keyID, dataKey, ciphertexts, err := t.EncryptionService.Seal(sessionID, tokens.AccessToken, tokens.RefreshToken)
+
+    if err != nil {
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    return &responses.EncryptedSpotifyTokens{
+        KeyID: keyID,
+        DataKey: dataKey,
+        AccessToken: ciphertexts[0],
+        RefreshToken: ciphertexts[1],
+        AccessTokenExpiresAt: tokens.AccessTokenExpiresAt,
+    }, nil
+}
+
+// Tokens sealed with a key that is no longer the active one are sealed again while they are open, so rotating keys needs
+// no migration
+func(t *TokenBroker) GetAccessToken(ctx context.Context, sessionID string) (string, error) {
+
+    encryptedTokens, err := t.SessionsDAO.GetSpotifyTokens(t.DB, sessionID, false)
+
+    if err != nil {
+        return "", err
+    }
+
+    tokens, err := t.openTokens(sessionID, encryptedTokens)
+
+    if err != nil {
+        return "", err
+    }
+
+    if isFresh(tokens) && t.EncryptionService.IsActiveKey(encryptedTokens.KeyID) {
+        return tokens.AccessToken, nil
+    }
+
+    transaction := func() error {
+
+        tx, err := t.DB.BeginTx(ctx, nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        // Another request may have refreshed the tokens while this one was waiting on the lock
+        encryptedTokens, err := t.SessionsDAO.GetSpotifyTokens(tx, sessionID, true)
+
+        if err != nil {
+            return err
+        }
+
+        tokens, err = t.openTokens(sessionID, encryptedTokens)
+
+        if err != nil {
+            return err
+        }
+
+        if !isFresh(tokens) {
+            refreshTokenResponse, err := t.SpotifyService.RetreiveAccessTokenFromRefreshToken(ctx, tokens.RefreshToken)
+
+            if err != nil {
+                return err
+            }
+
+            tokens.AccessToken = refreshTokenResponse.Access_token
+            tokens.AccessTokenExpiresAt = time.Now().Add(time.Duration(refreshTokenResponse.Expires_in) * time.Second)
+
+            // Spotify only sometimes hands out a new refresh token, and the old one keeps working when it does not
+            if refreshTokenResponse.Refresh_token != "" {
+                tokens.RefreshToken = refreshTokenResponse.Refresh_token
+            }
+        } else if t.EncryptionService.IsActiveKey(encryptedTokens.KeyID) {
+            return nil
+        }
+
+        encryptedTokens, err = t.SealTokens(sessionID, tokens)
+
+        if err != nil {
+            return err
+        }
+
+        err = t.SessionsDAO.UpdateSpotifyTokens(tx, sessionID, encryptedTokens)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    err = db.RunTransactionWithExponentialBackoff(transaction, 5)
+
+    if err != nil {
+        return "", err
+    }
+
+    return tokens.AccessToken, nil
+}
+
+func(t *TokenBroker) openTokens(sessionID string, encryptedTokens *responses.EncryptedSpotifyTokens) (*SpotifyTokens, error) {
+
+    plaintexts, err := t.EncryptionService.Open(sessionID, encryptedTokens.KeyID, encryptedTokens.DataKey, encryptedTokens.AccessToken, encryptedTokens.RefreshToken)
+
+    if err != nil {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "could not decrypt spotify tokens"}
+    }
+
+    return &SpotifyTokens{
+        AccessToken: plaintexts[0],
+        RefreshToken: plaintexts[1],
+        AccessTokenExpiresAt: encryptedTokens.AccessTokenExpiresAt,
+    }, nil
+}
+
+func isFresh(tokens *SpotifyTokens) bool {
+    return time.Now().Add(REFRESH_MARGIN).Before(tokens.AccessTokenExpiresAt)
+}
@@ -7,17 +7,26 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"reflect"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/Jack-Gitter/tunes/cursor"
 	"github.com/Jack-Gitter/tunes/db"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/daos"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/Jack-Gitter/tunes/models/services/cache"
-	"github.com/Jack-Gitter/tunes/models/services/s3Service"
+	"github.com/Jack-Gitter/tunes/models/services/emails"
+	"github.com/Jack-Gitter/tunes/models/services/images"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
+	"github.com/Jack-Gitter/tunes/models/services/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
@@ -25,9 +34,16 @@ import (
 type UserService struct {
     DB *sql.DB
     UsersDAO daos.IUsersDAO
+    FeedDAO daos.IFeedDAO
     CacheService cache.ICacheService
     TTL time.Duration
-    S3Service s3Service.Is3Service
+    StorageService storage.IStorageService
+    ImageService images.IImageService
+    OutboxDAO daos.IOutboxDAO
+    NotificationsDAO daos.INotificationsDAO
+    RealtimeService realtime.IRealtimeService
+    PreferencesDAO daos.IPreferencesDAO
+    EmailDispatcher emails.IEmailDispatcher
 }
 
 type IUserSerivce interface {
@@ -44,6 +60,8 @@ type IUserSerivce interface {
     UpsertUserProfilePicture(c *gin.Context)
     DeleteCurrentUser(c *gin.Context)
     DeleteUserByID(c *gin.Context)
+    GetCurrentUserPreferences(c *gin.Context)
+    UpdateCurrentUserPreferences(c *gin.Context)
 }
 
 // @Summary Gets a tunes user by their spotify ID
@@ -76,6 +94,15 @@ func(u *UserService) GetUserById(c *gin.Context) {
         userResponse := &responses.User{}
         bytesReader := bytes.NewReader(userBytes)
         gob.NewDecoder(bytesReader).Decode(userResponse)
+
+        err = u.withProfileImageURL(c.Request.Context(), userResponse)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
         c.JSON(http.StatusOK, userResponse)
         return
     }
@@ -96,6 +123,14 @@ func(u *UserService) GetUserById(c *gin.Context) {
 		return
 	}
 
+    err = u.withProfileImageURL(c.Request.Context(), user)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, user)
 }
 
@@ -128,7 +163,39 @@ func(u *UserService) UnFollowUser(c *gin.Context) {
 		return
 	}
 
-	err := u.UsersDAO.UnfollowUser(u.DB, spotifyID.(string), otherUserSpotifyID)
+    transaction := func() error {
+
+        tx, err := u.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        err = u.UsersDAO.UnfollowUser(tx, spotifyID.(string), otherUserSpotifyID)
+
+        if err != nil {
+            return err
+        }
+
+        err = u.FeedDAO.PruneUnfollow(tx, spotifyID.(string), otherUserSpotifyID)
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+
+    }
+
+    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
 
 	if err != nil {
 		c.Error(err)
@@ -146,8 +213,10 @@ func(u *UserService) UnFollowUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param spotifyID path string true "User spotify ID"
-// @Param spotifyID query string false "Pagination Key for follow up responses. This key is a spotify ID"
-// @Success 200 {object} responses.PaginationResponse[[]responses.User, string]
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.User]
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
@@ -155,16 +224,16 @@ func(u *UserService) UnFollowUser(c *gin.Context) {
 // @Security Bearer
 func(u *UserService) GetFollowersByID(c *gin.Context) {
 	spotifyID := c.Param("spotifyID")
-	paginationKey := c.Query("spotifyID")
+	page, err := cursor.ParsePageRequest(c)
 
-	if paginationKey == "" {
-		paginationKey = "0"
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
     tx, err := u.DB.BeginTx(context.Background(), nil)
 
-    paginatedFollowers := responses.PaginationResponse[[]responses.User, string]{PaginationKey: paginationKey}
-
     if err != nil {
         c.Error(customerrors.WrapBasicError(err))
         c.Abort()
@@ -189,12 +258,7 @@ func(u *UserService) GetFollowersByID(c *gin.Context) {
         return
     }
 
-	followers, err := u.UsersDAO.GetUserFollowers(tx, spotifyID, paginationKey)
-    paginatedFollowers.DataResponse = followers
-
-    if len(followers) > 0 {
-        paginatedFollowers.PaginationKey = followers[len(followers)-1].SpotifyID
-    }
+	followers, err := u.UsersDAO.GetUserFollowers(tx, spotifyID, page)
 
 	if err != nil {
 		c.Error(err)
@@ -202,6 +266,14 @@ func(u *UserService) GetFollowersByID(c *gin.Context) {
 		return
 	}
 
+    paginatedFollowers, err := cursor.BuildPage(followers, page, userKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
     err = tx.Commit()
 
     if err != nil {
@@ -210,6 +282,14 @@ func(u *UserService) GetFollowersByID(c *gin.Context) {
         return
     }
 
+    err = u.withProfileImageURLs(c.Request.Context(), paginatedFollowers.DataResponse)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, paginatedFollowers)
 
 }
@@ -220,8 +300,10 @@ func(u *UserService) GetFollowersByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param spotifyID path string true "User spotify ID"
-// @Param spotifyID query string false "Pagination Key for follow up responses. This key is a spotify ID"
-// @Success 200 {object} responses.PaginationResponse[[]responses.User, string]
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.User]
+// @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 404 {string} string 
 // @Failure 500 {string} string 
@@ -229,16 +311,16 @@ func(u *UserService) GetFollowersByID(c *gin.Context) {
 // @Security Bearer
 func(u *UserService) GetFollowingByID(c *gin.Context) {
 	spotifyID := c.Param("spotifyID")
-	paginationKey := c.Query("spotifyID")
+	page, err := cursor.ParsePageRequest(c)
 
-	if paginationKey == "" {
-		paginationKey = "0"
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
     tx, err := u.DB.BeginTx(context.Background(), nil)
 
-    paginatedFollowers := responses.PaginationResponse[[]responses.User, string]{PaginationKey: paginationKey}
-
     if err != nil {
         c.Error(customerrors.WrapBasicError(err))
         c.Abort()
@@ -263,13 +345,7 @@ func(u *UserService) GetFollowingByID(c *gin.Context) {
         return
     }
 
-	followers, err := u.UsersDAO.GetUserFollowing(tx, spotifyID, paginationKey)
-
-    paginatedFollowers.DataResponse = followers
-
-    if len(followers) > 0 {
-        paginatedFollowers.PaginationKey = followers[len(followers)-1].SpotifyID
-    }
+	followers, err := u.UsersDAO.GetUserFollowing(tx, spotifyID, page)
 
 	if err != nil {
 		c.Error(err)
@@ -277,6 +353,14 @@ func(u *UserService) GetFollowingByID(c *gin.Context) {
 		return
 	}
 
+    paginatedFollowers, err := cursor.BuildPage(followers, page, userKey)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
     err = tx.Commit()
 
     if err != nil {
@@ -285,6 +369,14 @@ func(u *UserService) GetFollowingByID(c *gin.Context) {
         return
     }
 
+    err = u.withProfileImageURLs(c.Request.Context(), paginatedFollowers.DataResponse)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, paginatedFollowers)
 
 }
@@ -294,8 +386,9 @@ func(u *UserService) GetFollowingByID(c *gin.Context) {
 // @Tags Users
 // @Accept json
 // @Produce json
-// @Param spotifyID query string false "Pagination Key for follow up responses. This key is a spotify ID"
-// @Success 200 {object} responses.PaginationResponse[[]responses.User, string]
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.User]
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 500 {string} string 
@@ -304,7 +397,6 @@ func(u *UserService) GetFollowingByID(c *gin.Context) {
 func(u *UserService) GetFollowers(c *gin.Context) {
 
 	spotifyID, found := c.Get("spotifyID")
-	paginationKey := c.Query("spotifyID")
 
 	if !found {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "Jwt issue"})
@@ -312,8 +404,12 @@ func(u *UserService) GetFollowers(c *gin.Context) {
 		return
 	}
 
-	if paginationKey == "" {
-		paginationKey = "0"
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
     tx, err := u.DB.BeginTx(context.Background(), nil)
@@ -342,7 +438,7 @@ func(u *UserService) GetFollowers(c *gin.Context) {
         return
     }
 
-	followers, err := u.UsersDAO.GetUserFollowers(tx, spotifyID.(string), paginationKey)
+	followers, err := u.UsersDAO.GetUserFollowers(tx, spotifyID.(string), page)
 
 
 	if err != nil {
@@ -351,14 +447,13 @@ func(u *UserService) GetFollowers(c *gin.Context) {
 		return
 	}
 
-    paginatedFollowers := responses.PaginationResponse[[]responses.User, string]{}
-    paginatedFollowers.DataResponse = followers
+    paginatedFollowers, err := cursor.BuildPage(followers, page, userKey)
 
-    resultPaginationKey := "0"
-    if len(followers) > 0 {
-        resultPaginationKey = followers[len(followers)-1].SpotifyID
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
     }
-    paginatedFollowers.PaginationKey = resultPaginationKey
 
     err = tx.Commit()
 
@@ -368,6 +463,14 @@ func(u *UserService) GetFollowers(c *gin.Context) {
         return
     }
 
+    err = u.withProfileImageURLs(c.Request.Context(), paginatedFollowers.DataResponse)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, paginatedFollowers)
 
 }
@@ -377,8 +480,9 @@ func(u *UserService) GetFollowers(c *gin.Context) {
 // @Tags Users
 // @Accept json
 // @Produce json
-// @Param spotifyID query string false "Pagination Key for follow up responses. This key is a spotify ID"
-// @Success 200 {object} responses.PaginationResponse[[]responses.User, string]
+// @Param cursor query string false "Opaque cursor from a previous response, used to fetch the next or previous page"
+// @Param pageSize query int false "Number of results per page, defaults to 25 with a maximum of 100"
+// @Success 200 {object} responses.PaginationResponse[[]responses.User]
 // @Failure 400 {string} string 
 // @Failure 401 {string} string 
 // @Failure 500 {string} string 
@@ -386,7 +490,6 @@ func(u *UserService) GetFollowers(c *gin.Context) {
 // @Security Bearer
 func(u *UserService) GetFollowing(c *gin.Context) {
 	spotifyID, found := c.Get("spotifyID")
-	paginationKey := c.Query("spotifyID")
 
 	if !found {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "Jwt issue"})
@@ -394,8 +497,12 @@ func(u *UserService) GetFollowing(c *gin.Context) {
 		return
 	}
 
-	if paginationKey == "" {
-		paginationKey = "0"
+	page, err := cursor.ParsePageRequest(c)
+
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
 	}
 
     tx, err := u.DB.BeginTx(context.Background(), nil)
@@ -424,7 +531,7 @@ func(u *UserService) GetFollowing(c *gin.Context) {
         return
     }
 
-	followers, err := u.UsersDAO.GetUserFollowing(tx, spotifyID.(string), paginationKey)
+	followers, err := u.UsersDAO.GetUserFollowing(tx, spotifyID.(string), page)
 
 	if err != nil {
 		c.Error(err)
@@ -440,14 +547,21 @@ func(u *UserService) GetFollowing(c *gin.Context) {
         return
     }
 
-    followersPaginated := responses.PaginationResponse[[]responses.User, string]{DataResponse: followers}
+    followersPaginated, err := cursor.BuildPage(followers, page, userKey)
 
-    resultPaginationKey := "0"
-    if len(followers) > 0 {
-        resultPaginationKey = followers[len(followers)-1].SpotifyID
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
     }
 
-    followersPaginated.PaginationKey = resultPaginationKey
+    err = u.withProfileImageURLs(c.Request.Context(), followersPaginated.DataResponse)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
 
 	c.JSON(http.StatusOK, followersPaginated)
     
@@ -469,6 +583,7 @@ func(u *UserService) GetFollowing(c *gin.Context) {
 func(u *UserService) FollowUser(c *gin.Context) {
 	otherUserSpotifyID := c.Param("otherUserSpotifyID")
 	spotifyID, found := c.Get("spotifyID")
+	spotifyUsername, usernameFound := c.Get("spotifyUsername")
 
 
 	if otherUserSpotifyID == spotifyID {
@@ -477,13 +592,79 @@ func(u *UserService) FollowUser(c *gin.Context) {
 		return
 	}
 
-	if !found {
+	if !found || !usernameFound {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "JWT fuckup"})
 		c.Abort()
 		return
 	}
 
-	err := u.UsersDAO.FollowUser(u.DB, spotifyID.(string), otherUserSpotifyID)
+    pushes := []realtime.Push{}
+
+    transaction := func() error {
+
+        tx, err := u.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        err = u.UsersDAO.FollowUser(tx, spotifyID.(string), otherUserSpotifyID)
+
+        if err != nil {
+            return err
+        }
+
+        err = u.FeedDAO.BackfillFollow(tx, spotifyID.(string), otherUserSpotifyID)
+
+        if err != nil {
+            return err
+        }
+
+        payload := events.UserFollowedPayload{
+            FollowerSpotifyID: spotifyID.(string),
+            FollowerUsername: spotifyUsername.(string),
+            FollowedSpotifyID: otherUserSpotifyID,
+        }
+
+        event := events.New(events.USER_FOLLOWED, payload)
+        err = u.OutboxDAO.EnqueueEvent(tx, event)
+
+        if err != nil {
+            return err
+        }
+
+        notificationID, err := u.NotificationsDAO.Notify(tx, otherUserSpotifyID, responses.FOLLOWED, spotifyID.(string), nil, nil)
+
+        if err != nil {
+            return err
+        }
+
+        // Following someone again after unfollowing them does not email them again
+        if notificationID != 0 {
+            err = u.EmailDispatcher.Dispatch(tx, event, []string{otherUserSpotifyID})
+
+            if err != nil {
+                return err
+            }
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        if notificationID != 0 {
+            pushes = []realtime.Push{realtime.NotificationPush(notificationID, otherUserSpotifyID, responses.FOLLOWED, spotifyID.(string))}
+        }
+
+        return nil
+
+    }
+
+    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
 
 	if err != nil {
 		c.Error(err)
@@ -491,6 +672,8 @@ func(u *UserService) FollowUser(c *gin.Context) {
 		return
 	}
 
+    u.RealtimeService.Publish(pushes...)
+
 	c.Status(http.StatusNoContent)
 
 }
@@ -530,6 +713,15 @@ func(u *UserService) GetCurrentUser(c *gin.Context) {
         userResponse := &responses.User{}
         bytesReader := bytes.NewReader(userBytes)
         gob.NewDecoder(bytesReader).Decode(userResponse)
+
+        err = u.withProfileImageURL(c.Request.Context(), userResponse)
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
         c.JSON(http.StatusOK, userResponse)
         return
     }
@@ -550,6 +742,14 @@ func(u *UserService) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
+    err = u.withProfileImageURL(c.Request.Context(), user)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, user)
 }
 
@@ -572,8 +772,9 @@ func(u *UserService) UpdateUserByID(c *gin.Context) {
 
 	userUpdateRequest := &requests.UpdateUserRequestDTO{}
 	spotifyID := c.Param("spotifyID")
+	requestorSpotifyID, found := c.Get("spotifyID")
 
-	if spotifyID == "" {
+	if spotifyID == "" || !found {
 		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "JwtFuckup"})
 		c.Abort()
 		return
@@ -582,7 +783,7 @@ func(u *UserService) UpdateUserByID(c *gin.Context) {
 	c.ShouldBindBodyWithJSON(userUpdateRequest)
 
 
-    resp, err := u.UsersDAO.UpdateUser(u.DB, spotifyID, userUpdateRequest)
+    resp, err := u.updateUser(spotifyID, userUpdateRequest, requestorSpotifyID.(string))
 
 	if err != nil {
 		c.Error(err)
@@ -606,6 +807,14 @@ func(u *UserService) UpdateUserByID(c *gin.Context) {
 		return
 	}
 
+    err = u.withProfileImageURL(c.Request.Context(), resp)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -636,7 +845,7 @@ func(u *UserService) UpdateCurrentUser(c *gin.Context) {
 
 	c.ShouldBindBodyWithJSON(userUpdateRequest)
 
-    resp, err := u.UsersDAO.UpdateUser(u.DB, spotifyID.(string), userUpdateRequest)
+    resp, err := u.updateUser(spotifyID.(string), userUpdateRequest, spotifyID.(string))
 
 	if err != nil {
 		c.Error(err)
@@ -661,6 +870,14 @@ func(u *UserService) UpdateCurrentUser(c *gin.Context) {
 	}
 
 
+    err = u.withProfileImageURL(c.Request.Context(), resp)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
 	c.JSON(http.StatusOK, resp)
 
 }
@@ -757,17 +974,381 @@ func(u *UserService) DeleteUserByID(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// @Summary Uploads a user profile picture
-// @Description Uploads a user profile picture
+// @Summary Uploads a profile picture for the current user
+// @Description Uploads a profile picture for the current user. Accepts a multipart form with a profilePicture file, or a raw octet-stream body. The image is resized into small, medium and large thumbnails
 // @Tags Users
-// @Accept json
+// @Accept multipart/form-data
+// @Accept octet-stream
 // @Produce json
-// @Success 204
-// @Router /users/current/uploadProfilePicture [POST]
+// @Param profilePicture formData file false "Profile picture (jpeg, png or gif)"
+// @Success 200 {object} responses.ProfileImage
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 413 {string} string
+// @Failure 415 {string} string
+// @Failure 500 {string} string
+// @Router /users/current/uploadProfilePicture [post]
 // @Security Bearer
 func(u *UserService) UpsertUserProfilePicture(c *gin.Context) {
 
-    fmt.Println("we are uploading the profile picture")
-    u.S3Service.UploadToBucket()
+	spotifyID, spotifyIdExists := c.Get("spotifyID")
+
+	if !spotifyIdExists {
+		c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "forgot to set JWT"})
+		c.Abort()
+		return
+	}
+
+    imageBytes, err := u.readProfilePictureUpload(c)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    thumbnails, err := u.ImageService.CreateThumbnails(imageBytes)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    keys := make(map[images.ThumbnailSize]string)
+
+    for size, thumbnail := range thumbnails {
+        key := fmt.Sprintf("%s/%s.jpg", spotifyID.(string), size)
+        err := u.StorageService.Put(c.Request.Context(), key, bytes.NewReader(thumbnail), "image/jpeg")
+
+        if err != nil {
+            c.Error(err)
+            c.Abort()
+            return
+        }
+
+        keys[size] = key
+    }
+
+    profileImage := &responses.ProfileImage{
+        Small: keys[images.SMALL],
+        Medium: keys[images.MEDIUM],
+        Large: keys[images.LARGE],
+    }
+
+    profileImage, err = u.UsersDAO.UpsertUserProfilePicture(u.DB, spotifyID.(string), profileImage)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    key, err := u.CacheService.GenerateKey(reflect.TypeOf(responses.User{}), cache.UserCacheKey{SpotifyID: spotifyID.(string)})
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    err = u.CacheService.Delete(key)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    profileImage, err = storage.ProfileImageURLs(c.Request.Context(), u.StorageService, profileImage)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, profileImage)
+}
+
+// @Summary Gets the current users notification preferences
+// @Description Gets how the current user is emailed about each type of event, either straight away, in a daily or weekly digest, or not at all. Event types the user has not set are returned with their defaults
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Success 200 {array} responses.NotificationPreference
+// @Failure 401 {string} string 
+// @Failure 500 {string} string 
+// @Router /users/current/preferences [get]
+// @Security Bearer
+func(u *UserService) GetCurrentUserPreferences(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    preferences, err := u.PreferencesDAO.GetPreferences(u.DB, spotifyID.(string))
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, notificationPreferences(preferences))
+}
+
+// @Summary Updates the current users notification preferences
+// @Description Sets how the current user is emailed about the given types of event. Event types that are left out are not changed. Digests are sent at the same time every day, and weekly digests on Mondays
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param UpdateNotificationPreferencesDTO body requests.UpdateNotificationPreferencesDTO true "Delivery for each event type, one of OFF, INSTANT, DAILY or WEEKLY"
+// @Success 200 {array} responses.NotificationPreference
+// @Failure 400 {string} string 
+// @Failure 401 {string} string 
+// @Failure 500 {string} string 
+// @Router /users/current/preferences [patch]
+// @Security Bearer
+func(u *UserService) UpdateCurrentUserPreferences(c *gin.Context) {
+
+    spotifyID, found := c.Get("spotifyID")
+
+    if !found {
+        c.Error(&customerrors.CustomError{StatusCode: http.StatusInternalServerError, Msg: "bad jwt lookup"})
+        c.Abort()
+        return
+    }
+
+    updatePreferencesRequest := &requests.UpdateNotificationPreferencesDTO{}
+    c.ShouldBindBodyWithJSON(updatePreferencesRequest)
+
+    var preferences map[string]responses.NotificationDelivery
+
+    transaction := func() error {
+
+        tx, err := u.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        err = u.PreferencesDAO.UpsertPreferences(tx, spotifyID.(string), updatePreferencesRequest.Preferences)
+
+        if err != nil {
+            return err
+        }
+
+        preferences, err = u.PreferencesDAO.GetPreferences(tx, spotifyID.(string))
+
+        if err != nil {
+            return err
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
+
+    if err != nil {
+        c.Error(err)
+        c.Abort()
+        return
+    }
+
+    c.JSON(http.StatusOK, notificationPreferences(preferences))
+}
+
+func(u *UserService) readProfilePictureUpload(c *gin.Context) ([]byte, error) {
+
+    maxBytes := u.ImageService.GetMaxBytes()
+    tooLarge := &customerrors.CustomError{StatusCode: http.StatusRequestEntityTooLarge, Msg: fmt.Sprintf("profile picture must be at most %d bytes", maxBytes)}
+
+    // leave some room for the multipart boundaries and headers
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes + (1 << 20))
+
+    mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+    if err != nil {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusUnsupportedMediaType, Msg: "invalid Content-Type header"}
+    }
+
+    var reader io.Reader
+
+    switch mediaType {
+        case "multipart/form-data":
+            fileHeader, err := c.FormFile("profilePicture")
+
+            if err != nil {
+                var maxBytesError *http.MaxBytesError
+                if errors.As(err, &maxBytesError) {
+                    return nil, tooLarge
+                }
+                return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "form must contain a profilePicture file"}
+            }
+
+            if fileHeader.Size > maxBytes {
+                return nil, tooLarge
+            }
+
+            file, err := fileHeader.Open()
+
+            if err != nil {
+                return nil, customerrors.WrapBasicError(err)
+            }
+
+            defer file.Close()
+            reader = file
+        case "application/octet-stream":
+            reader = c.Request.Body
+        default:
+            return nil, &customerrors.CustomError{StatusCode: http.StatusUnsupportedMediaType, Msg: "MIME type must be multipart/form-data or application/octet-stream"}
+    }
+
+    imageBytes, err := io.ReadAll(io.LimitReader(reader, maxBytes + 1))
+
+    if err != nil {
+        var maxBytesError *http.MaxBytesError
+        if errors.As(err, &maxBytesError) {
+            return nil, tooLarge
+        }
+        return nil, customerrors.WrapBasicError(err)
+    }
+
+    if int64(len(imageBytes)) > maxBytes {
+        return nil, tooLarge
+    }
+
+    if len(imageBytes) == 0 {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "profile picture is empty"}
+    }
+
+    return imageBytes, nil
+}
+
+// Fills in the defaults for the event types the user has not set, in a stable order
+func notificationPreferences(preferences map[string]responses.NotificationDelivery) []responses.NotificationPreference {
+
+    result := []responses.NotificationPreference{}
+
+    for eventType, defaultDelivery := range events.DEFAULT_EMAIL_DELIVERIES {
+        delivery, found := preferences[string(eventType)]
+
+        if !found {
+            delivery = defaultDelivery
+        }
+
+        result = append(result, responses.NotificationPreference{EventType: string(eventType), Delivery: delivery})
+    }
+
+    slices.SortFunc(result, func(a responses.NotificationPreference, b responses.NotificationPreference) int {
+        return strings.Compare(a.EventType, b.EventType)
+    })
+
+    return result
+}
+
+// Users are stored and cached with the keys of their profile images, which are swapped for URLs just before responding
+func(u *UserService) withProfileImageURL(ctx context.Context, user *responses.User) error {
+
+    profileImage, err := storage.ProfileImageURLs(ctx, u.StorageService, user.ProfileImage)
+
+    if err != nil {
+        return err
+    }
+
+    user.ProfileImage = profileImage
+
+    return nil
+}
+
+func(u *UserService) withProfileImageURLs(ctx context.Context, users []responses.User) error {
+
+    for i := range users {
+        err := u.withProfileImageURL(ctx, &users[i])
+
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+func userKey(user responses.User) (string, string) {
+    return user.SpotifyID, user.SpotifyID
+}
+
+// Updates a user, publishing user.roleChanged when the update changes their role
+func(u *UserService) updateUser(spotifyID string, userUpdateRequest *requests.UpdateUserRequestDTO, requestorSpotifyID string) (*responses.User, error) {
+
+    resp := &responses.User{}
+
+    transaction := func() error {
+
+        tx, err := u.DB.BeginTx(context.Background(), nil)
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        defer tx.Rollback()
+
+        user, err := u.UsersDAO.GetUser(tx, spotifyID)
+
+        if err != nil {
+            return err
+        }
+
+        resp, err = u.UsersDAO.UpdateUser(tx, spotifyID, userUpdateRequest)
+
+        if err != nil {
+            return err
+        }
+
+        if resp.Role != user.Role {
+
+            payload := events.RoleChangedPayload{
+                SpotifyID: spotifyID,
+                OldRole: user.Role,
+                NewRole: resp.Role,
+                ChangedBySpotifyID: requestorSpotifyID,
+            }
+
+            err = u.OutboxDAO.EnqueueEvent(tx, events.New(events.USER_ROLE_CHANGED, payload))
+
+            if err != nil {
+                return err
+            }
+        }
+
+        err = tx.Commit()
+
+        if err != nil {
+            return customerrors.WrapBasicError(err)
+        }
+
+        return nil
+    }
+
+    err := db.RunTransactionWithExponentialBackoff(transaction, 5)
+
+    if err != nil {
+        return nil, err
+    }
 
+    return resp, nil
 }
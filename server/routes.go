@@ -1,6 +1,7 @@
 package server
 
 import (
+	"expvar"
 	"os"
 	"time"
 
@@ -9,7 +10,14 @@ import (
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/services/auth"
 	"github.com/Jack-Gitter/tunes/models/services/comments"
+	"github.com/Jack-Gitter/tunes/models/services/diary"
+	"github.com/Jack-Gitter/tunes/models/services/health"
+	"github.com/Jack-Gitter/tunes/models/services/notifications"
 	"github.com/Jack-Gitter/tunes/models/services/posts"
+	"github.com/Jack-Gitter/tunes/models/services/realtime"
+	"github.com/Jack-Gitter/tunes/models/services/search"
+	"github.com/Jack-Gitter/tunes/models/services/storage"
+	"github.com/Jack-Gitter/tunes/models/services/subjects"
 	"github.com/Jack-Gitter/tunes/models/services/users"
 	"github.com/Jack-Gitter/tunes/validation"
 	"github.com/gin-contrib/cors"
@@ -18,7 +26,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPostsService, commentsService comments.ICommentsService, authSerivce auth.IAuthService) *gin.Engine {
+func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPostsService, commentsService comments.ICommentsService, searchService search.ISearchService, authSerivce auth.IAuthService, storageService storage.IStorageService, healthService health.IHealthService, notificationsService notifications.INotificationsService, realtimeService realtime.IRealtimeService, subjectsService subjects.ISubjectsService, diaryService diary.IDiaryService) *gin.Engine {
 
     frontend_uri := os.Getenv("FRONTEND_URI")
 
@@ -32,12 +40,16 @@ func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPo
         },
     )
 
-	r := gin.Default()
+	r := gin.New()
 
+    // Stream tickets are passed in the query string, which the logger would otherwise write out
+    r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/stream"}}), gin.Recovery())
     r.Use(cors)
 
     baseGroup := r.Group("", customerrors.ErrorHandlerMiddleware) 
     {
+        baseGroup.GET("/.well-known/jwks.json", authSerivce.GetJWKS)
+
         loginGroup := baseGroup.Group("/login") 
         {
             loginGroup.GET("/", authSerivce.Login)
@@ -45,6 +57,12 @@ func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPo
             loginGroup.GET("/jwt", authSerivce.RefreshJWT)
         }
 
+        if servableStorage, ok := storageService.(storage.IServableStorageService); ok {
+            baseGroup.GET("/storage/*key", servableStorage.ServeObject)
+        }
+
+        baseGroup.GET("/stream", realtimeService.ValidateStreamTicket, realtimeService.Stream)
+
         authGroup := baseGroup.Group("", authSerivce.ValidateUserJWT) 
         {
 
@@ -60,12 +78,15 @@ func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPo
                 userGroup.POST("/current/uploadProfilePicture", userService.UpsertUserProfilePicture)
                 userGroup.DELETE("/current/unfollow/:otherUserSpotifyID", userService.UnFollowUser)
                 userGroup.PATCH("/current", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateUserRequestDTO), userService.UpdateCurrentUser)
+                userGroup.GET("/current/preferences", userService.GetCurrentUserPreferences)
+                userGroup.PATCH("/current/preferences", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateNotificationPreferencesDTO), userService.UpdateCurrentUserPreferences)
                 userGroup.DELETE("/current", userService.DeleteCurrentUser)
 
                 adminOnly := userGroup.Group("/admin", authSerivce.ValidateAdminUser)
                 {
                     adminOnly.PATCH("/:spotifyID", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateUserRequestDTO), userService.UpdateUserByID)
                     adminOnly.DELETE("/:spotifyID", userService.DeleteUserByID)
+                    adminOnly.DELETE("/:spotifyID/sessions", authSerivce.RevokeUserSessions)
                 }
 
             }
@@ -73,31 +94,65 @@ func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPo
             postGroup := authGroup.Group("/posts")
             {
 
-                postGroup.GET("/:spotifyID/:songID", postsService.GetPostBySpotifyIDAndSongID)
+                postGroup.GET("/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.GetPostByID)
                 postGroup.GET("/current/:songID", postsService.GetPostCurrentUserBySongID)
                 postGroup.GET("/previews/users/current", postsService.GetAllPostsForCurrentUser)
                 postGroup.GET("/previews/users/:spotifyID", postsService.GetAllPostsForUserByID)
-                postGroup.GET("/comments/:spotifyID/:songID", postsService.GetPostCommentsPaginated)
+                postGroup.GET("/comments/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.GetPostCommentsPaginated)
+                postGroup.GET("/votes/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.GetPostVoters)
                 postGroup.GET("/feed", postsService.GetCurrentUserFeed)
+                postGroup.GET("/albums/:albumID", postsService.GetAlbumPostsPaginated)
                 postGroup.POST("/", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateCreatePostDTO), postsService.CreatePostForCurrentUser)
-                postGroup.POST("/likes/:spotifyID/:songID", postsService.LikePost)
-                postGroup.POST("/dislikes/:spotifyID/:songID", postsService.DislikePost)
-                postGroup.PATCH("/current/:songID", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateUpdatePostRequestDTO), postsService.UpdateCurrentUserPost)
-                postGroup.DELETE("/current/:songID", postsService.DeletePostForCurrentUserBySongID)
-                postGroup.DELETE("/votes/current/:posterSpotifyID/:songID",  postsService.RemovePostVote)
+                postGroup.POST("/likes/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.LikePost)
+                postGroup.POST("/dislikes/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.DislikePost)
+                postGroup.PATCH("/current/:postID", validation.ValidateContentTypeJSON, validation.ValidatePathParams[requests.PostIDPathParams](), validation.ValidateData(validation.ValidateUpdatePostRequestDTO), postsService.UpdateCurrentUserPost)
+                postGroup.DELETE("/current/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.DeletePostForCurrentUser)
+                postGroup.DELETE("/votes/current/:postID", validation.ValidatePathParams[requests.PostIDPathParams](),  postsService.RemovePostVote)
 
                 adminOnly := postGroup.Group("/admin", authSerivce.ValidateAdminUser)
                 {
-                    adminOnly.DELETE("/:spotifyID/:songID", postsService.DeletePostBySpotifyIDAndSongID)
+                    adminOnly.DELETE("/:postID", validation.ValidatePathParams[requests.PostIDPathParams](), postsService.DeletePostByID)
                 }
 
             }
 
+            debugGroup := authGroup.Group("/debug", authSerivce.ValidateAdminUser)
+            {
+                debugGroup.GET("/vars", gin.WrapH(expvar.Handler()))
+            }
+
+            authGroup.POST("/stream/tickets", realtimeService.CreateStreamTicket)
+
+            authGroup.POST("/logout", authSerivce.Logout)
+            authGroup.POST("/logout/everywhere", authSerivce.LogoutEverywhere)
+
+            authGroup.GET("/search", searchService.Search)
+            authGroup.GET("/songs/:songID", subjectsService.GetSongPage)
+            authGroup.GET("/albums/:albumID", subjectsService.GetAlbumPage)
+
+            diaryGroup := authGroup.Group("/diary")
+            {
+                diaryGroup.GET("/recent", diaryService.GetListenCandidates)
+                diaryGroup.GET("/drafts", diaryService.GetDrafts)
+                diaryGroup.POST("/drafts", validation.ValidateContentTypeJSON, validation.ValidateData(validation.ValidateCreateDraftsDTO), diaryService.CreateDrafts)
+                diaryGroup.DELETE("/drafts/:draftID", validation.ValidatePathParams[requests.DraftIDPathParams](), diaryService.DeleteDraft)
+            }
+
+            notificationGroup := authGroup.Group("/notifications")
+            {
+                notificationGroup.GET("", notificationsService.GetNotifications)
+                notificationGroup.GET("/unreadCount", notificationsService.GetUnreadNotificationCount)
+                notificationGroup.POST("/read", notificationsService.MarkAllNotificationsRead)
+                notificationGroup.POST("/read/:notificationID", validation.ValidatePathParams[requests.NotificationIDPathParams](), notificationsService.MarkNotificationRead)
+            }
+
             commentGroup := authGroup.Group("/comments")
             {
 
                 commentGroup.GET("/:commentID",  validation.ValidatePathParams[requests.CommentIDPathParams](), commentsService.GetComment)
-                commentGroup.POST("/:spotifyID/:songID", validation.ValidateContentTypeJSON, validation.ValidateData[requests.CreateCommentDTO](), commentsService.CreateComment)
+                commentGroup.GET("/replies/:commentID", validation.ValidatePathParams[requests.CommentIDPathParams](), commentsService.GetCommentReplies)
+                commentGroup.GET("/votes/:commentID", validation.ValidatePathParams[requests.CommentIDPathParams](), commentsService.GetCommentVoters)
+                commentGroup.POST("/:postID", validation.ValidateContentTypeJSON, validation.ValidatePathParams[requests.PostIDPathParams](), validation.ValidateData[requests.CreateCommentDTO](), commentsService.CreateComment)
                 commentGroup.POST("/like/:commentID", validation.ValidatePathParams[requests.CommentIDPathParams](), commentsService.LikeComment)
                 commentGroup.POST("/dislike/:commentID", validation.ValidatePathParams[requests.CommentIDPathParams](), commentsService.DislikeComment)
                 commentGroup.PATCH("/current/:commentID", validation.ValidateContentTypeJSON, validation.ValidatePathParams[requests.CommentIDPathParams](), validation.ValidateData(validation.ValidateUpdateCommentDTO), commentsService.UpdateComment)
@@ -114,6 +169,7 @@ func InitializeHttpServer(userService users.IUserSerivce, postsService posts.IPo
     }
 
     r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
+    r.GET("/health", healthService.GetHealth)
 
 	return r
 }
@@ -0,0 +1,31 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/requests"
+	"github.com/gin-gonic/gin"
+)
+
+// As many listens as Spotify returns from recently played
+const MAX_DRAFTS_PER_REQUEST = 50
+
+func ValidateCreateDraftsDTO(createDraftsDTO requests.CreateDraftsDTO, c *gin.Context) error {
+
+    if len(createDraftsDTO.Listens) < 1 || len(createDraftsDTO.Listens) > MAX_DRAFTS_PER_REQUEST {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("Listens must have between 1 and %d listens", MAX_DRAFTS_PER_REQUEST)}
+    }
+
+    for _, listen := range createDraftsDTO.Listens {
+        if listen.TrackID == "" {
+            return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "every listen needs a TrackID"}
+        }
+        if listen.ListenedAt.IsZero() || listen.ListenedAt.After(time.Now()) {
+            return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "every listen needs a ListenedAt that is not in the future"}
+        }
+    }
+
+    return nil
+}
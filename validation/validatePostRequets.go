@@ -2,29 +2,59 @@ package validation
 
 import (
 	"net/http"
+	"time"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/gin-gonic/gin"
 )
 
 func ValidateUpdatePostRequestDTO(req requests.UpdatePostRequestDTO, c *gin.Context) error {
-    if req.Rating == nil && req.Review == nil {
+    if !req.Rating.Set && req.Review == nil {
         return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "one of the fields must be defined"}
     }
-    if req.Rating != nil && (*req.Rating < 0 || *req.Rating > 5) {
-        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad range for rating"}
-    }
-    return nil
+    return ValidateRating(req.Rating.Rating)
 
 }
 
 func ValidateCreatePostDTO(createPostDTO requests.CreatePostDTO, c *gin.Context) error {
-    if createPostDTO.SongID == nil {
-        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "Must provide a spotifyID"}
+    if createPostDTO.DraftID != nil {
+        if createPostDTO.SubjectType != nil || createPostDTO.SubjectID != nil || createPostDTO.SongID != nil || createPostDTO.ListenedAt != nil {
+            return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "SubjectType, SubjectID, SongID and ListenedAt come from the draft when DraftID is provided"}
+        }
+        return ValidateRating(createPostDTO.Rating)
+    }
+    if createPostDTO.SubjectType != nil && !responses.IsValidSubjectType(*createPostDTO.SubjectType) {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "SubjectType must be TRACK, ALBUM or ARTIST"}
+    }
+    if createPostDTO.SubjectID == nil && createPostDTO.SongID == nil {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "Must provide a SubjectID"}
+    }
+    if createPostDTO.SubjectID != nil && createPostDTO.SongID != nil {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "Only one of SubjectID and SongID can be provided"}
+    }
+    if createPostDTO.SongID != nil && createPostDTO.SubjectType != nil && *createPostDTO.SubjectType != responses.TRACK {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "SongID can only be provided for track posts, use SubjectID instead"}
+    }
+    if createPostDTO.SubjectID != nil && *createPostDTO.SubjectID == "" || createPostDTO.SongID != nil && *createPostDTO.SongID == "" {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "SubjectID cannot be empty"}
     }
-    if createPostDTO.Rating != nil && (*createPostDTO.Rating < 0 || *createPostDTO.Rating > 5) {
-        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "Rating must be between 0 and 5"}
+    if createPostDTO.ListenedAt != nil && createPostDTO.ListenedAt.After(time.Now()) {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "ListenedAt cannot be in the future"}
+    }
+
+    return ValidateRating(createPostDTO.Rating)
+}
+
+// Routes that look posts up by their subject only carry the subject ID. The type is read from the optional subjectType
+// query parameter, and is TRACK when it is missing
+func ParseSubjectTypeQuery(c *gin.Context) (responses.SubjectType, error) {
+
+    subjectType := responses.SubjectType(c.DefaultQuery("subjectType", string(responses.TRACK)))
+
+    if !responses.IsValidSubjectType(subjectType) {
+        return "", &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "subjectType must be TRACK, ALBUM or ARTIST"}
     }
 
-    return nil
+    return subjectType, nil
 }
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+)
+
+// The ratings posts accept. It is set from RATING_MIN, RATING_MAX and RATING_STEP before the server starts
+var RatingScale = responses.DEFAULT_RATING_SCALE
+
+// A nil rating is always valid, it is a review without a rating
+func ValidateRating(rating *responses.Rating) error {
+
+    if rating == nil || RatingScale.Contains(*rating) {
+        return nil
+    }
+
+    return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("Rating must be between %s and %s in steps of %s", RatingScale.Min, RatingScale.Max, RatingScale.Step)}
+}
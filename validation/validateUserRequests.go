@@ -1,8 +1,10 @@
 package validation
 
 import (
+	"fmt"
 	"net/http"
 	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/events"
 	"github.com/Jack-Gitter/tunes/models/dtos/requests"
 	"github.com/Jack-Gitter/tunes/models/dtos/responses"
 	"github.com/gin-gonic/gin"
@@ -44,3 +46,18 @@ func CanSetRole(currentUserRole responses.Role, roleToSet responses.Role) bool {
             return false
     }
 }
+
+func ValidateNotificationPreferencesDTO(req requests.UpdateNotificationPreferencesDTO, c *gin.Context) error {
+    if len(req.Preferences) < 1 {
+        return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "bad body"}
+    }
+    for eventType, delivery := range req.Preferences {
+        if _, found := events.DEFAULT_EMAIL_DELIVERIES[events.EventType(eventType)]; !found {
+            return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: fmt.Sprintf("cannot set preferences for %s events", eventType)}
+        }
+        if !responses.IsValidNotificationDelivery(delivery) {
+            return &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "Invalid delivery"}
+        }
+    }
+    return nil
+}
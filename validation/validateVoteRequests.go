@@ -0,0 +1,24 @@
+package validation
+
+import (
+	"net/http"
+	"github.com/Jack-Gitter/tunes/models/customerrors"
+	"github.com/Jack-Gitter/tunes/models/dtos/responses"
+	"github.com/gin-gonic/gin"
+)
+
+// Reads the optional vote query parameter used to filter voter lists. No filter is returned as nil
+func ParseVoteQuery(c *gin.Context) (*responses.Vote, error) {
+
+    vote := responses.Vote(c.Query("vote"))
+
+    if vote == "" {
+        return nil, nil
+    }
+
+    if !responses.IsValidVote(vote) {
+        return nil, &customerrors.CustomError{StatusCode: http.StatusBadRequest, Msg: "vote must be LIKE or DISLIKE"}
+    }
+
+    return &vote, nil
+}